@@ -1,18 +1,191 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
 	"log"
 	"net/http"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/dspo/go-homework/pkg"
+	"github.com/dspo/go-homework/pkg/bootstrap"
+	"github.com/dspo/go-homework/pkg/config"
+	"github.com/dspo/go-homework/pkg/database"
+	"github.com/dspo/go-homework/pkg/logging"
+	"github.com/dspo/go-homework/pkg/migrate"
+	"github.com/dspo/go-homework/pkg/server"
+	"github.com/dspo/go-homework/pkg/session"
 )
 
 const (
-	address = ":8080"
+	connectRetries  = 30
+	connectInterval = 2 * time.Second
+	// readinessDelay 是收到终止信号后、开始排空请求前的等待时长，
+	// 留给 readinessProbe 发现 /readyz 失败并将 Pod 从 Service 中摘除。
+	readinessDelay = 2 * time.Second
+	// gcInterval 是清理过期会话与登录失败计数的间隔。
+	gcInterval = 10 * time.Minute
 )
 
 func main() {
-	log.Printf("The HTTP Server is going to run on %s\n", address)
-	http.HandleFunc("/healthz", func(_ http.ResponseWriter, _ *http.Request) {})
-	if err := http.ListenAndServe(address, nil); err != nil {
-		log.Fatalf("failed to ListenAndServe on %s\n", address)
+	configPath := flag.String("config", "", "path to the config file, overrides $"+config.PathEnv+" (default "+config.DefaultPath+")")
+	flag.Parse()
+
+	// 日志级别来自配置，配置加载完成前只能使用标准库 log。
+	cfg, err := config.Load(config.Path(*configPath))
+	if err != nil {
+		log.Fatalf("failed to load config: %v\n", err)
+	}
+	lg, flush, err := logging.Setup(cfg.Log.Level)
+	if err != nil {
+		log.Fatalf("failed to init logger: %v\n", err)
+	}
+	defer flush()
+
+	db, err := openDatabase(lg, cfg.Database)
+	if err != nil {
+		lg.Fatal("failed to open database", zap.Error(err))
+	}
+
+	switch args := flag.Args(); {
+	case len(args) == 0 || args[0] == "serve":
+		serve(lg, cfg, db)
+	case args[0] == "migrate":
+		if err := runMigrate(db, args[1:]); err != nil {
+			lg.Fatal("failed to migrate", zap.Error(err))
+		}
+	default:
+		lg.Fatal("unknown command, want serve or migrate", zap.String("command", args[0]))
+	}
+}
+
+// serve 启动 HTTP 服务，随后执行未完成的迁移并初始化数据。完成前 /readyz 返回未就绪，/api 下的接口返回 503，
+// 过期数据的清理也在完成后才开始。数据库 schema 高于本程序已知的版本时拒绝启动，以免旧版本程序写坏新 schema。
+func serve(lg *zap.Logger, cfg *config.Config, db *gorm.DB) {
+	migrator, err := migrate.New(db)
+	if err != nil {
+		lg.Fatal("failed to load migrations", zap.Error(err))
+	}
+	shutdown := pkg.NewShutdownManager(pkg.DefaultShutdownTimeout, readinessDelay)
+
+	sessions, err := session.New(cfg.Session, db)
+	if err != nil {
+		lg.Fatal("failed to create session store", zap.Error(err))
+	}
+
+	var bootstrapped atomic.Bool
+	app := server.New(db, sessions,
+		server.WithLoginThrottle(cfg.LoginThrottle),
+		server.WithPasswordPolicy(cfg.PasswordPolicy),
+		server.WithTrustedProxies(cfg.Server.TrustedProxies),
+		server.WithAppName(cfg.AppName),
+		server.WithOIDC(cfg.OIDC),
+		server.WithSessionCookie(cfg.Session.Cookie),
+		server.WithServing(bootstrapped.Load),
+	)
+	app.AddReadinessCheck("migrations", func(ctx context.Context) error {
+		pending, err := migrator.Pending(ctx)
+		if err != nil {
+			return err
+		}
+		if len(pending) > 0 {
+			return fmt.Errorf("%d migrations pending, latest known version is %d", len(pending), migrator.Latest())
+		}
+		return nil
+	})
+	app.AddReadinessCheck("bootstrap", func(context.Context) error {
+		if !bootstrapped.Load() {
+			return errors.New("initial data is not ready")
+		}
+		return nil
+	})
+	app.AddReadinessCheck("shutdown", func(context.Context) error {
+		if shutdown.ShuttingDown() {
+			return errors.New("server is shutting down")
+		}
+		return nil
+	})
+
+	address := cfg.Server.Listen.Address()
+	srv := &http.Server{
+		Addr:    address,
+		Handler: app.Handler(),
+	}
+
+	// 后注册的先执行：先排空 HTTP 请求，再停止过期数据清理，最后关闭数据库连接。
+	shutdown.Register("database", 0, func(context.Context) error {
+		sqlDB, err := db.DB()
+		if err != nil {
+			return err
+		}
+		return sqlDB.Close()
+	})
+	gcCtx, stopGC := context.WithCancel(context.Background())
+	shutdown.Register("gc", 0, func(context.Context) error {
+		stopGC()
+		return nil
+	})
+	shutdown.Register("http server", 0, srv.Shutdown)
+	shutdown.Listen()
+
+	go func() {
+		lg.Info("the HTTP server is going to run", zap.String("address", address))
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			lg.Fatal("failed to ListenAndServe", zap.String("address", address), zap.Error(err))
+		}
+	}()
+
+	done, err := migrator.Up(context.Background())
+	if errors.Is(err, migrate.ErrNewerSchema) {
+		lg.Fatal("refusing to serve", zap.Error(err))
+	}
+	if err != nil {
+		lg.Fatal("failed to migrate database", zap.Error(err))
+	}
+	for _, m := range done {
+		lg.Info("applied migration", zap.Uint64("version", m.Version), zap.String("name", m.Name))
+	}
+	report, err := bootstrap.Run(context.Background(), db)
+	if err != nil {
+		lg.Fatal("failed to bootstrap database", zap.Error(err))
+	}
+	lg.Info(report.String())
+	go app.RunGC(gcCtx, gcInterval)
+	bootstrapped.Store(true)
+
+	if err := shutdown.Wait(); err != nil {
+		lg.Fatal("failed to shut down gracefully", zap.Error(err))
+	}
+	lg.Info("the HTTP server has been shut down")
+}
+
+// openDatabase 连接配置的数据库。数据库可能晚于应用就绪，因此连接失败时会重试。
+func openDatabase(lg *zap.Logger, cfg config.Database) (*gorm.DB, error) {
+	gormConfig := &gorm.Config{
+		TranslateError: true,
+		Logger: logger.New(logging.GormWriter(lg), logger.Config{
+			SlowThreshold:             200 * time.Millisecond,
+			LogLevel:                  logger.Warn,
+			IgnoreRecordNotFoundError: true,
+		}),
+	}
+
+	var err error
+	for i := 0; i < connectRetries; i++ {
+		// gorm.Open 会 Ping 数据库，成功即表示数据库已可用。
+		var db *gorm.DB
+		if db, err = database.Open(cfg, gormConfig); err == nil {
+			return db, nil
+		}
+		lg.Warn("database is not ready, retrying", zap.Error(err), zap.Duration("interval", connectInterval))
+		time.Sleep(connectInterval)
 	}
+	return nil, err
 }
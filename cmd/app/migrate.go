@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/dspo/go-homework/pkg/migrate"
+)
+
+const migrateUsage = `usage: app [-config path] migrate <command>
+
+commands:
+  up      apply all pending migrations
+  down    roll back the most recently applied migration
+  status  list migrations and whether they have been applied`
+
+// runMigrate 执行 migrate 子命令。
+func runMigrate(db *gorm.DB, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("%s", migrateUsage)
+	}
+	migrator, err := migrate.New(db)
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+
+	switch args[0] {
+	case "up":
+		done, err := migrator.Up(ctx)
+		for _, m := range done {
+			fmt.Printf("applied %04d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			return err
+		}
+		if len(done) == 0 {
+			fmt.Println("no pending migrations")
+		}
+	case "down":
+		m, err := migrator.Down(ctx)
+		if err != nil {
+			return err
+		}
+		if m == nil {
+			fmt.Println("no applied migrations to roll back")
+			return nil
+		}
+		fmt.Printf("rolled back %04d_%s\n", m.Version, m.Name)
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		_, _ = fmt.Fprintln(w, "VERSION\tNAME\tSTATUS\tAPPLIED AT")
+		for _, s := range statuses {
+			state, appliedAt := "pending", "-"
+			if s.AppliedAt != nil {
+				state, appliedAt = "applied", s.AppliedAt.Format(time.DateTime)
+			}
+			_, _ = fmt.Fprintf(w, "%04d\t%s\t%s\t%s\n", s.Version, s.Name, state, appliedAt)
+		}
+		return w.Flush()
+	default:
+		return fmt.Errorf("unknown migrate command %q\n%s", args[0], migrateUsage)
+	}
+	return nil
+}
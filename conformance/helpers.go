@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	"github.com/google/uuid"
+	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
 	"github.com/dspo/go-homework/sdk"
@@ -38,6 +39,16 @@ func helperRolesContain(roles []sdk.Role, roleName string) bool {
 	return false
 }
 
+// createTestUser 创建一个已修改初始密码的用户，返回用户与其当前密码。
+// 用户在当前用例（在 BeforeAll 中调用时为当前容器）结束后由 admin 删除。
+func createTestUser(prefix string) (*sdk.User, string) {
+	user, pass := createAndSetupUser(helperUniqueName(prefix), "pass1234")
+	DeferCleanup(func() {
+		_ = loginAsAdmin(sdk.GetSDK()).Users().Delete(user.ID)
+	})
+	return user, pass
+}
+
 // loginWithUsername 返回带登录态的客户端，便于链式访问。
 func loginWithUsername(sdk sdk.SDK, username, password string) sdk.UserClient {
 	client, err := sdk.LoginWithUsername(username, password)
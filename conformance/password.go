@@ -0,0 +1,117 @@
+package conformance
+
+import (
+	"net/http"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/dspo/go-homework/sdk"
+)
+
+var _ = Describe("Password Reset", Label("PasswordReset"), func() {
+	var user *sdk.User
+	var pass string
+
+	BeforeEach(func() {
+		user, pass = createAndSetupUser(helperUniqueName("reset"), "pass1234")
+		DeferCleanup(func() {
+			_ = loginAsAdmin(sdk.GetSDK()).Users().Delete(user.ID)
+		})
+	})
+
+	It("should set a temporary password that must be changed on next login", func() {
+		admin := loginAsAdmin(sdk.GetSDK())
+		team, err := admin.Teams().Create(&sdk.CreateTeamRequest{Name: helperUniqueName("reset_team")})
+		Expect(err).NotTo(HaveOccurred(), "unexpected error: %v", err)
+		DeferCleanup(func() {
+			_ = loginAsAdmin(sdk.GetSDK()).Teams().Delete(team.ID)
+		})
+		Expect(admin.Teams().AddUser(team.ID, user.ID)).To(Succeed())
+		client := loginWithUsername(sdk.GetSDK(), user.Username, pass)
+
+		reset, err := admin.Users().ResetPassword(user.ID, "")
+		Expect(err).NotTo(HaveOccurred(), "unexpected error: %v", err)
+		Expect(reset.Password).NotTo(BeEmpty())
+
+		By("- Existing sessions and the old password are invalidated")
+		_, err = client.Me().Get()
+		Expect(err).To(sdk.HaveOccurredWithStatusCode(http.StatusUnauthorized))
+		_, err = sdk.GetSDK().LoginWithUsername(user.Username, pass)
+		Expect(err).To(sdk.HaveOccurredWithStatusCode(http.StatusUnauthorized))
+
+		By("- The temporary password must be changed before accessing other APIs")
+		client = loginWithUsername(sdk.GetSDK(), user.Username, reset.Password)
+		_, err = client.Me().Get()
+		Expect(err).To(sdk.HaveOccurredWithStatusCode(http.StatusForbidden))
+		Expect(client.Me().UpdatePassword(reset.Password, "newpass1234")).To(Succeed())
+
+		By("- Memberships are kept")
+		client = loginWithUsername(sdk.GetSDK(), user.Username, "newpass1234")
+		teams, err := client.Me().ListTeams(nil)
+		Expect(err).NotTo(HaveOccurred(), "unexpected error: %v", err)
+		Expect(teams.List).To(ContainElement(HaveField("ID", team.ID)))
+
+		logs, err := admin.Audits().List(&sdk.ListParams{Keyword: Ptr(user.Username)})
+		Expect(err).NotTo(HaveOccurred(), "unexpected error: %v", err)
+		Expect(logs.List).To(ContainElement(HaveField("Content", ContainSubstring("重置了用户"))))
+	})
+
+	It("should accept a password chosen by admin", func() {
+		admin := loginAsAdmin(sdk.GetSDK())
+		reset, err := admin.Users().ResetPassword(user.ID, "temp12345")
+		Expect(err).NotTo(HaveOccurred(), "unexpected error: %v", err)
+		Expect(reset.Password).To(Equal("temp12345"))
+		loginWithUsername(sdk.GetSDK(), user.Username, "temp12345")
+
+		_, err = admin.Users().ResetPassword(user.ID, "short")
+		Expect(err).To(sdk.HaveOccurredWithStatusCode(http.StatusBadRequest))
+	})
+
+	It("should be forbidden for normal users, admin themselves and unknown users", func() {
+		client := loginWithUsername(sdk.GetSDK(), user.Username, pass)
+		_, err := client.Users().ResetPassword(user.ID, "")
+		Expect(err).To(sdk.HaveOccurredWithStatusCode(http.StatusForbidden))
+
+		admin := loginAsAdmin(sdk.GetSDK())
+		me, err := admin.Me().Get()
+		Expect(err).NotTo(HaveOccurred(), "unexpected error: %v", err)
+		_, err = admin.Users().ResetPassword(me.ID, "")
+		Expect(err).To(sdk.HaveOccurredWithStatusCode(http.StatusBadRequest))
+		_, err = admin.Users().ResetPassword(999999, "")
+		Expect(err).To(sdk.HaveOccurredWithStatusCode(http.StatusNotFound))
+	})
+})
+
+var _ = Describe("Password Policy", Label("PasswordPolicy"), func() {
+	It("should list the violated rules", func() {
+		admin := loginAsAdmin(sdk.GetSDK())
+		_, err := admin.Users().Create(helperUniqueName("policy"), "12345678")
+		Expect(err).To(sdk.HaveOccurredWithStatusCode(http.StatusBadRequest))
+		Expect(err.(*sdk.Error).Violations).To(ContainElement(HaveField("Rule", "banned")))
+
+		_, err = admin.Users().Create(helperUniqueName("policy"), "a b")
+		Expect(err).To(sdk.HaveOccurredWithStatusCode(http.StatusBadRequest))
+		Expect(err.(*sdk.Error).Violations).To(ConsistOf(
+			HaveField("Rule", "min_length"),
+			HaveField("Rule", "allowed_symbols"),
+		))
+	})
+
+	It("should refuse to reuse recent passwords", func() {
+		user, pass := createAndSetupUser(helperUniqueName("history"), "pass1234")
+		DeferCleanup(func() {
+			_ = loginAsAdmin(sdk.GetSDK()).Users().Delete(user.ID)
+		})
+		client := loginWithUsername(sdk.GetSDK(), user.Username, pass)
+		Expect(client.Me().UpdatePassword(pass, "pass5678")).To(Succeed())
+
+		client = loginWithUsername(sdk.GetSDK(), user.Username, "pass5678")
+		err := client.Me().UpdatePassword("pass5678", pass)
+		Expect(err).To(sdk.HaveOccurredWithStatusCode(http.StatusBadRequest))
+		Expect(err.(*sdk.Error).Violations).To(ConsistOf(HaveField("Rule", "history")))
+
+		By("- The current password is still valid")
+		loginWithUsername(sdk.GetSDK(), user.Username, "pass5678")
+	})
+})
@@ -0,0 +1,135 @@
+package conformance
+
+import (
+	"net/http"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/dspo/go-homework/sdk"
+)
+
+var _ = Describe("Sessions", Label("Session"), func() {
+	var user *sdk.User
+	var pass string
+
+	BeforeEach(func() {
+		user, pass = createAndSetupUser(helperUniqueName("session"), "pass1234")
+		admin := loginAsAdmin(sdk.GetSDK())
+		DeferCleanup(func() {
+			_ = admin.Users().Delete(user.ID)
+		})
+		// 清除 createAndSetupUser 登录时留下的会话，每个用例从零个会话开始。
+		Expect(admin.Users().RevokeSessions(user.ID)).To(Succeed())
+	})
+
+	// currentSessionID 返回 client 当前会话的 ID。
+	currentSessionID := func(client sdk.UserClient) string {
+		sessions, err := client.Me().ListSessions()
+		Expect(err).NotTo(HaveOccurred(), "unexpected error: %v", err)
+		for _, s := range sessions.List {
+			if s.Current {
+				return s.ID
+			}
+		}
+		Fail("no session is marked as current")
+		return ""
+	}
+
+	It("should list my active sessions", func() {
+		laptop := loginWithUsername(sdk.GetSDK(), user.Username, pass)
+		phone := loginWithUsername(sdk.GetSDK(), user.Username, pass)
+
+		sessions, err := laptop.Me().ListSessions()
+		Expect(err).NotTo(HaveOccurred(), "unexpected error: %v", err)
+		Expect(sessions.Total).To(Equal(2))
+		Expect(sessions.List).To(HaveLen(2))
+
+		current := 0
+		for _, s := range sessions.List {
+			Expect(s.ID).NotTo(BeEmpty())
+			Expect(s.IP).NotTo(BeEmpty())
+			Expect(s.UserAgent).NotTo(BeEmpty())
+			Expect(s.CreatedAt).To(BeNumerically(">", 0))
+			Expect(s.LastSeenAt).To(BeNumerically(">=", s.CreatedAt))
+			Expect(s.ExpiresAt).To(BeNumerically(">", s.LastSeenAt))
+			if s.Current {
+				current++
+			}
+		}
+		Expect(current).To(Equal(1))
+		Expect(currentSessionID(laptop)).NotTo(Equal(currentSessionID(phone)))
+	})
+
+	It("should inspect one of my sessions", func() {
+		client := loginWithUsername(sdk.GetSDK(), user.Username, pass)
+		id := currentSessionID(client)
+
+		s, err := client.Me().GetSession(id)
+		Expect(err).NotTo(HaveOccurred(), "unexpected error: %v", err)
+		Expect(s.ID).To(Equal(id))
+		Expect(s.Current).To(BeTrue())
+
+		_, err = client.Me().GetSession("no-such-session")
+		Expect(err).To(sdk.HaveOccurredWithStatusCode(http.StatusNotFound))
+
+		By("- Sessions of other users are not visible")
+		admin := loginAsAdmin(sdk.GetSDK())
+		_, err = admin.Me().GetSession(id)
+		Expect(err).To(sdk.HaveOccurredWithStatusCode(http.StatusNotFound))
+		Expect(admin.Me().RevokeSession(id)).To(sdk.HaveOccurredWithStatusCode(http.StatusNotFound))
+	})
+
+	It("should revoke another session of mine", func() {
+		laptop := loginWithUsername(sdk.GetSDK(), user.Username, pass)
+		phone := loginWithUsername(sdk.GetSDK(), user.Username, pass)
+
+		Expect(laptop.Me().RevokeSession(currentSessionID(phone))).To(Succeed())
+
+		_, err := phone.Me().Get()
+		Expect(err).To(sdk.HaveOccurredWithStatusCode(http.StatusUnauthorized))
+		_, err = laptop.Me().Get()
+		Expect(err).NotTo(HaveOccurred(), "the current session should not be affected: %v", err)
+
+		logs, err := loginAsAdmin(sdk.GetSDK()).Audits().List(&sdk.ListParams{Keyword: Ptr(user.Username)})
+		Expect(err).NotTo(HaveOccurred(), "unexpected error: %v", err)
+		Expect(logs.List).To(ContainElement(HaveField("Content", ContainSubstring("撤销了会话"))))
+	})
+
+	It("should log out everywhere", func() {
+		laptop := loginWithUsername(sdk.GetSDK(), user.Username, pass)
+		phone := loginWithUsername(sdk.GetSDK(), user.Username, pass)
+
+		Expect(laptop.Me().RevokeAllSessions()).To(Succeed())
+
+		for _, client := range []sdk.UserClient{laptop, phone} {
+			_, err := client.Me().Get()
+			Expect(err).To(sdk.HaveOccurredWithStatusCode(http.StatusUnauthorized))
+		}
+		_, err := sdk.GetSDK().LoginWithUsername(user.Username, pass)
+		Expect(err).NotTo(HaveOccurred(), "the user should be able to login again: %v", err)
+	})
+
+	It("should allow admin to revoke all sessions of a user", func() {
+		client := loginWithUsername(sdk.GetSDK(), user.Username, pass)
+		admin := loginAsAdmin(sdk.GetSDK())
+
+		Expect(admin.Users().RevokeSessions(user.ID)).To(Succeed())
+		_, err := client.Me().Get()
+		Expect(err).To(sdk.HaveOccurredWithStatusCode(http.StatusUnauthorized))
+		_, err = admin.Me().Get()
+		Expect(err).NotTo(HaveOccurred(), "admin's own session should not be affected: %v", err)
+
+		Expect(admin.Users().RevokeSessions(999999)).To(sdk.HaveOccurredWithStatusCode(http.StatusNotFound))
+
+		logs, err := admin.Audits().List(&sdk.ListParams{Keyword: Ptr(user.Username)})
+		Expect(err).NotTo(HaveOccurred(), "unexpected error: %v", err)
+		Expect(logs.List).To(ContainElement(HaveField("Content", ContainSubstring("的全部会话"))))
+	})
+
+	It("should forbid normal users to revoke sessions of others", func() {
+		client := loginWithUsername(sdk.GetSDK(), user.Username, pass)
+		err := client.Users().RevokeSessions(1)
+		Expect(err).To(sdk.HaveOccurredWithStatusCode(http.StatusForbidden))
+	})
+})
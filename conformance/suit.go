@@ -1,6 +1,8 @@
 package conformance
 
 import (
+	"net/http"
+
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
@@ -30,7 +32,24 @@ var _ = BeforeSuite(func() {
 	By("5. Admin re-login with new password")
 	admin = loginWithUsername(sdk.GetSDK(), "admin", "admin123")
 
-	By("6. Admin tries to access protected resource again")
+	By("6. Admin tries to access protected resource without two-factor authentication")
+	By("- Should get 403 error indicating admin must enable two-factor authentication")
+	_, err = admin.Me().Get()
+	Expect(err).To(sdk.HaveOccurredWithStatusCode(http.StatusForbidden))
+
+	By("7. Admin enrolls and activates TOTP")
+	enrollment, err := admin.Me().EnrollTwoFactor()
+	Expect(err).NotTo(HaveOccurred(), "unexpected error: %v", err)
+	code, err := sdk.TOTPCode(enrollment.Secret)
+	Expect(err).NotTo(HaveOccurred())
+	_, err = admin.Me().ActivateTwoFactor(code)
+	Expect(err).NotTo(HaveOccurred(), "unexpected error: %v", err)
+
+	By("8. Register the TOTP secret so that later admin logins complete the second step automatically")
+	sdk.GetSDK().RegisterTOTPSecret("admin", enrollment.Secret)
+	sdk.GetSDK().RegisterTOTPSecret("admin@example.com", enrollment.Secret)
+
+	By("9. Admin tries to access protected resource again")
 	_, err = admin.Me().Get()
 	Expect(err).NotTo(HaveOccurred(), "unexpected error: %v", err)
 	By("13. All test users ready for testing")
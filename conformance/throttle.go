@@ -0,0 +1,96 @@
+package conformance
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/dspo/go-homework/sdk"
+)
+
+// accountMaxFailures 与 config.yaml 中 login_throttle.account_max_failures 的默认值一致。
+// 同一 IP 的失败次数另有上限，整个测试套件共享同一客户端 IP，因此这里尽量少地制造失败。
+const accountMaxFailures = 5
+
+var _ = Describe("Login Throttling", Label("Throttle"), func() {
+	var user *sdk.User
+	var pass string
+
+	BeforeEach(func() {
+		user, pass = createAndSetupUser(helperUniqueName("throttle"), "pass1234")
+		DeferCleanup(func() {
+			_ = loginAsAdmin(sdk.GetSDK()).Users().Delete(user.ID)
+		})
+	})
+
+	lockUser := func() {
+		By("- Fail to login until the account is locked")
+		for range accountMaxFailures {
+			_, err := sdk.GetSDK().LoginWithUsername(user.Username, "wrong password")
+			Expect(err).To(sdk.HaveOccurredWithStatusCode(http.StatusUnauthorized))
+		}
+	}
+
+	It("should reject a locked account even with the right password", func() {
+		lockUser()
+
+		_, err := sdk.GetSDK().LoginWithUsername(user.Username, pass)
+		Expect(err).To(sdk.HaveOccurredWithStatusCode(http.StatusTooManyRequests))
+		var e *sdk.Error
+		Expect(errors.As(err, &e)).To(BeTrue())
+		Expect(e.RetryAfter).To(BeNumerically(">", 0))
+
+		logs, err := loginAsAdmin(sdk.GetSDK()).Audits().List(&sdk.ListParams{Keyword: Ptr(user.Username)})
+		Expect(err).NotTo(HaveOccurred(), "unexpected error: %v", err)
+		Expect(logs.List).To(ContainElement(HaveField("Content", ContainSubstring("被锁定"))))
+	})
+
+	It("should be unlocked by admin", func() {
+		lockUser()
+
+		admin := loginAsAdmin(sdk.GetSDK())
+		Expect(admin.Users().Unlock(user.ID)).To(Succeed())
+		loginWithUsername(sdk.GetSDK(), user.Username, pass)
+
+		Expect(admin.Users().Unlock(999999)).To(sdk.HaveOccurredWithStatusCode(http.StatusNotFound))
+
+		logs, err := admin.Audits().List(&sdk.ListParams{Keyword: Ptr(user.Username)})
+		Expect(err).NotTo(HaveOccurred(), "unexpected error: %v", err)
+		Expect(logs.List).To(ContainElement(HaveField("Content", ContainSubstring("解除了用户"))))
+	})
+
+	It("should unlock a locked client IP by admin", func() {
+		admin := loginAsAdmin(sdk.GetSDK())
+		sessions, err := admin.Me().ListSessions()
+		Expect(err).NotTo(HaveOccurred(), "unexpected error: %v", err)
+		Expect(sessions.List).NotTo(BeEmpty())
+		ip := sessions.List[0].IP
+		// 整个测试套件共享同一客户端 IP，先清除其他用例留下的失败计数。
+		Expect(admin.Users().UnlockIP(ip)).To(Succeed())
+
+		By("- Fail to login with unknown accounts until the IP is locked")
+		Eventually(func() error {
+			_, err := sdk.GetSDK().LoginWithUsername(helperUniqueName("nobody"), "wrong password")
+			return err
+		}).WithPolling(0).WithTimeout(time.Minute).Should(sdk.HaveOccurredWithStatusCode(http.StatusTooManyRequests))
+		_, err = sdk.GetSDK().LoginWithUsername(user.Username, pass)
+		Expect(err).To(sdk.HaveOccurredWithStatusCode(http.StatusTooManyRequests))
+
+		Expect(admin.Users().UnlockIP(ip)).To(Succeed())
+		loginWithUsername(sdk.GetSDK(), user.Username, pass)
+		Expect(admin.Users().UnlockIP("not-an-ip")).To(sdk.HaveOccurredWithStatusCode(http.StatusBadRequest))
+
+		logs, err := admin.Audits().List(&sdk.ListParams{Keyword: Ptr(ip)})
+		Expect(err).NotTo(HaveOccurred(), "unexpected error: %v", err)
+		Expect(logs.List).To(ContainElement(HaveField("Content", ContainSubstring("解除了 IP "+ip+" 的登录锁定"))))
+	})
+
+	It("should forbid normal users to unlock accounts", func() {
+		client := loginWithUsername(sdk.GetSDK(), user.Username, pass)
+		Expect(client.Users().Unlock(user.ID)).To(sdk.HaveOccurredWithStatusCode(http.StatusForbidden))
+		Expect(client.Users().UnlockIP("127.0.0.1")).To(sdk.HaveOccurredWithStatusCode(http.StatusForbidden))
+	})
+})
@@ -0,0 +1,132 @@
+package conformance
+
+import (
+	"net/http"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/dspo/go-homework/sdk"
+)
+
+var _ = Describe("Personal Access Tokens", Label("Token"), func() {
+	var user *sdk.User
+	var pass string
+	var client sdk.UserClient
+
+	BeforeEach(func() {
+		user, pass = createAndSetupUser(helperUniqueName("token"), "pass1234")
+		DeferCleanup(func() {
+			_ = loginAsAdmin(sdk.GetSDK()).Users().Delete(user.ID)
+		})
+		client = loginWithUsername(sdk.GetSDK(), user.Username, pass)
+	})
+
+	It("should authenticate with a bearer token", func() {
+		token, err := client.Me().CreateAccessToken(&sdk.CreateAccessTokenRequest{Name: "ci", ExpiresInDays: Ptr(7)})
+		Expect(err).NotTo(HaveOccurred(), "unexpected error: %v", err)
+		Expect(token.Token).NotTo(BeEmpty())
+		Expect(token.ExpiresAt).To(BeNumerically("~", time.Now().AddDate(0, 0, 7).Unix(), 60))
+
+		me, err := sdk.GetSDK().WithAccessToken(token.Token).Me().Get()
+		Expect(err).NotTo(HaveOccurred(), "unexpected error: %v", err)
+		Expect(me.ID).To(Equal(user.ID))
+
+		By("- The secret is never listed, last_used_at is recorded")
+		tokens, err := client.Me().ListAccessTokens()
+		Expect(err).NotTo(HaveOccurred(), "unexpected error: %v", err)
+		Expect(tokens.List).To(HaveLen(1))
+		Expect(tokens.List[0].ID).To(Equal(token.ID))
+		Expect(tokens.List[0].Name).To(Equal("ci"))
+		Expect(tokens.List[0].Token).To(BeEmpty())
+		Expect(tokens.List[0].LastUsedAt).NotTo(BeNil())
+	})
+
+	It("should apply the same permission checks as cookie auth", func() {
+		token, err := client.Me().CreateAccessToken(&sdk.CreateAccessTokenRequest{Name: "ci"})
+		Expect(err).NotTo(HaveOccurred(), "unexpected error: %v", err)
+		_, err = sdk.GetSDK().WithAccessToken(token.Token).Audits().List(nil)
+		Expect(err).To(sdk.HaveOccurredWithStatusCode(http.StatusForbidden))
+
+		admin := loginAsAdmin(sdk.GetSDK())
+		adminToken, err := admin.Me().CreateAccessToken(&sdk.CreateAccessTokenRequest{Name: helperUniqueName("admin")})
+		Expect(err).NotTo(HaveOccurred(), "unexpected error: %v", err)
+		DeferCleanup(func() {
+			_ = admin.Me().RevokeAccessToken(adminToken.ID)
+		})
+		_, err = sdk.GetSDK().WithAccessToken(adminToken.Token).Audits().List(nil)
+		Expect(err).NotTo(HaveOccurred(), "unexpected error: %v", err)
+	})
+
+	It("should reject revoked and unknown tokens", func() {
+		token, err := client.Me().CreateAccessToken(&sdk.CreateAccessTokenRequest{Name: "ci"})
+		Expect(err).NotTo(HaveOccurred(), "unexpected error: %v", err)
+		Expect(client.Me().RevokeAccessToken(token.ID)).To(Succeed())
+
+		_, err = sdk.GetSDK().WithAccessToken(token.Token).Me().Get()
+		Expect(err).To(sdk.HaveOccurredWithStatusCode(http.StatusUnauthorized))
+		_, err = sdk.GetSDK().WithAccessToken("hwpat_unknown").Me().Get()
+		Expect(err).To(sdk.HaveOccurredWithStatusCode(http.StatusUnauthorized))
+		Expect(client.Me().RevokeAccessToken(token.ID)).To(sdk.HaveOccurredWithStatusCode(http.StatusNotFound))
+
+		logs, err := loginAsAdmin(sdk.GetSDK()).Audits().List(&sdk.ListParams{Keyword: Ptr(user.Username)})
+		Expect(err).NotTo(HaveOccurred(), "unexpected error: %v", err)
+		Expect(logs.List).To(ContainElement(HaveField("Content", ContainSubstring("创建了个人访问令牌 ci"))))
+		Expect(logs.List).To(ContainElement(HaveField("Content", ContainSubstring("撤销了个人访问令牌 ci"))))
+	})
+
+	It("should validate token requests", func() {
+		_, err := client.Me().CreateAccessToken(&sdk.CreateAccessTokenRequest{Name: ""})
+		Expect(err).To(sdk.HaveOccurredWithStatusCode(http.StatusBadRequest))
+		_, err = client.Me().CreateAccessToken(&sdk.CreateAccessTokenRequest{Name: "ci", ExpiresInDays: Ptr(0)})
+		Expect(err).To(sdk.HaveOccurredWithStatusCode(http.StatusBadRequest))
+		_, err = client.Me().CreateAccessToken(&sdk.CreateAccessTokenRequest{Name: "ci", ExpiresInDays: Ptr(366)})
+		Expect(err).To(sdk.HaveOccurredWithStatusCode(http.StatusBadRequest))
+
+		_, err = client.Me().CreateAccessToken(&sdk.CreateAccessTokenRequest{Name: "ci"})
+		Expect(err).NotTo(HaveOccurred(), "unexpected error: %v", err)
+		_, err = client.Me().CreateAccessToken(&sdk.CreateAccessTokenRequest{Name: "ci"})
+		Expect(err).To(sdk.HaveOccurredWithStatusCode(http.StatusConflict))
+	})
+
+	It("should only be created in a login session", func() {
+		token, err := client.Me().CreateAccessToken(&sdk.CreateAccessTokenRequest{Name: "ci"})
+		Expect(err).NotTo(HaveOccurred(), "unexpected error: %v", err)
+		_, err = sdk.GetSDK().WithAccessToken(token.Token).Me().CreateAccessToken(&sdk.CreateAccessTokenRequest{Name: "more"})
+		Expect(err).To(sdk.HaveOccurredWithStatusCode(http.StatusForbidden))
+	})
+
+	It("should be revoked with the credentials of the user", func() {
+		admin := loginAsAdmin(sdk.GetSDK())
+		token, err := client.Me().CreateAccessToken(&sdk.CreateAccessTokenRequest{Name: "ci"})
+		Expect(err).NotTo(HaveOccurred(), "unexpected error: %v", err)
+		Expect(admin.Users().RevokeSessions(user.ID)).To(Succeed())
+		_, err = sdk.GetSDK().WithAccessToken(token.Token).Me().Get()
+		Expect(err).To(sdk.HaveOccurredWithStatusCode(http.StatusUnauthorized))
+
+		client = loginWithUsername(sdk.GetSDK(), user.Username, pass)
+		token, err = client.Me().CreateAccessToken(&sdk.CreateAccessTokenRequest{Name: "ci"})
+		Expect(err).NotTo(HaveOccurred(), "unexpected error: %v", err)
+		_, err = admin.Users().ResetPassword(user.ID, "")
+		Expect(err).NotTo(HaveOccurred(), "unexpected error: %v", err)
+		_, err = sdk.GetSDK().WithAccessToken(token.Token).Me().Get()
+		Expect(err).To(sdk.HaveOccurredWithStatusCode(http.StatusUnauthorized))
+	})
+
+	It("should be revoked when the password is changed", func() {
+		token, err := client.Me().CreateAccessToken(&sdk.CreateAccessTokenRequest{Name: "ci"})
+		Expect(err).NotTo(HaveOccurred(), "unexpected error: %v", err)
+		Expect(client.Me().UpdatePassword(pass, pass+"789")).To(Succeed())
+		_, err = sdk.GetSDK().WithAccessToken(token.Token).Me().Get()
+		Expect(err).To(sdk.HaveOccurredWithStatusCode(http.StatusUnauthorized))
+	})
+
+	It("should not be affected by logging out", func() {
+		token, err := client.Me().CreateAccessToken(&sdk.CreateAccessTokenRequest{Name: "ci"})
+		Expect(err).NotTo(HaveOccurred(), "unexpected error: %v", err)
+		Expect(client.Logout()).To(Succeed())
+		_, err = sdk.GetSDK().WithAccessToken(token.Token).Me().Get()
+		Expect(err).NotTo(HaveOccurred(), "unexpected error: %v", err)
+	})
+})
@@ -0,0 +1,174 @@
+package conformance
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/dspo/go-homework/sdk"
+)
+
+var _ = Describe("Two-Factor Authentication", Label("TwoFactor"), func() {
+	var user *sdk.User
+	var pass string
+
+	BeforeEach(func() {
+		user, pass = createTestUser("totp")
+	})
+
+	// enable 为 user 启用两步验证，返回 TOTP 密钥与恢复码。
+	enable := func() (string, []string) {
+		client := loginWithUsername(sdk.GetSDK(), user.Username, pass)
+		status, err := client.Me().GetTwoFactor()
+		Expect(err).NotTo(HaveOccurred(), "unexpected error: %v", err)
+		Expect(status.Enabled).To(BeFalse())
+		Expect(status.Required).To(BeFalse())
+
+		enrollment, err := client.Me().EnrollTwoFactor()
+		Expect(err).NotTo(HaveOccurred(), "unexpected error: %v", err)
+		Expect(enrollment.ProvisioningURI).To(HavePrefix("otpauth://totp/"))
+		Expect(enrollment.ProvisioningURI).To(ContainSubstring(user.Username))
+		Expect(enrollment.QRCode).To(HavePrefix("data:image/png;base64,"))
+
+		_, err = client.Me().ActivateTwoFactor("000000")
+		Expect(err).To(sdk.HaveOccurredWithStatusCode(http.StatusBadRequest))
+		code, err := sdk.TOTPCode(enrollment.Secret)
+		Expect(err).NotTo(HaveOccurred())
+		codes, err := client.Me().ActivateTwoFactor(code)
+		Expect(err).NotTo(HaveOccurred(), "unexpected error: %v", err)
+		Expect(codes.RecoveryCodes).To(HaveLen(10))
+
+		status, err = client.Me().GetTwoFactor()
+		Expect(err).NotTo(HaveOccurred(), "unexpected error: %v", err)
+		Expect(status.Enabled).To(BeTrue())
+		Expect(status.RecoveryCodesRemaining).To(Equal(10))
+		return enrollment.Secret, codes.RecoveryCodes
+	}
+
+	// challenge 以密码登录 user，返回须完成的登录挑战。
+	challenge := func() string {
+		_, err := sdk.GetSDK().LoginWithUsername(user.Username, pass)
+		var required *sdk.TwoFactorRequiredError
+		Expect(errors.As(err, &required)).To(BeTrue(), "expected a two-factor challenge, got %v", err)
+		Expect(required.Challenge).NotTo(BeEmpty())
+		return required.Challenge
+	}
+
+	It("should require a TOTP code after the password once enabled", func() {
+		secret, _ := enable()
+
+		By("- A wrong code does not complete the login")
+		id := challenge()
+		_, err := sdk.GetSDK().CompleteLogin(id, "000000")
+		Expect(err).To(sdk.HaveOccurredWithStatusCode(http.StatusUnauthorized))
+
+		By("- The right code completes the login")
+		code, err := sdk.TOTPCode(secret)
+		Expect(err).NotTo(HaveOccurred())
+		client, err := sdk.GetSDK().CompleteLogin(id, code)
+		Expect(err).NotTo(HaveOccurred(), "unexpected error: %v", err)
+		me, err := client.Me().Get()
+		Expect(err).NotTo(HaveOccurred(), "unexpected error: %v", err)
+		Expect(me.ID).To(Equal(user.ID))
+
+		By("- A completed challenge can not be used again")
+		_, err = sdk.GetSDK().CompleteLogin(id, code)
+		Expect(err).To(sdk.HaveOccurredWithStatusCode(http.StatusUnauthorized))
+
+		By("- The SDK completes the login automatically once the secret is registered")
+		sdk.GetSDK().RegisterTOTPSecret(user.Username, secret)
+		loginWithUsername(sdk.GetSDK(), user.Username, pass)
+	})
+
+	It("should accept each recovery code only once", func() {
+		_, codes := enable()
+
+		client, err := sdk.GetSDK().CompleteLogin(challenge(), strings.ToUpper(codes[0]))
+		Expect(err).NotTo(HaveOccurred(), "unexpected error: %v", err)
+		status, err := client.Me().GetTwoFactor()
+		Expect(err).NotTo(HaveOccurred(), "unexpected error: %v", err)
+		Expect(status.RecoveryCodesRemaining).To(Equal(9))
+
+		_, err = sdk.GetSDK().CompleteLogin(challenge(), codes[0])
+		Expect(err).To(sdk.HaveOccurredWithStatusCode(http.StatusUnauthorized))
+
+		By("- Regenerating invalidates the previous recovery codes")
+		regenerated, err := client.Me().RegenerateRecoveryCodes(codes[1])
+		Expect(err).NotTo(HaveOccurred(), "unexpected error: %v", err)
+		Expect(regenerated.RecoveryCodes).To(HaveLen(10))
+		_, err = client.Me().RegenerateRecoveryCodes(codes[2])
+		Expect(err).To(sdk.HaveOccurredWithStatusCode(http.StatusBadRequest))
+	})
+
+	It("should be disabled by the user with a code", func() {
+		secret, _ := enable()
+		code, err := sdk.TOTPCode(secret)
+		Expect(err).NotTo(HaveOccurred())
+		client, err := sdk.GetSDK().CompleteLogin(challenge(), code)
+		Expect(err).NotTo(HaveOccurred(), "unexpected error: %v", err)
+
+		Expect(client.Me().DisableTwoFactor("000000")).To(sdk.HaveOccurredWithStatusCode(http.StatusBadRequest))
+		By("- A code can not be used twice")
+		Expect(client.Me().DisableTwoFactor(code)).To(sdk.HaveOccurredWithStatusCode(http.StatusBadRequest))
+		code, err = sdk.TOTPCode(secret)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(client.Me().DisableTwoFactor(code)).To(Succeed())
+		loginWithUsername(sdk.GetSDK(), user.Username, pass)
+
+		logs, err := loginAsAdmin(sdk.GetSDK()).Audits().List(&sdk.ListParams{Keyword: Ptr(user.Username)})
+		Expect(err).NotTo(HaveOccurred(), "unexpected error: %v", err)
+		Expect(logs.List).To(ContainElement(HaveField("Content", ContainSubstring("停用了两步验证"))))
+	})
+
+	It("should count wrong codes of a signed-in user as login failures", func() {
+		secret, _ := enable()
+		code, err := sdk.TOTPCode(secret)
+		Expect(err).NotTo(HaveOccurred())
+		client, err := sdk.GetSDK().CompleteLogin(challenge(), code)
+		Expect(err).NotTo(HaveOccurred(), "unexpected error: %v", err)
+
+		for range accountMaxFailures {
+			Expect(client.Me().DisableTwoFactor("000000")).To(sdk.HaveOccurredWithStatusCode(http.StatusBadRequest))
+		}
+		code, err = sdk.TOTPCode(secret)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(client.Me().DisableTwoFactor(code)).To(sdk.HaveOccurredWithStatusCode(http.StatusTooManyRequests))
+		_, err = client.Me().RegenerateRecoveryCodes(code)
+		Expect(err).To(sdk.HaveOccurredWithStatusCode(http.StatusTooManyRequests))
+
+		By("- Unlocking the account lets the user confirm with a code again")
+		Expect(loginAsAdmin(sdk.GetSDK()).Users().Unlock(user.ID)).To(Succeed())
+		Expect(client.Me().DisableTwoFactor(code)).To(Succeed())
+	})
+
+	It("should be reset by admin for a user who lost the authenticator", func() {
+		enable()
+
+		admin := loginAsAdmin(sdk.GetSDK())
+		Expect(admin.Users().ResetTwoFactor(user.ID)).To(Succeed())
+		loginWithUsername(sdk.GetSDK(), user.Username, pass)
+
+		Expect(admin.Users().ResetTwoFactor(999999)).To(sdk.HaveOccurredWithStatusCode(http.StatusNotFound))
+
+		logs, err := admin.Audits().List(&sdk.ListParams{Keyword: Ptr(user.Username)})
+		Expect(err).NotTo(HaveOccurred(), "unexpected error: %v", err)
+		Expect(logs.List).To(ContainElement(HaveField("Content", ContainSubstring("重置了用户"))))
+	})
+
+	It("should forbid normal users to reset others' two-factor authentication", func() {
+		client := loginWithUsername(sdk.GetSDK(), user.Username, pass)
+		Expect(client.Users().ResetTwoFactor(user.ID)).To(sdk.HaveOccurredWithStatusCode(http.StatusForbidden))
+	})
+
+	It("should not allow admin to disable two-factor authentication", func() {
+		admin := loginAsAdmin(sdk.GetSDK())
+		status, err := admin.Me().GetTwoFactor()
+		Expect(err).NotTo(HaveOccurred(), "unexpected error: %v", err)
+		Expect(status.Enabled).To(BeTrue())
+		Expect(status.Required).To(BeTrue())
+		Expect(admin.Me().DisableTwoFactor("000000")).To(sdk.HaveOccurredWithStatusCode(http.StatusForbidden))
+	})
+})
@@ -408,6 +408,69 @@ var _ = Describe("Users", Label("User"), func() {
 			Expect(visibleIDs[userB.ID]).To(BeTrue())
 			Expect(visibleIDs[userC.ID]).To(BeTrue())
 		})
+
+		It("should explain my visibility of other users", func() {
+			client := loginWithUsername(sdk.GetSDK(), userA.Username, passA)
+			v, err := client.Users().GetVisibility(userC.ID, nil)
+			Expect(err).NotTo(HaveOccurred(), "unexpected error: %v", err)
+			Expect(v.ViewerID).To(Equal(userA.ID))
+			Expect(v.Visible).To(BeTrue())
+			Expect(v.Reason).To(Equal("shared_team"))
+			Expect(v.SharedTeamIDs).To(Equal([]int{teamAID}))
+
+			// 不可见的用户同样可以查询，以便排查被拒绝的操作。
+			v, err = client.Users().GetVisibility(userB.ID, nil)
+			Expect(err).NotTo(HaveOccurred(), "unexpected error: %v", err)
+			Expect(v.Visible).To(BeFalse())
+			Expect(v.Reason).To(Equal("no_shared_team"))
+			Expect(v.SharedTeamIDs).To(BeEmpty())
+			_, err = client.Users().Get(userB.ID)
+			Expect(err).To(sdk.HaveOccurredWithStatusCode(http.StatusForbidden))
+
+			v, err = client.Users().GetVisibility(userA.ID, &userA.ID)
+			Expect(err).NotTo(HaveOccurred(), "unexpected error: %v", err)
+			Expect(v.Reason).To(Equal("self"))
+
+			_, err = client.Users().GetVisibility(userB.ID, &userC.ID)
+			Expect(err).To(sdk.HaveOccurredWithStatusCode(http.StatusForbidden))
+			_, err = client.Users().GetVisibility(999999, nil)
+			Expect(err).To(sdk.HaveOccurredWithStatusCode(http.StatusNotFound))
+			_, err = client.Users().VisibilityReport(userA.ID, userB.ID)
+			Expect(err).To(sdk.HaveOccurredWithStatusCode(http.StatusForbidden))
+		})
+
+		It("should report pairwise visibility to admin", func() {
+			admin := loginWithUsername(sdk.GetSDK(), "admin", "admin123")
+			v, err := admin.Users().GetVisibility(userB.ID, &userC.ID)
+			Expect(err).NotTo(HaveOccurred(), "unexpected error: %v", err)
+			Expect(v.ViewerID).To(Equal(userC.ID))
+			Expect(v.Reason).To(Equal("shared_team"))
+			Expect(v.SharedTeamIDs).To(Equal([]int{teamBID}))
+			v, err = admin.Users().GetVisibility(userB.ID, nil)
+			Expect(err).NotTo(HaveOccurred(), "unexpected error: %v", err)
+			Expect(v.Reason).To(Equal("admin"))
+
+			report, err := admin.Users().VisibilityReport(userA.ID, userB.ID, userC.ID, userA.ID)
+			Expect(err).NotTo(HaveOccurred(), "unexpected error: %v", err)
+			Expect(report.Total).To(Equal(6))
+			visible := make(map[[2]int]bool)
+			for _, item := range report.List {
+				visible[[2]int{item.ViewerID, item.UserID}] = item.Visible
+			}
+			Expect(visible).To(Equal(map[[2]int]bool{
+				{userA.ID, userB.ID}: false,
+				{userA.ID, userC.ID}: true,
+				{userB.ID, userA.ID}: false,
+				{userB.ID, userC.ID}: true,
+				{userC.ID, userA.ID}: true,
+				{userC.ID, userB.ID}: true,
+			}))
+
+			_, err = admin.Users().VisibilityReport(userA.ID)
+			Expect(err).To(sdk.HaveOccurredWithStatusCode(http.StatusBadRequest))
+			_, err = admin.Users().VisibilityReport(userA.ID, 999999)
+			Expect(err).To(sdk.HaveOccurredWithStatusCode(http.StatusNotFound))
+		})
 	})
 
 	Context("Me APIs", func() {
@@ -742,6 +805,135 @@ var _ = Describe("Users", Label("User"), func() {
 			}
 			Expect(found).To(BeTrue(), "exiting project should not remove user from team")
 		})
+
+		It("should report my permissions on resources", func() {
+			s := sdk.GetSDK().Guest()
+			leader, leaderPass := createAndSetupUser(helperUniqueName("me_perm_leader"), "pass1234")
+			member, memberPass := createAndSetupUser(helperUniqueName("me_perm_member"), "pass1234")
+			DeferCleanup(func() {
+				s = loginAsAdmin(s)
+				_ = s.Users().Delete(leader.ID)
+				_ = s.Users().Delete(member.ID)
+			})
+
+			s = loginAsAdmin(s)
+			team, err := s.Teams().Create(&sdk.CreateTeamRequest{Name: helperUniqueName("me_perm_team")})
+			Expect(err).NotTo(HaveOccurred(), "unexpected error: %v", err)
+			other, err := s.Teams().Create(&sdk.CreateTeamRequest{Name: helperUniqueName("me_perm_other")})
+			Expect(err).NotTo(HaveOccurred(), "unexpected error: %v", err)
+			DeferCleanup(func() {
+				s = loginAsAdmin(s)
+				_ = s.Teams().Delete(team.ID)
+				_ = s.Teams().Delete(other.ID)
+			})
+			Expect(s.Teams().AddUser(team.ID, leader.ID)).To(Succeed())
+			Expect(s.Teams().AddUser(team.ID, member.ID)).To(Succeed())
+			_, err = s.Teams().UpdateLeader(team.ID, &leader.ID)
+			Expect(err).NotTo(HaveOccurred(), "unexpected error: %v", err)
+			project, err := s.Teams().CreateProject(team.ID, &sdk.CreateProjectRequest{Name: helperUniqueName("me_perm_project")})
+			Expect(err).NotTo(HaveOccurred(), "unexpected error: %v", err)
+			Expect(s.Projects().AddUser(project.ID, member.ID)).To(Succeed())
+
+			perms, err := s.Me().Permissions(sdk.TeamResource(team.ID))
+			Expect(err).NotTo(HaveOccurred(), "unexpected error: %v", err)
+			Expect(perms.Resource).To(Equal(sdk.TeamResource(team.ID)))
+			Expect(perms.Actions).To(ContainElements("getTeam", "updateTeam", "deleteTeam", "createTeamProject"))
+			global, err := s.Me().Permissions("")
+			Expect(err).NotTo(HaveOccurred(), "unexpected error: %v", err)
+			Expect(global.Actions).To(ContainElements("createUser", "createTeam", "createRole", "audits"))
+
+			s, err = s.LoginWithUsername(leader.Username, leaderPass)
+			Expect(err).NotTo(HaveOccurred(), "unexpected error: %v", err)
+			batch, err := s.Me().BatchPermissions(sdk.TeamResource(team.ID), sdk.TeamResource(other.ID), sdk.ProjectResource(project.ID))
+			Expect(err).NotTo(HaveOccurred(), "unexpected error: %v", err)
+			Expect(batch.List).To(HaveLen(3))
+			Expect(batch.List[0].Allows("updateTeam")).To(BeTrue())
+			Expect(batch.List[0].Allows("addTeamUser")).To(BeTrue())
+			Expect(batch.List[1].Actions).To(BeEmpty())
+			Expect(batch.List[2].Allows("deleteProject")).To(BeTrue())
+			global, err = s.Me().Permissions("")
+			Expect(err).NotTo(HaveOccurred(), "unexpected error: %v", err)
+			Expect(global.Actions).To(ContainElement("me"))
+			Expect(global.Actions).NotTo(ContainElements("createTeam", "audits"))
+
+			// 与接口实际的授权结果一致。
+			s, err = s.LoginWithUsername(member.Username, memberPass)
+			Expect(err).NotTo(HaveOccurred(), "unexpected error: %v", err)
+			batch, err = s.Me().BatchPermissions(sdk.TeamResource(team.ID), sdk.ProjectResource(project.ID), sdk.UserResource(leader.ID))
+			Expect(err).NotTo(HaveOccurred(), "unexpected error: %v", err)
+			Expect(batch.List[0].Actions).To(ConsistOf("getTeam", "getTeamUsers", "getTeamProjects", "listTeamRoles"))
+			Expect(batch.List[1].Actions).To(ConsistOf("getProject", "getProjectUsers"))
+			Expect(batch.List[2].Actions).To(ConsistOf("getUser", "getUserTeams", "getUserProjects", "listUserRoles", "getUserVisibility"))
+			_, err = s.Teams().Update(team.ID, &sdk.UpdateTeamRequest{Name: Ptr(helperUniqueName("me_perm_renamed"))})
+			Expect(err).To(sdk.HaveOccurredWithStatusCode(http.StatusForbidden))
+			_, err = s.Projects().Get(project.ID)
+			Expect(err).NotTo(HaveOccurred(), "unexpected error: %v", err)
+
+			// 不存在的资源没有可以执行的操作，不影响其他资源的结果。
+			batch, err = s.Me().BatchPermissions("teams/999999", sdk.TeamResource(team.ID))
+			Expect(err).NotTo(HaveOccurred(), "unexpected error: %v", err)
+			Expect(batch.List[0].Actions).To(BeEmpty())
+			Expect(batch.List[0].Error).NotTo(BeEmpty())
+			Expect(batch.List[1].Allows("getTeam")).To(BeTrue())
+			Expect(batch.List[1].Error).To(BeEmpty())
+			_, err = s.Me().Permissions("roles/1")
+			Expect(err).To(sdk.HaveOccurredWithStatusCode(http.StatusBadRequest))
+		})
+
+		It("should not report actions the APIs always reject", func() {
+			s := sdk.GetSDK().Guest()
+			user, userPass := createTestUser("me_perm_target")
+
+			s = loginAsAdmin(s)
+			me, err := s.Me().Get()
+			Expect(err).NotTo(HaveOccurred(), "unexpected error: %v", err)
+			roleName := helperUniqueName("me_perm_role")
+			role, err := s.Roles().Create(&sdk.CreateRoleRequest{Name: roleName})
+			Expect(err).NotTo(HaveOccurred(), "unexpected error: %v", err)
+			DeferCleanup(func() {
+				s = loginAsAdmin(s)
+				_ = s.Roles().Delete(role.ID)
+			})
+			var adminRoleID int
+			for _, r := range me.Roles {
+				if r.Name == "admin" {
+					adminRoleID = r.ID
+				}
+			}
+			Expect(adminRoleID).NotTo(Equal(0))
+
+			By("admin can neither delete itself nor reset its own password or revoke its own admin role")
+			batch, err := s.Me().BatchPermissions(sdk.UserResource(me.ID), sdk.UserResource(user.ID), "")
+			Expect(err).NotTo(HaveOccurred(), "unexpected error: %v", err)
+			Expect(batch.List[0].Actions).To(ContainElements("getUser", "addUserRole"))
+			Expect(batch.List[0].Actions).NotTo(ContainElements("deleteUser"))
+			Expect(batch.List[0].Actions).NotTo(ContainElements("resetUserPassword"))
+			Expect(batch.List[0].Actions).NotTo(ContainElements("removeUserRole"))
+			Expect(s.Users().Delete(me.ID)).To(sdk.HaveOccurredWithStatusCode(http.StatusForbidden))
+			_, err = s.Users().ResetPassword(me.ID, "")
+			Expect(err).To(sdk.HaveOccurredWithStatusCode(http.StatusBadRequest))
+			Expect(s.Users().RemoveRole(me.ID, adminRoleID)).To(sdk.HaveOccurredWithStatusCode(http.StatusForbidden))
+
+			By("admin can not disable two-factor authentication")
+			Expect(batch.List[2].Actions).NotTo(ContainElements("disableMyTwoFactor"))
+			Expect(s.Me().DisableTwoFactor("000000")).To(sdk.HaveOccurredWithStatusCode(http.StatusForbidden))
+
+			By("removeUserRole is reported only when the user has a role to remove")
+			Expect(batch.List[1].Actions).To(ContainElements("deleteUser", "resetUserPassword"))
+			Expect(batch.List[1].Actions).NotTo(ContainElements("removeUserRole"))
+			Expect(s.Users().AddRole(user.ID, role.ID)).To(Succeed())
+			perms, err := s.Me().Permissions(sdk.UserResource(user.ID))
+			Expect(err).NotTo(HaveOccurred(), "unexpected error: %v", err)
+			Expect(perms.Allows("removeUserRole")).To(BeTrue())
+			Expect(s.Users().RemoveRole(user.ID, role.ID)).To(Succeed())
+
+			By("normal users can disable two-factor authentication")
+			s, err = s.LoginWithUsername(user.Username, userPass)
+			Expect(err).NotTo(HaveOccurred(), "unexpected error: %v", err)
+			perms, err = s.Me().Permissions("")
+			Expect(err).NotTo(HaveOccurred(), "unexpected error: %v", err)
+			Expect(perms.Allows("disableMyTwoFactor")).To(BeTrue())
+		})
 	})
 
 	Context("User Teams and Projects", Ordered, func() {
@@ -875,7 +1067,7 @@ var _ = Describe("Roles", Label("Role"), func() {
 			))
 		})
 
-		It("should not add system roles to users manually", func() {
+		It("should not add system roles other than admin to users manually", func() {
 			s := sdk.GetSDK().Guest()
 			user, _ := createAndSetupUser(helperUniqueName("role_sys"), "pass1234")
 			DeferCleanup(func() {
@@ -886,22 +1078,23 @@ var _ = Describe("Roles", Label("Role"), func() {
 			s = loginAsAdmin(s)
 			roles, err := s.Roles().List()
 			Expect(err).NotTo(HaveOccurred(), "unexpected error: %v", err)
-			var adminRoleID int
+			var managed []int
 			for _, role := range roles.List {
-				if role.Name == "admin" {
-					adminRoleID = role.ID
-					break
+				if role.Name == "team leader" || role.Name == "normal user" {
+					managed = append(managed, role.ID)
 				}
 			}
-			Expect(adminRoleID).NotTo(Equal(0))
-
-			err = s.Users().AddRole(user.ID, adminRoleID)
-			Expect(err).To(Or(
-				sdk.HaveOccurredWithStatusCode(http.StatusBadRequest),
-				sdk.HaveOccurredWithStatusCode(http.StatusForbidden),
-				sdk.HaveOccurredWithStatusCode(http.StatusNotAcceptable),
-				sdk.HaveOccurredWithStatusCode(http.StatusConflict),
-			))
+			Expect(managed).To(HaveLen(2))
+
+			for _, roleID := range managed {
+				err = s.Users().AddRole(user.ID, roleID)
+				Expect(err).To(Or(
+					sdk.HaveOccurredWithStatusCode(http.StatusBadRequest),
+					sdk.HaveOccurredWithStatusCode(http.StatusForbidden),
+					sdk.HaveOccurredWithStatusCode(http.StatusNotAcceptable),
+					sdk.HaveOccurredWithStatusCode(http.StatusConflict),
+				))
+			}
 		})
 
 		It("should auto-assign admin role to admin user", func() {
@@ -1067,6 +1260,250 @@ var _ = Describe("Roles", Label("Role"), func() {
 		})
 	})
 
+	Context("Multiple Admins", func() {
+		It("should grant and revoke the admin role while keeping the bootstrap admin", func() {
+			s := sdk.GetSDK().Guest()
+			user, pass := createAndSetupUser(helperUniqueName("second_admin"), "pass1234")
+			DeferCleanup(func() {
+				s = loginAsAdmin(s)
+				_ = s.Users().Delete(user.ID)
+			})
+
+			s = loginAsAdmin(s)
+			bootstrap, err := s.Me().Get()
+			Expect(err).NotTo(HaveOccurred(), "unexpected error: %v", err)
+			roles, err := s.Roles().List()
+			Expect(err).NotTo(HaveOccurred(), "unexpected error: %v", err)
+			var adminRoleID int
+			for _, role := range roles.List {
+				if role.Name == "admin" {
+					adminRoleID = role.ID
+				}
+			}
+			Expect(adminRoleID).NotTo(Equal(0))
+			team, err := s.Teams().Create(&sdk.CreateTeamRequest{Name: helperUniqueName("admin_team")})
+			Expect(err).NotTo(HaveOccurred(), "unexpected error: %v", err)
+			DeferCleanup(func() {
+				s = loginAsAdmin(s)
+				_ = s.Teams().Delete(team.ID)
+			})
+			Expect(s.Teams().AddUser(team.ID, user.ID)).To(Succeed())
+			Expect(s.Users().AddTeamRole(user.ID, adminRoleID, team.ID)).To(sdk.HaveOccurredWithStatusCode(http.StatusBadRequest))
+
+			By("Granting the admin role to another user")
+			Expect(s.Users().AddRole(user.ID, adminRoleID)).To(Succeed())
+			audits, err := s.Audits().List(&sdk.ListParams{Keyword: Ptr("授予了用户 " + user.Username)})
+			Expect(err).NotTo(HaveOccurred(), "unexpected error: %v", err)
+			Expect(audits.List).To(HaveLen(1))
+			// 重复授予不改变什么，也不再记录审计日志。
+			Expect(s.Users().AddRole(user.ID, adminRoleID)).To(Succeed())
+			audits, err = s.Audits().List(&sdk.ListParams{Keyword: Ptr("授予了用户 " + user.Username)})
+			Expect(err).NotTo(HaveOccurred(), "unexpected error: %v", err)
+			Expect(audits.List).To(HaveLen(1))
+
+			By("The new admin must enable two-factor authentication like the bootstrap admin")
+			second := loginWithUsername(sdk.GetSDK(), user.Username, pass)
+			_, err = second.Me().Get()
+			Expect(err).To(sdk.HaveOccurredWithStatusCode(http.StatusForbidden))
+			// 只有可用的 admin 才能解绑或删除 admin，而 admin 不能解绑或删除自身，因此总保留一名可用的 admin。
+			Expect(second.Users().RemoveRole(bootstrap.ID, adminRoleID)).To(sdk.HaveOccurredWithStatusCode(http.StatusForbidden))
+			Expect(second.Users().Delete(user.ID)).To(sdk.HaveOccurredWithStatusCode(http.StatusForbidden))
+			enrollment, err := second.Me().EnrollTwoFactor()
+			Expect(err).NotTo(HaveOccurred(), "unexpected error: %v", err)
+			code, err := sdk.TOTPCode(enrollment.Secret)
+			Expect(err).NotTo(HaveOccurred())
+			_, err = second.Me().ActivateTwoFactor(code)
+			Expect(err).NotTo(HaveOccurred(), "unexpected error: %v", err)
+			sdk.GetSDK().RegisterTOTPSecret(user.Username, enrollment.Secret)
+			second = loginWithUsername(sdk.GetSDK(), user.Username, pass)
+			_, err = second.Audits().List(nil)
+			Expect(err).NotTo(HaveOccurred(), "unexpected error: %v", err)
+
+			By("The bootstrap admin keeps the admin role and can not be deleted")
+			Expect(second.Users().RemoveRole(bootstrap.ID, adminRoleID)).To(sdk.HaveOccurredWithStatusCode(http.StatusForbidden))
+			Expect(second.Users().Delete(bootstrap.ID)).To(sdk.HaveOccurredWithStatusCode(http.StatusForbidden))
+			Expect(second.Users().RemoveRole(user.ID, adminRoleID)).To(sdk.HaveOccurredWithStatusCode(http.StatusForbidden))
+
+			By("Revoking the admin role")
+			s = loginAsAdmin(s)
+			Expect(s.Users().RemoveRole(user.ID, adminRoleID)).To(Succeed())
+			Expect(s.Users().RemoveRole(user.ID, adminRoleID)).To(sdk.HaveOccurredWithStatusCode(http.StatusNotFound))
+			audits, err = s.Audits().List(&sdk.ListParams{Keyword: Ptr("撤销了用户 " + user.Username)})
+			Expect(err).NotTo(HaveOccurred(), "unexpected error: %v", err)
+			Expect(audits.List).NotTo(BeEmpty())
+			second = loginWithUsername(sdk.GetSDK(), user.Username, pass)
+			_, err = second.Audits().List(nil)
+			Expect(err).To(sdk.HaveOccurredWithStatusCode(http.StatusForbidden))
+
+			By("Deleting another admin")
+			Expect(s.Users().AddRole(user.ID, adminRoleID)).To(Succeed())
+			Expect(s.Users().Delete(user.ID)).To(Succeed())
+		})
+	})
+
+	Context("Team-Scoped Roles", func() {
+		It("should bind roles within a team and its projects", func() {
+			s := sdk.GetSDK().Guest()
+			reviewer, _ := createAndSetupUser(helperUniqueName("scoped_reviewer"), "pass1234")
+			peer, peerPass := createAndSetupUser(helperUniqueName("scoped_peer"), "pass1234")
+			DeferCleanup(func() {
+				s = loginAsAdmin(s)
+				_ = s.Users().Delete(reviewer.ID)
+				_ = s.Users().Delete(peer.ID)
+			})
+
+			s = loginAsAdmin(s)
+			roleName := helperUniqueName("reviewer")
+			role, err := s.Roles().Create(&sdk.CreateRoleRequest{Name: roleName})
+			Expect(err).NotTo(HaveOccurred(), "unexpected error: %v", err)
+			team, err := s.Teams().Create(&sdk.CreateTeamRequest{Name: helperUniqueName("scoped_team")})
+			Expect(err).NotTo(HaveOccurred(), "unexpected error: %v", err)
+			other, err := s.Teams().Create(&sdk.CreateTeamRequest{Name: helperUniqueName("scoped_other")})
+			Expect(err).NotTo(HaveOccurred(), "unexpected error: %v", err)
+			DeferCleanup(func() {
+				s = loginAsAdmin(s)
+				_ = s.Teams().Delete(team.ID)
+				_ = s.Teams().Delete(other.ID)
+				_ = s.Roles().Delete(role.ID)
+			})
+			project, err := s.Teams().CreateProject(team.ID, &sdk.CreateProjectRequest{Name: helperUniqueName("scoped_project")})
+			Expect(err).NotTo(HaveOccurred(), "unexpected error: %v", err)
+			Expect(s.Teams().AddUser(team.ID, peer.ID)).To(Succeed())
+			Expect(s.Projects().AddUser(project.ID, reviewer.ID)).To(Succeed())
+
+			// 只能在用户所在的 Team 内绑定。
+			Expect(s.Users().AddTeamRole(reviewer.ID, role.ID, other.ID)).To(sdk.HaveOccurredWithStatusCode(http.StatusBadRequest))
+			Expect(s.Users().AddTeamRole(reviewer.ID, role.ID, 999999)).To(sdk.HaveOccurredWithStatusCode(http.StatusNotFound))
+			Expect(s.Users().AddTeamRole(reviewer.ID, role.ID, team.ID)).To(Succeed())
+
+			bindings, err := s.Users().ListRoles(reviewer.ID)
+			Expect(err).NotTo(HaveOccurred(), "unexpected error: %v", err)
+			var scoped []sdk.RoleBinding
+			for _, b := range bindings.List {
+				Expect(b.User.ID).To(Equal(reviewer.ID))
+				if b.Team != nil {
+					scoped = append(scoped, b)
+				} else {
+					Expect(b.Role.Name).NotTo(Equal(roleName), "the binding should not be global")
+				}
+			}
+			Expect(scoped).To(HaveLen(1))
+			Expect(scoped[0].Team.ID).To(Equal(team.ID))
+			Expect(scoped[0].Role.ID).To(Equal(role.ID))
+
+			names := func(users *sdk.UsersListResponse, err error) []string {
+				Expect(err).NotTo(HaveOccurred(), "unexpected error: %v", err)
+				var names []string
+				for _, u := range users.List {
+					names = append(names, u.Username)
+				}
+				return names
+			}
+			byRole := &sdk.ListParams{RoleNames: []string{roleName}}
+			Expect(names(s.Users().List(byRole))).To(BeEmpty(), "a team-scoped binding should not match globally")
+			Expect(names(s.Users().List(&sdk.ListParams{RoleNames: []string{roleName}, TeamIds: []int{team.ID}}))).To(ConsistOf(reviewer.Username))
+			Expect(names(s.Teams().ListUsers(team.ID, byRole))).To(ConsistOf(reviewer.Username))
+			Expect(names(s.Projects().ListUsers(project.ID, byRole))).To(ConsistOf(reviewer.Username))
+
+			// 同一 Team 的成员可以查看该 Team 内的绑定。
+			peerClient, err := s.LoginWithUsername(peer.Username, peerPass)
+			Expect(err).NotTo(HaveOccurred(), "unexpected error: %v", err)
+			teamBindings, err := peerClient.Teams().ListRoles(team.ID)
+			Expect(err).NotTo(HaveOccurred(), "unexpected error: %v", err)
+			Expect(teamBindings.List).To(HaveLen(1))
+			Expect(teamBindings.List[0].User.ID).To(Equal(reviewer.ID))
+			_, err = peerClient.Teams().ListRoles(other.ID)
+			Expect(err).To(sdk.HaveOccurredWithStatusCode(http.StatusForbidden))
+			Expect(peerClient.Users().RemoveTeamRole(reviewer.ID, role.ID, team.ID)).To(sdk.HaveOccurredWithStatusCode(http.StatusForbidden))
+
+			// 不在该 Team 的用户即使能看到 reviewer，也不能通过 team_id 探知该 Team 内的绑定。
+			outsider, outsiderPass := createTestUser("scoped_outsider")
+			s = loginAsAdmin(s)
+			Expect(s.Teams().AddUser(other.ID, outsider.ID)).To(Succeed())
+			Expect(s.Teams().AddUser(other.ID, reviewer.ID)).To(Succeed())
+			outsiderClient, err := s.LoginWithUsername(outsider.Username, outsiderPass)
+			Expect(err).NotTo(HaveOccurred(), "unexpected error: %v", err)
+			Expect(names(outsiderClient.Users().List(&sdk.ListParams{TeamIds: []int{team.ID}}))).To(BeEmpty())
+			Expect(names(outsiderClient.Users().List(&sdk.ListParams{RoleNames: []string{roleName}, TeamIds: []int{team.ID, other.ID}}))).To(BeEmpty())
+			Expect(names(outsiderClient.Users().List(&sdk.ListParams{TeamIds: []int{team.ID, other.ID}}))).To(ConsistOf(outsider.Username, reviewer.Username))
+
+			// 离开 Team 时解除绑定。
+			s = loginAsAdmin(s)
+			Expect(s.Teams().RemoveUser(team.ID, reviewer.ID)).To(Succeed())
+			teamBindings, err = s.Teams().ListRoles(team.ID)
+			Expect(err).NotTo(HaveOccurred(), "unexpected error: %v", err)
+			Expect(teamBindings.List).To(BeEmpty())
+			Expect(s.Users().RemoveTeamRole(reviewer.ID, role.ID, team.ID)).To(sdk.HaveOccurredWithStatusCode(http.StatusNotFound))
+
+			// 解绑与删除 Team 同样解除绑定。
+			Expect(s.Teams().AddUser(team.ID, reviewer.ID)).To(Succeed())
+			Expect(s.Users().AddTeamRole(reviewer.ID, role.ID, team.ID)).To(Succeed())
+			Expect(s.Users().AddTeamRole(peer.ID, role.ID, team.ID)).To(Succeed())
+			Expect(s.Users().RemoveTeamRole(peer.ID, role.ID, team.ID)).To(Succeed())
+			Expect(s.Teams().Delete(team.ID)).To(Succeed())
+			bindings, err = s.Users().ListRoles(reviewer.ID)
+			Expect(err).NotTo(HaveOccurred(), "unexpected error: %v", err)
+			for _, b := range bindings.List {
+				Expect(b.Team).To(BeNil())
+			}
+		})
+
+		It("should grant team scoped permissions within the team only", func() {
+			s := sdk.GetSDK().Guest()
+			user, pass := createTestUser("scoped_perm")
+
+			s = loginAsAdmin(s)
+			role, err := s.Roles().Create(&sdk.CreateRoleRequest{Name: helperUniqueName("scoped_perm"), Permissions: []string{"projects:read", "projects:update"}})
+			Expect(err).NotTo(HaveOccurred(), "unexpected error: %v", err)
+			auditor, err := s.Roles().Create(&sdk.CreateRoleRequest{Name: helperUniqueName("scoped_audit"), Permissions: []string{"audits:read"}})
+			Expect(err).NotTo(HaveOccurred(), "unexpected error: %v", err)
+			team, err := s.Teams().Create(&sdk.CreateTeamRequest{Name: helperUniqueName("scoped_perm_team")})
+			Expect(err).NotTo(HaveOccurred(), "unexpected error: %v", err)
+			other, err := s.Teams().Create(&sdk.CreateTeamRequest{Name: helperUniqueName("scoped_perm_other")})
+			Expect(err).NotTo(HaveOccurred(), "unexpected error: %v", err)
+			DeferCleanup(func() {
+				s = loginAsAdmin(s)
+				_ = s.Teams().Delete(team.ID)
+				_ = s.Teams().Delete(other.ID)
+				_ = s.Roles().Delete(role.ID)
+				_ = s.Roles().Delete(auditor.ID)
+			})
+			project, err := s.Teams().CreateProject(team.ID, &sdk.CreateProjectRequest{Name: helperUniqueName("scoped_perm_p")})
+			Expect(err).NotTo(HaveOccurred(), "unexpected error: %v", err)
+			otherProject, err := s.Teams().CreateProject(other.ID, &sdk.CreateProjectRequest{Name: helperUniqueName("scoped_perm_p")})
+			Expect(err).NotTo(HaveOccurred(), "unexpected error: %v", err)
+			Expect(s.Teams().AddUser(team.ID, user.ID)).To(Succeed())
+			Expect(s.Teams().AddUser(other.ID, user.ID)).To(Succeed())
+
+			By("- Roles with permissions that only apply globally can not be bound within a team")
+			Expect(s.Users().AddTeamRole(user.ID, auditor.ID, team.ID)).To(sdk.HaveOccurredWithStatusCode(http.StatusBadRequest))
+			Expect(s.Users().AddTeamRole(user.ID, role.ID, team.ID)).To(Succeed())
+			_, err = s.Roles().Update(role.ID, &sdk.UpdateRoleRequest{Permissions: &[]string{"projects:read", "audits:read"}})
+			Expect(err).To(sdk.HaveOccurredWithStatusCode(http.StatusBadRequest))
+
+			By("- The binding grants its permissions on the projects of the team")
+			client, err := s.LoginWithUsername(user.Username, pass)
+			Expect(err).NotTo(HaveOccurred(), "unexpected error: %v", err)
+			_, err = client.Projects().Get(project.ID)
+			Expect(err).NotTo(HaveOccurred(), "unexpected error: %v", err)
+			_, err = client.Projects().Update(project.ID, &sdk.UpdateProjectRequest{Name: helperUniqueName("scoped_perm_r")})
+			Expect(err).NotTo(HaveOccurred(), "unexpected error: %v", err)
+			Expect(client.Projects().Delete(project.ID)).To(sdk.HaveOccurredWithStatusCode(http.StatusForbidden))
+			_, err = client.Teams().CreateProject(team.ID, &sdk.CreateProjectRequest{Name: helperUniqueName("scoped_perm_n")})
+			Expect(err).To(sdk.HaveOccurredWithStatusCode(http.StatusForbidden))
+			permissions, err := client.Me().Permissions(sdk.ProjectResource(project.ID))
+			Expect(err).NotTo(HaveOccurred(), "unexpected error: %v", err)
+			Expect(permissions.Actions).To(ContainElements("getProject", "updateProject", "patchProject"))
+			Expect(permissions.Actions).NotTo(ContainElement("deleteProject"))
+
+			By("- But not in other teams")
+			_, err = client.Projects().Get(otherProject.ID)
+			Expect(err).To(sdk.HaveOccurredWithStatusCode(http.StatusForbidden))
+			_, err = client.Projects().Update(otherProject.ID, &sdk.UpdateProjectRequest{Name: helperUniqueName("scoped_perm_r")})
+			Expect(err).To(sdk.HaveOccurredWithStatusCode(http.StatusForbidden))
+		})
+	})
+
 	Context("Role Permissions", func() {
 		It("should allow only admin to manage roles", func() {
 			s := sdk.GetSDK().Guest()
@@ -1118,5 +1555,81 @@ var _ = Describe("Roles", Label("Role"), func() {
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(ContainSubstring("403"))
 		})
+
+		It("should list the permissions that can be granted", func() {
+			s := sdk.GetSDK().Guest()
+			user, pass := createAndSetupUser(helperUniqueName("perm_list"), "pass1234")
+			DeferCleanup(func() {
+				s = loginAsAdmin(s)
+				_ = s.Users().Delete(user.ID)
+			})
+			s, err := s.LoginWithUsername(user.Username, pass)
+			Expect(err).NotTo(HaveOccurred(), "unexpected error: %v", err)
+			permissions, err := s.Roles().Permissions()
+			Expect(err).NotTo(HaveOccurred(), "unexpected error: %v", err)
+			var names []string
+			for _, p := range permissions.List {
+				Expect(p.Desc).NotTo(BeEmpty())
+				names = append(names, p.Name)
+			}
+			Expect(names).To(ContainElements("audits:read", "teams:create", "users:create"))
+		})
+
+		It("should grant the permissions of custom roles", func() {
+			s := sdk.GetSDK().Guest()
+			user, pass := createAndSetupUser(helperUniqueName("perm_grant"), "pass1234")
+			DeferCleanup(func() {
+				s = loginAsAdmin(s)
+				_ = s.Users().Delete(user.ID)
+			})
+
+			s = loginAsAdmin(s)
+			_, err := s.Roles().Create(&sdk.CreateRoleRequest{Name: helperUniqueName("perm_bad"), Permissions: []string{"audits:write"}})
+			Expect(err).To(sdk.HaveOccurredWithStatusCode(http.StatusBadRequest))
+			roles, err := s.Roles().List()
+			Expect(err).NotTo(HaveOccurred(), "unexpected error: %v", err)
+			for _, role := range roles.List {
+				if role.Type == "System" {
+					_, err = s.Roles().Update(role.ID, &sdk.UpdateRoleRequest{Permissions: &[]string{"audits:read"}})
+					Expect(err).To(sdk.HaveOccurredWithStatusCode(http.StatusBadRequest))
+				}
+			}
+
+			role, err := s.Roles().Create(&sdk.CreateRoleRequest{Name: helperUniqueName("auditor"), Permissions: []string{"teams:create", "audits:read", "audits:read"}})
+			Expect(err).NotTo(HaveOccurred(), "unexpected error: %v", err)
+			Expect(role.Permissions).To(Equal([]string{"audits:read", "teams:create"}))
+			DeferCleanup(func() {
+				s = loginAsAdmin(s)
+				_ = s.Roles().Delete(role.ID)
+			})
+			Expect(s.Users().AddRole(user.ID, role.ID)).To(Succeed())
+
+			s, err = s.LoginWithUsername(user.Username, pass)
+			Expect(err).NotTo(HaveOccurred(), "unexpected error: %v", err)
+			_, err = s.Audits().List(nil)
+			Expect(err).NotTo(HaveOccurred(), "unexpected error: %v", err)
+			team, err := s.Teams().Create(&sdk.CreateTeamRequest{Name: helperUniqueName("perm_team")})
+			Expect(err).NotTo(HaveOccurred(), "unexpected error: %v", err)
+			DeferCleanup(func() {
+				s = loginAsAdmin(s)
+				_ = s.Teams().Delete(team.ID)
+			})
+			// 未被授予的权限与 Role 管理仍只有 admin 可以执行。
+			_, err = s.Users().Create(helperUniqueName("perm_user"), "pass1234")
+			Expect(err).To(sdk.HaveOccurredWithStatusCode(http.StatusForbidden))
+			_, err = s.Roles().Update(role.ID, &sdk.UpdateRoleRequest{Permissions: &[]string{"users:create"}})
+			Expect(err).To(sdk.HaveOccurredWithStatusCode(http.StatusForbidden))
+
+			s = loginAsAdmin(s)
+			updated, err := s.Roles().Update(role.ID, &sdk.UpdateRoleRequest{Permissions: &[]string{"teams:create"}})
+			Expect(err).NotTo(HaveOccurred(), "unexpected error: %v", err)
+			Expect(updated.Permissions).To(Equal([]string{"teams:create"}))
+			Expect(updated.Name).To(Equal(role.Name))
+
+			s, err = s.LoginWithUsername(user.Username, pass)
+			Expect(err).NotTo(HaveOccurred(), "unexpected error: %v", err)
+			_, err = s.Audits().List(nil)
+			Expect(err).To(sdk.HaveOccurredWithStatusCode(http.StatusForbidden))
+		})
 	})
 })
@@ -0,0 +1,156 @@
+// Package bootstrap 在每次启动时确保系统初始化数据存在：内置的 3 个 System Roles 与 admin 用户。
+//
+// 初始化是幂等的：只创建缺失的数据，不修改已存在的数据（如 admin 已修改的密码）；
+// 唯一的例外是 admin 丢失了 admin Role 的绑定时会被修复。多个副本同时启动时，
+// 插入冲突的一方会读取另一方已写入的数据，不会重复创建或重置 admin。
+package bootstrap
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/dspo/go-homework/pkg/models"
+	"github.com/dspo/go-homework/pkg/password"
+)
+
+// AdminInitialPassword 是 admin 的初始密码，首次登录后必须修改。
+const AdminInitialPassword = "adminadmin"
+
+// SystemRoles 是内置的 System Roles，ID 固定。
+var SystemRoles = []models.Role{
+	{ID: 1, Name: models.RoleAdmin, Type: models.RoleTypeSystem, Description: "超级管理员"},
+	{ID: 2, Name: models.RoleTeamLeader, Type: models.RoleTypeSystem, Description: "至少担任一个 Team 的 Leader"},
+	{ID: 3, Name: models.RoleNormalUser, Type: models.RoleTypeSystem, Description: "普通用户"},
+}
+
+// Report 记录一次初始化实际做了哪些修改。
+type Report struct {
+	// CreatedRoles 是本次创建的 System Roles 的名称。
+	CreatedRoles []string
+	// CreatedAdmin 表示本次创建了 admin 用户。
+	CreatedAdmin bool
+	// BoundAdminRole 表示本次为 admin 用户补充绑定了 admin Role。
+	BoundAdminRole bool
+	// AdminID 是 admin 用户的 ID。
+	AdminID uint
+}
+
+// Changed 判断本次初始化是否修改了数据。
+func (r *Report) Changed() bool {
+	return len(r.CreatedRoles) > 0 || r.CreatedAdmin || r.BoundAdminRole
+}
+
+func (r *Report) String() string {
+	if !r.Changed() {
+		return "bootstrap: nothing to do, all seed data is present"
+	}
+	var items []string
+	if len(r.CreatedRoles) > 0 {
+		items = append(items, fmt.Sprintf("created system roles %q", r.CreatedRoles))
+	}
+	if r.CreatedAdmin {
+		items = append(items, fmt.Sprintf("created user %s (ID:%d) with the initial password", models.AdminUsername, r.AdminID))
+	}
+	if r.BoundAdminRole {
+		items = append(items, fmt.Sprintf("repaired role binding %s -> %s", models.AdminUsername, models.RoleAdmin))
+	}
+	return "bootstrap: " + strings.Join(items, "; ")
+}
+
+// Run 创建缺失的 System Roles 与 admin 用户，并确保 admin 绑定了 admin Role。
+func Run(ctx context.Context, db *gorm.DB) (*Report, error) {
+	// 各步骤独立提交而不放在一个事务中：并发启动时插入冲突的一方需要读到另一方已提交的数据。
+	tx := db.WithContext(ctx)
+	report := new(Report)
+	roleIDs := make(map[string]uint, len(SystemRoles))
+	for _, role := range SystemRoles {
+		id, created, err := ensureRole(tx, role)
+		if err != nil {
+			return nil, fmt.Errorf("failed to ensure role %q: %w", role.Name, err)
+		}
+		if created {
+			report.CreatedRoles = append(report.CreatedRoles, role.Name)
+		}
+		roleIDs[role.Name] = id
+	}
+
+	admin, created, err := ensureAdmin(tx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to ensure user %q: %w", models.AdminUsername, err)
+	}
+	report.CreatedAdmin, report.AdminID = created, admin.ID
+
+	result := tx.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&models.UserRole{UserID: admin.ID, RoleID: roleIDs[models.RoleAdmin]})
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to bind role %q: %w", models.RoleAdmin, result.Error)
+	}
+	// 新建的 admin 本就需要绑定，不算作修复。
+	report.BoundAdminRole = result.RowsAffected > 0 && !created
+	return report, nil
+}
+
+// ensureRole 返回名为 role.Name 的 Role 的 ID，不存在时以 role 创建。
+// 预设的 ID 已被其他 Role 占用时由数据库分配 ID。
+func ensureRole(tx *gorm.DB, role models.Role) (uint, bool, error) {
+	var existing models.Role
+	result := tx.Where("name = ?", role.Name).Limit(1).Find(&existing)
+	if result.Error != nil || result.RowsAffected > 0 {
+		return existing.ID, false, result.Error
+	}
+
+	var taken int64
+	if err := tx.Model(&models.Role{}).Where("id = ?", role.ID).Count(&taken).Error; err != nil {
+		return 0, false, err
+	}
+	if taken > 0 {
+		role.ID = 0
+	}
+	result = tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&role)
+	if result.Error != nil {
+		return 0, false, result.Error
+	}
+	if result.RowsAffected > 0 {
+		return role.ID, true, nil
+	}
+	// 其他副本抢先创建了该 Role。
+	if err := tx.Where("name = ?", role.Name).First(&existing).Error; err != nil {
+		return 0, false, err
+	}
+	return existing.ID, false, nil
+}
+
+// ensureAdmin 返回 admin 用户，不存在时以初始密码创建并要求首次登录修改密码。
+func ensureAdmin(tx *gorm.DB) (*models.User, bool, error) {
+	var admin models.User
+	result := tx.Where("username = ?", models.AdminUsername).Limit(1).Find(&admin)
+	if result.Error != nil || result.RowsAffected > 0 {
+		return &admin, false, result.Error
+	}
+
+	hash, err := password.Hash(AdminInitialPassword)
+	if err != nil {
+		return nil, false, err
+	}
+	admin = models.User{
+		Username:           models.AdminUsername,
+		PasswordHash:       hash,
+		MustChangePassword: true,
+	}
+	result = tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&admin)
+	if result.Error != nil {
+		return nil, false, result.Error
+	}
+	if result.RowsAffected > 0 {
+		return &admin, true, nil
+	}
+	// 其他副本抢先创建了 admin。
+	if err := tx.Where("username = ?", models.AdminUsername).First(&admin).Error; err != nil {
+		return nil, false, err
+	}
+	return &admin, false, nil
+}
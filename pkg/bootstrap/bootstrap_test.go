@@ -0,0 +1,56 @@
+package bootstrap
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/dspo/go-homework/pkg/config"
+	"github.com/dspo/go-homework/pkg/database"
+	"github.com/dspo/go-homework/pkg/migrate"
+	"github.com/dspo/go-homework/pkg/models"
+)
+
+func TestRunIsIdempotent(t *testing.T) {
+	db, err := database.Open(config.Database{Name: config.DatabaseSQLite, Path: filepath.Join(t.TempDir(), "app.db")}, nil)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	m, err := migrate.New(db)
+	if err != nil {
+		t.Fatalf("failed to load migrations: %v", err)
+	}
+	if _, err := m.Up(context.Background()); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+
+	report, err := Run(context.Background(), db)
+	if err != nil {
+		t.Fatalf("first Run() error = %v", err)
+	}
+	if len(report.CreatedRoles) != len(SystemRoles) || !report.CreatedAdmin || report.BoundAdminRole {
+		t.Errorf("first Run() = %+v", report)
+	}
+
+	// admin 修改过的密码不应被重置。
+	if err := db.Model(&models.User{ID: report.AdminID}).Updates(map[string]any{"password_hash": "changed", "must_change_password": false}).Error; err != nil {
+		t.Fatal(err)
+	}
+	report, err = Run(context.Background(), db)
+	if err != nil || report.Changed() {
+		t.Errorf("second Run() = %+v, %v", report, err)
+	}
+	var admin models.User
+	db.Preload("Roles").First(&admin, report.AdminID)
+	if admin.PasswordHash != "changed" || admin.MustChangePassword || !admin.IsAdmin() {
+		t.Errorf("admin was modified: %+v", admin)
+	}
+
+	if err := db.Where("user_id = ?", admin.ID).Delete(&models.UserRole{}).Error; err != nil {
+		t.Fatal(err)
+	}
+	report, err = Run(context.Background(), db)
+	if err != nil || !report.BoundAdminRole || report.CreatedAdmin || len(report.CreatedRoles) > 0 {
+		t.Errorf("Run() after losing the admin role = %+v, %v", report, err)
+	}
+}
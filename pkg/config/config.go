@@ -0,0 +1,542 @@
+// Package config 加载并校验 config/app/config.yaml，是应用与测试框架共用的配置来源。
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// DefaultPath 是未通过 flag 或环境变量指定时使用的配置文件路径。
+	DefaultPath = "config/app/config.yaml"
+	// PathEnv 是用于指定配置文件路径的环境变量。
+	PathEnv = "APP_CONFIG"
+)
+
+// 配置项的默认值。
+const (
+	DefaultAppName      = "go-homework"
+	DefaultListenHost   = "0.0.0.0"
+	DefaultListenPort   = 8080
+	DefaultLogLevel     = "info"
+	DefaultDatabaseName = DatabaseMySQL
+	DefaultDatabasePort = 3306
+	DefaultDBName       = "go_dev"
+	DefaultDatabasePath = "data/go-homework.db"
+
+	DefaultSessionStore           = SessionStoreDatabase
+	DefaultSessionAbsoluteTimeout = 24 * time.Hour
+	DefaultSessionIdleTimeout     = 2 * time.Hour
+	DefaultSessionCookieSameSite  = SameSiteLax
+)
+
+// DefaultLoginThrottle 是 login_throttle 各项的默认值。
+// 单个 IP 的阈值较高，以免同一出口 IP 后的多个用户相互影响。
+var DefaultLoginThrottle = LoginThrottle{
+	AccountMaxFailures: 5,
+	IPMaxFailures:      50,
+	Window:             15 * time.Minute,
+	Lockout:            time.Minute,
+	MaxLockout:         time.Hour,
+}
+
+// DefaultPasswordPolicy 是 password_policy 各项的默认值，与早期版本硬编码的规则一致：
+// 8-30 个字符，只能包含字母、数字、下划线和连字符。
+var DefaultPasswordPolicy = PasswordPolicy{
+	MinLength:      8,
+	MaxLength:      30,
+	AllowedSymbols: "_-",
+	Banned:         []string{"password", "password1", "12345678", "123456789", "1234567890", "qwerty123", "11111111", "abc12345"},
+	History:        5,
+}
+
+// password_policy.required_classes 支持的字符类别。
+const (
+	CharacterClassLower  = "lower"
+	CharacterClassUpper  = "upper"
+	CharacterClassDigit  = "digit"
+	CharacterClassSymbol = "symbol"
+)
+
+// DefaultOIDCScopes 与 DefaultOIDCUsernameClaim 是 oidc.scopes 与 oidc.username_claim 的默认值。
+var DefaultOIDCScopes = []string{"openid", "profile", "email"}
+
+const DefaultOIDCUsernameClaim = "preferred_username"
+
+var characterClasses = []string{CharacterClassLower, CharacterClassUpper, CharacterClassDigit, CharacterClassSymbol}
+
+// database.name 支持的数据库后端。
+const (
+	DatabaseMySQL  = "mysql"
+	DatabaseSQLite = "sqlite"
+)
+
+var databases = []string{DatabaseMySQL, DatabaseSQLite}
+
+// session.store 支持的会话存储。
+const (
+	SessionStoreMemory   = "memory"
+	SessionStoreDatabase = "database"
+)
+
+var sessionStores = []string{SessionStoreMemory, SessionStoreDatabase}
+
+// session.cookie.same_site 支持的取值。
+const (
+	SameSiteLax    = "lax"
+	SameSiteStrict = "strict"
+	SameSiteNone   = "none"
+)
+
+var sameSiteModes = []string{SameSiteLax, SameSiteStrict, SameSiteNone}
+
+var logLevels = []string{"debug", "info", "warn", "error"}
+
+// Config 对应 config.yaml 的完整结构。
+type Config struct {
+	AppName        string         `yaml:"app_name"`
+	Server         Server         `yaml:"server"`
+	Log            Log            `yaml:"log"`
+	Database       Database       `yaml:"database"`
+	Session        Session        `yaml:"session"`
+	LoginThrottle  LoginThrottle  `yaml:"login_throttle"`
+	PasswordPolicy PasswordPolicy `yaml:"password_policy"`
+	OIDC           OIDC           `yaml:"oidc"`
+	Prometheus     Prometheus     `yaml:"prometheus"`
+}
+
+type Server struct {
+	Listen Listen `yaml:"listen"`
+	// TrustedProxies 是可信的反向代理的 IP 或 CIDR，只有来自这些地址的请求才采信 X-Forwarded-For 等头中的客户端 IP。
+	// 默认为空，即总是使用连接的对端地址，避免客户端伪造 IP 绕过按 IP 的登录限流。
+	TrustedProxies []string `yaml:"trusted_proxies"`
+}
+
+type Listen struct {
+	Host string `yaml:"host"`
+	Port int    `yaml:"port"`
+}
+
+// Address 返回 host:port 形式的监听地址。
+func (l Listen) Address() string {
+	return net.JoinHostPort(l.Host, strconv.Itoa(l.Port))
+}
+
+type Log struct {
+	Level string `yaml:"level"`
+}
+
+type Database struct {
+	// Name 是数据库后端的类型，mysql 或 sqlite。
+	Name     string `yaml:"name"`
+	Server   string `yaml:"server"`
+	Port     int    `yaml:"port"`
+	User     string `yaml:"user"`
+	Password string `yaml:"password"`
+	// DBName 是应用使用的库名。
+	DBName string `yaml:"dbname"`
+	// Path 是 SQLite 数据库文件的路径，仅 sqlite 使用。
+	Path string `yaml:"path"`
+}
+
+// DSN 返回连接应用库的 DSN，格式取决于 Name。
+func (d Database) DSN() string {
+	if d.Name == DatabaseSQLite {
+		// SQLite 默认不检查外键，需显式开启以与 MySQL 的约束行为一致。
+		return "file:" + d.Path + "?_foreign_keys=1&_busy_timeout=5000&_journal_mode=WAL&_loc=auto"
+	}
+	return d.mysqlConfig(d.DBName).FormatDSN()
+}
+
+// ServerDSN 返回不指定库名的 MySQL DSN，用于建库、删库等操作。
+func (d Database) ServerDSN() string {
+	return d.mysqlConfig("").FormatDSN()
+}
+
+func (d Database) mysqlConfig(dbName string) *mysql.Config {
+	cfg := mysql.NewConfig()
+	cfg.User = d.User
+	cfg.Passwd = d.Password
+	cfg.Net = "tcp"
+	cfg.Addr = net.JoinHostPort(d.Server, strconv.Itoa(d.Port))
+	cfg.DBName = dbName
+	cfg.ParseTime = true
+	cfg.Loc = time.Local
+	cfg.Params = map[string]string{"charset": "utf8mb4"}
+	return cfg
+}
+
+type Session struct {
+	// Store 是会话的存储位置。memory 仅适用于单副本，多副本部署须使用 database 以共享会话。
+	Store string `yaml:"store"`
+	// AbsoluteTimeout 是会话自登录起的最长有效期。
+	AbsoluteTimeout time.Duration `yaml:"absolute_timeout"`
+	// IdleTimeout 是会话无访问时的最长有效期，每次访问都会续期，0 表示不限制，未配置时为 DefaultSessionIdleTimeout。
+	IdleTimeout *time.Duration `yaml:"idle_timeout"`
+	Cookie      SessionCookie  `yaml:"cookie"`
+}
+
+// SessionCookie 是 session Cookie 的属性，CSRF token 的 Cookie 使用相同的属性。
+// session Cookie 总是 HttpOnly，页面脚本无法读取。
+type SessionCookie struct {
+	// Secure 为 true 时浏览器只通过 HTTPS 发送 Cookie，经 HTTPS 对外提供服务时应当开启。
+	Secure bool `yaml:"secure"`
+	// SameSite 是 lax、strict 或 none，none 要求同时开启 Secure。
+	SameSite string `yaml:"same_site"`
+	// Domain 为空时 Cookie 只发送给设置它的主机，不包括子域名。
+	Domain string `yaml:"domain"`
+}
+
+// LoginThrottle 是登录失败的限流与锁定设置，账号与 IP 分别计数。
+type LoginThrottle struct {
+	// AccountMaxFailures 是同一账号连续登录失败多少次后锁定。
+	AccountMaxFailures int `yaml:"account_max_failures"`
+	// IPMaxFailures 是同一 IP 连续登录失败多少次后锁定。
+	IPMaxFailures int `yaml:"ip_max_failures"`
+	// Window 是失败计数的有效期，自最近一次失败或锁定结束起计算，过期后重新计数。
+	Window time.Duration `yaml:"window"`
+	// Lockout 是首次锁定的时长，此后每多失败一次锁定时长翻倍，最多为 MaxLockout。
+	Lockout    time.Duration `yaml:"lockout"`
+	MaxLockout time.Duration `yaml:"max_lockout"`
+}
+
+// PasswordPolicy 是用户设置密码时须满足的规则，创建用户与修改密码时校验。
+type PasswordPolicy struct {
+	MinLength int `yaml:"min_length"`
+	MaxLength int `yaml:"max_length"`
+	// AllowedSymbols 是字母与数字之外允许使用的字符。
+	AllowedSymbols string `yaml:"allowed_symbols"`
+	// RequiredClasses 是密码须包含的字符类别，可选 lower、upper、digit、symbol。
+	RequiredClasses []string `yaml:"required_classes"`
+	// Banned 是禁止使用的常见弱密码，不区分大小写。
+	Banned []string `yaml:"banned"`
+	// History 是不允许重复使用的最近密码个数，包括当前密码。
+	History int `yaml:"history"`
+	// MaxAge 是密码的最长使用时间，过期后用户须先修改密码，0 表示永不过期。
+	MaxAge time.Duration `yaml:"max_age"`
+}
+
+// OIDC 是通过 OpenID Connect 身份提供方（IdP）单点登录的设置。
+type OIDC struct {
+	Enabled bool `yaml:"enabled"`
+	// Issuer 是 IdP 的 issuer URL，服务端从 {issuer}/.well-known/openid-configuration 发现 IdP 的端点。
+	Issuer       string `yaml:"issuer"`
+	ClientID     string `yaml:"client_id"`
+	ClientSecret string `yaml:"client_secret"`
+	// RedirectURL 是在 IdP 登记的回调地址，即本服务的 /api/login/oidc/callback 的完整 URL。
+	RedirectURL string   `yaml:"redirect_url"`
+	Scopes      []string `yaml:"scopes"`
+	// UsernameClaim 是自动创建用户时用作用户名的 ID Token claim。
+	UsernameClaim string `yaml:"username_claim"`
+	// AutoProvision 为 true 时，为无法匹配到已有用户的身份自动创建用户并绑定 normal user Role；
+	// 为 false 时这些身份无法登录，须由 admin 预先创建邮箱相同的用户。
+	AutoProvision bool `yaml:"auto_provision"`
+}
+
+type Prometheus struct {
+	Address string `yaml:"address"`
+}
+
+// Path 返回配置文件路径，优先级为 flag > 环境变量 APP_CONFIG > DefaultPath。
+func Path(flagValue string) string {
+	if flagValue != "" {
+		return flagValue
+	}
+	if v := os.Getenv(PathEnv); v != "" {
+		return v
+	}
+	return DefaultPath
+}
+
+// Load 读取并解析 path 指定的配置文件。
+func Load(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+	cfg, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// Parse 展开 data 中的 ${ENV} 占位符，解析为 Config，补全默认值并校验。
+// 校验失败时返回 *ValidationError，其中列出每一个不合法的配置项。
+func Parse(data []byte) (*Config, error) {
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, err
+	}
+	expandNode(&root)
+
+	// 展开后重新编码，以便借助 KnownFields 拒绝未知的配置项。
+	expanded, err := yaml.Marshal(&root)
+	if err != nil {
+		return nil, err
+	}
+	var cfg Config
+	dec := yaml.NewDecoder(bytes.NewReader(expanded))
+	dec.KnownFields(true)
+	if err := dec.Decode(&cfg); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+
+	cfg.applyDefaults()
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+var placeholder = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// expandNode 递归展开标量中的 ${ENV} 占位符。未设置的环境变量展开为空字符串，
+// 整个值为空时该项视为未配置，随后由默认值补全。
+func expandNode(n *yaml.Node) {
+	if n.Kind == yaml.ScalarNode {
+		if !placeholder.MatchString(n.Value) {
+			return
+		}
+		n.Value = placeholder.ReplaceAllStringFunc(n.Value, func(s string) string {
+			return os.Getenv(placeholder.FindStringSubmatch(s)[1])
+		})
+		// 清空 tag 使展开后的值重新推断类型，如端口号解析为整数。
+		n.Tag = ""
+		return
+	}
+	for _, child := range n.Content {
+		expandNode(child)
+	}
+}
+
+func (c *Config) applyDefaults() {
+	if c.AppName == "" {
+		c.AppName = DefaultAppName
+	}
+	if c.Server.Listen.Host == "" {
+		c.Server.Listen.Host = DefaultListenHost
+	}
+	if c.Server.Listen.Port == 0 {
+		c.Server.Listen.Port = DefaultListenPort
+	}
+	if c.Log.Level == "" {
+		c.Log.Level = DefaultLogLevel
+	}
+	if c.Database.Name == "" {
+		c.Database.Name = DefaultDatabaseName
+	}
+	if c.Database.Port == 0 {
+		c.Database.Port = DefaultDatabasePort
+	}
+	if c.Database.DBName == "" {
+		c.Database.DBName = DefaultDBName
+	}
+	if c.Database.Name == DatabaseSQLite && c.Database.Path == "" {
+		c.Database.Path = DefaultDatabasePath
+	}
+	lt, dlt := &c.LoginThrottle, DefaultLoginThrottle
+	if lt.AccountMaxFailures == 0 {
+		lt.AccountMaxFailures = dlt.AccountMaxFailures
+	}
+	if lt.IPMaxFailures == 0 {
+		lt.IPMaxFailures = dlt.IPMaxFailures
+	}
+	if lt.Window == 0 {
+		lt.Window = dlt.Window
+	}
+	if lt.Lockout == 0 {
+		lt.Lockout = dlt.Lockout
+	}
+	if lt.MaxLockout == 0 {
+		lt.MaxLockout = dlt.MaxLockout
+	}
+	pp, dpp := &c.PasswordPolicy, DefaultPasswordPolicy
+	if pp.MinLength == 0 {
+		pp.MinLength = dpp.MinLength
+	}
+	if pp.MaxLength == 0 {
+		pp.MaxLength = dpp.MaxLength
+	}
+	if pp.AllowedSymbols == "" {
+		pp.AllowedSymbols = dpp.AllowedSymbols
+	}
+	// 显式配置为空列表时不禁用任何密码。
+	if pp.Banned == nil {
+		pp.Banned = dpp.Banned
+	}
+	if pp.History == 0 {
+		pp.History = dpp.History
+	}
+	if c.OIDC.Scopes == nil {
+		c.OIDC.Scopes = DefaultOIDCScopes
+	}
+	if c.OIDC.UsernameClaim == "" {
+		c.OIDC.UsernameClaim = DefaultOIDCUsernameClaim
+	}
+	if c.Session.Store == "" {
+		c.Session.Store = DefaultSessionStore
+	}
+	if c.Session.AbsoluteTimeout == 0 {
+		c.Session.AbsoluteTimeout = DefaultSessionAbsoluteTimeout
+	}
+	// 显式配置为 0 时不限制空闲时间。
+	if c.Session.IdleTimeout == nil {
+		idle := DefaultSessionIdleTimeout
+		c.Session.IdleTimeout = &idle
+	}
+	if c.Session.Cookie.SameSite == "" {
+		c.Session.Cookie.SameSite = DefaultSessionCookieSameSite
+	}
+}
+
+// Problem 描述一个不合法的配置项。
+type Problem struct {
+	Key     string
+	Message string
+}
+
+// ValidationError 汇总全部不合法的配置项。
+type ValidationError struct {
+	Problems []Problem
+}
+
+func (e *ValidationError) Error() string {
+	items := make([]string, 0, len(e.Problems))
+	for _, p := range e.Problems {
+		items = append(items, p.Key+": "+p.Message)
+	}
+	return "invalid configuration: " + strings.Join(items, "; ")
+}
+
+func (e *ValidationError) add(key, format string, args ...any) {
+	e.Problems = append(e.Problems, Problem{Key: key, Message: fmt.Sprintf(format, args...)})
+}
+
+func (c *Config) validate() error {
+	e := new(ValidationError)
+	validatePort(e, "server.listen.port", c.Server.Listen.Port)
+	for i, proxy := range c.Server.TrustedProxies {
+		if net.ParseIP(proxy) == nil {
+			if _, _, err := net.ParseCIDR(proxy); err != nil {
+				e.add(fmt.Sprintf("server.trusted_proxies[%d]", i), "must be an IP or CIDR, got %q", proxy)
+			}
+		}
+	}
+	if !slices.Contains(logLevels, c.Log.Level) {
+		e.add("log.level", "must be one of %s, got %q", strings.Join(logLevels, ", "), c.Log.Level)
+	}
+	if !slices.Contains(databases, c.Database.Name) {
+		e.add("database.name", "unsupported database %q, must be one of %s", c.Database.Name, strings.Join(databases, ", "))
+	}
+	// SQLite 只需要文件路径，且已有默认值。
+	if c.Database.Name != DatabaseSQLite {
+		if c.Database.Server == "" {
+			e.add("database.server", "is required")
+		}
+		validatePort(e, "database.port", c.Database.Port)
+		if c.Database.User == "" {
+			e.add("database.user", "is required")
+		}
+	}
+	if !slices.Contains(sessionStores, c.Session.Store) {
+		e.add("session.store", "must be one of %s, got %q", strings.Join(sessionStores, ", "), c.Session.Store)
+	}
+	if c.Session.AbsoluteTimeout < 0 {
+		e.add("session.absolute_timeout", "must be positive, got %s", c.Session.AbsoluteTimeout)
+	}
+	if *c.Session.IdleTimeout < 0 {
+		e.add("session.idle_timeout", "must not be negative, got %s", *c.Session.IdleTimeout)
+	}
+	if !slices.Contains(sameSiteModes, c.Session.Cookie.SameSite) {
+		e.add("session.cookie.same_site", "must be one of %s, got %q", strings.Join(sameSiteModes, ", "), c.Session.Cookie.SameSite)
+	} else if c.Session.Cookie.SameSite == SameSiteNone && !c.Session.Cookie.Secure {
+		// 浏览器拒绝没有 Secure 属性的 SameSite=None Cookie。
+		e.add("session.cookie.same_site", "none requires session.cookie.secure")
+	}
+	lt := c.LoginThrottle
+	if lt.AccountMaxFailures < 0 {
+		e.add("login_throttle.account_max_failures", "must be positive, got %d", lt.AccountMaxFailures)
+	}
+	if lt.IPMaxFailures < 0 {
+		e.add("login_throttle.ip_max_failures", "must be positive, got %d", lt.IPMaxFailures)
+	}
+	if lt.Window < 0 {
+		e.add("login_throttle.window", "must be positive, got %s", lt.Window)
+	}
+	if lt.Lockout < 0 {
+		e.add("login_throttle.lockout", "must be positive, got %s", lt.Lockout)
+	}
+	if lt.MaxLockout < lt.Lockout {
+		e.add("login_throttle.max_lockout", "must not be less than login_throttle.lockout, got %s", lt.MaxLockout)
+	}
+	pp := c.PasswordPolicy
+	if pp.MinLength < 1 {
+		e.add("password_policy.min_length", "must be positive, got %d", pp.MinLength)
+	}
+	if pp.MaxLength < pp.MinLength {
+		e.add("password_policy.max_length", "must not be less than password_policy.min_length, got %d", pp.MaxLength)
+	}
+	for _, r := range pp.AllowedSymbols {
+		if r < '!' || r > '~' || r >= '0' && r <= '9' || r >= 'A' && r <= 'Z' || r >= 'a' && r <= 'z' {
+			e.add("password_policy.allowed_symbols", "must only contain printable ASCII symbols, got %q", r)
+			break
+		}
+	}
+	for i, class := range pp.RequiredClasses {
+		if !slices.Contains(characterClasses, class) {
+			e.add(fmt.Sprintf("password_policy.required_classes[%d]", i), "must be one of %s, got %q", strings.Join(characterClasses, ", "), class)
+		}
+	}
+	if len(pp.RequiredClasses) > pp.MinLength {
+		e.add("password_policy.required_classes", "requires more characters than password_policy.min_length")
+	}
+	if pp.History < 1 {
+		e.add("password_policy.history", "must be positive, got %d", pp.History)
+	}
+	if pp.MaxAge < 0 {
+		e.add("password_policy.max_age", "must not be negative, got %s", pp.MaxAge)
+	}
+	if c.OIDC.Enabled {
+		validateAbsoluteURL(e, "oidc.issuer", c.OIDC.Issuer)
+		if c.OIDC.ClientID == "" {
+			e.add("oidc.client_id", "is required")
+		}
+		validateAbsoluteURL(e, "oidc.redirect_url", c.OIDC.RedirectURL)
+		if !slices.Contains(c.OIDC.Scopes, "openid") {
+			e.add("oidc.scopes", "must contain openid")
+		}
+	}
+	if c.Prometheus.Address != "" {
+		validateAbsoluteURL(e, "prometheus.address", c.Prometheus.Address)
+	}
+	if len(e.Problems) > 0 {
+		return e
+	}
+	return nil
+}
+
+func validatePort(e *ValidationError, key string, port int) {
+	if port < 1 || port > 65535 {
+		e.add(key, "must be between 1 and 65535, got %d", port)
+	}
+}
+
+func validateAbsoluteURL(e *ValidationError, key, value string) {
+	if u, err := url.Parse(value); err != nil || u.Scheme == "" || u.Host == "" {
+		e.add(key, "must be an absolute URL, got %q", value)
+	}
+}
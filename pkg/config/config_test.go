@@ -0,0 +1,201 @@
+package config
+
+import (
+	"errors"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadRepoConfig(t *testing.T) {
+	t.Setenv("MYSQL_SERVER", "db.local")
+	t.Setenv("MYSQL_PORT", "3307")
+	t.Setenv("MYSQL_USER", "app")
+	t.Setenv("MYSQL_PASSWORD", "p@ss:word")
+
+	cfg, err := Load(filepath.Join("..", "..", DefaultPath))
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+	if cfg.Database.Server != "db.local" || cfg.Database.Port != 3307 || cfg.Database.User != "app" {
+		t.Errorf("placeholders are not expanded: %+v", cfg.Database)
+	}
+	if got, want := cfg.Database.DSN(), "app:p@ss:word@tcp(db.local:3307)/go_dev?"; !strings.HasPrefix(got, want) {
+		t.Errorf("DSN() = %q, want prefix %q", got, want)
+	}
+	if got := cfg.Server.Listen.Address(); got != "0.0.0.0:8080" {
+		t.Errorf("Address() = %q", got)
+	}
+}
+
+func TestParseDefaults(t *testing.T) {
+	cfg, err := Parse([]byte("database:\n  server: mysql\n  port: ${UNSET_PORT_FOR_TEST}\n  user: root\n"))
+	if err != nil {
+		t.Fatalf("failed to parse config: %v", err)
+	}
+	if cfg.AppName != DefaultAppName || cfg.Server.Listen.Port != DefaultListenPort || cfg.Log.Level != DefaultLogLevel ||
+		cfg.Database.Name != DefaultDatabaseName || cfg.Database.Port != DefaultDatabasePort || cfg.Database.DBName != DefaultDBName {
+		t.Errorf("defaults are not applied: %+v", cfg)
+	}
+}
+
+func TestParseReportsEveryInvalidKey(t *testing.T) {
+	_, err := Parse([]byte(`
+server:
+  listen:
+    port: 70000
+log:
+  level: verbose
+database:
+  name: oracle
+  port: -1
+prometheus:
+  address: prometheus:9090
+`))
+	var ve *ValidationError
+	if !errors.As(err, &ve) {
+		t.Fatalf("expected *ValidationError, got %v", err)
+	}
+	var keys []string
+	for _, p := range ve.Problems {
+		keys = append(keys, p.Key)
+	}
+	want := []string{"server.listen.port", "log.level", "database.name", "database.server", "database.port", "database.user", "prometheus.address"}
+	if strings.Join(keys, ",") != strings.Join(want, ",") {
+		t.Errorf("invalid keys = %v, want %v", keys, want)
+	}
+}
+
+func TestParseRejectsUnknownKeys(t *testing.T) {
+	_, err := Parse([]byte("database:\n  server: mysql\n  user: root\n  passwd: secret\n"))
+	if err == nil || !strings.Contains(err.Error(), "passwd") {
+		t.Fatalf("expected unknown key error, got %v", err)
+	}
+}
+
+func TestPath(t *testing.T) {
+	t.Setenv(PathEnv, "")
+	if got := Path(""); got != DefaultPath {
+		t.Errorf("Path() = %q, want %q", got, DefaultPath)
+	}
+	t.Setenv(PathEnv, "/etc/app.yaml")
+	if got := Path(""); got != "/etc/app.yaml" {
+		t.Errorf("Path() = %q, want env value", got)
+	}
+	if got := Path("flag.yaml"); got != "flag.yaml" {
+		t.Errorf("Path() = %q, want flag value", got)
+	}
+}
+
+func TestParseSQLite(t *testing.T) {
+	cfg, err := Parse([]byte("database:\n  name: sqlite\n"))
+	if err != nil {
+		t.Fatalf("sqlite should not require mysql settings: %v", err)
+	}
+	if cfg.Database.Path != DefaultDatabasePath {
+		t.Errorf("Path = %q, want %q", cfg.Database.Path, DefaultDatabasePath)
+	}
+	if got, want := cfg.Database.DSN(), "file:"+DefaultDatabasePath+"?_foreign_keys=1"; !strings.HasPrefix(got, want) {
+		t.Errorf("DSN() = %q, want prefix %q", got, want)
+	}
+}
+
+func TestParseSession(t *testing.T) {
+	cfg, err := Parse([]byte("database:\n  name: sqlite\nsession:\n  idle_timeout: 15m\n"))
+	if err != nil {
+		t.Fatalf("failed to parse config: %v", err)
+	}
+	if cfg.Session.Store != DefaultSessionStore || cfg.Session.AbsoluteTimeout != DefaultSessionAbsoluteTimeout || *cfg.Session.IdleTimeout != 15*time.Minute ||
+		cfg.Session.Cookie.SameSite != DefaultSessionCookieSameSite || cfg.Session.Cookie.Secure {
+		t.Errorf("unexpected session config: %+v", cfg.Session)
+	}
+
+	_, err = Parse([]byte("database:\n  name: sqlite\nsession:\n  store: redis\n  absolute_timeout: -1h\n"))
+	var ve *ValidationError
+	if !errors.As(err, &ve) || len(ve.Problems) != 2 {
+		t.Errorf("expected problems with session.store and session.absolute_timeout, got %v", err)
+	}
+
+	for _, cookie := range []string{"same_site: always", "same_site: none"} {
+		_, err = Parse([]byte("database:\n  name: sqlite\nsession:\n  cookie:\n    " + cookie + "\n"))
+		if !errors.As(err, &ve) || len(ve.Problems) != 1 || ve.Problems[0].Key != "session.cookie.same_site" {
+			t.Errorf("%s: expected a problem with session.cookie.same_site, got %v", cookie, err)
+		}
+	}
+}
+
+func TestParseSessionIdleTimeout(t *testing.T) {
+	t.Setenv("SESSION_IDLE_TIMEOUT", "")
+	for doc, want := range map[string]time.Duration{
+		"session:\n  store: memory\n":                         DefaultSessionIdleTimeout,
+		"session:\n  idle_timeout: ${SESSION_IDLE_TIMEOUT}\n": DefaultSessionIdleTimeout,
+		"session:\n  idle_timeout: 0s\n":                      0,
+	} {
+		cfg, err := Parse([]byte("database:\n  name: sqlite\n" + doc))
+		if err != nil {
+			t.Fatalf("%q: failed to parse config: %v", doc, err)
+		}
+		if got := *cfg.Session.IdleTimeout; got != want {
+			t.Errorf("%q: IdleTimeout = %s, want %s", doc, got, want)
+		}
+	}
+}
+
+func TestParseLoginThrottle(t *testing.T) {
+	cfg, err := Parse([]byte("database:\n  name: sqlite\nlogin_throttle:\n  account_max_failures: 3\n  max_lockout: 10m\n"))
+	if err != nil {
+		t.Fatalf("failed to parse config: %v", err)
+	}
+	want := DefaultLoginThrottle
+	want.AccountMaxFailures, want.MaxLockout = 3, 10*time.Minute
+	if cfg.LoginThrottle != want {
+		t.Errorf("LoginThrottle = %+v, want %+v", cfg.LoginThrottle, want)
+	}
+
+	_, err = Parse([]byte("database:\n  name: sqlite\nserver:\n  trusted_proxies: [10.0.0.0/8, proxy.local]\nlogin_throttle:\n  lockout: 2h\n"))
+	var ve *ValidationError
+	if !errors.As(err, &ve) || len(ve.Problems) != 2 {
+		t.Errorf("expected problems with server.trusted_proxies and login_throttle.max_lockout, got %v", err)
+	}
+}
+
+func TestParsePasswordPolicy(t *testing.T) {
+	cfg, err := Parse([]byte("database:\n  name: sqlite\npassword_policy:\n  min_length: 12\n  required_classes: [upper, digit]\n  banned: []\n  max_age: 2160h\n"))
+	if err != nil {
+		t.Fatalf("failed to parse config: %v", err)
+	}
+	pp := cfg.PasswordPolicy
+	if pp.MinLength != 12 || pp.MaxLength != DefaultPasswordPolicy.MaxLength || pp.AllowedSymbols != "_-" ||
+		len(pp.RequiredClasses) != 2 || len(pp.Banned) != 0 || pp.History != DefaultPasswordPolicy.History || pp.MaxAge != 90*24*time.Hour {
+		t.Errorf("unexpected password policy: %+v", pp)
+	}
+
+	_, err = Parse([]byte("database:\n  name: sqlite\npassword_policy:\n  min_length: 40\n  allowed_symbols: \"a!\"\n  required_classes: [emoji]\n  history: -1\n"))
+	var ve *ValidationError
+	if !errors.As(err, &ve) || len(ve.Problems) != 4 {
+		t.Errorf("expected problems with max_length, allowed_symbols, required_classes and history, got %v", err)
+	}
+}
+
+func TestParseOIDC(t *testing.T) {
+	cfg, err := Parse([]byte("database:\n  name: sqlite\noidc:\n  enabled: true\n  issuer: https://idp.example.com\n  client_id: go-homework\n  redirect_url: https://app.example.com/api/login/oidc/callback\n"))
+	if err != nil {
+		t.Fatalf("failed to parse config: %v", err)
+	}
+	if !slices.Equal(cfg.OIDC.Scopes, DefaultOIDCScopes) || cfg.OIDC.UsernameClaim != DefaultOIDCUsernameClaim || cfg.OIDC.AutoProvision {
+		t.Errorf("unexpected oidc config: %+v", cfg.OIDC)
+	}
+
+	// 未启用时不校验。
+	if _, err := Parse([]byte("database:\n  name: sqlite\noidc:\n  issuer: idp.example.com\n")); err != nil {
+		t.Errorf("a disabled oidc config should not be validated: %v", err)
+	}
+
+	_, err = Parse([]byte("database:\n  name: sqlite\noidc:\n  enabled: true\n  issuer: idp.example.com\n  scopes: [email]\n"))
+	var ve *ValidationError
+	if !errors.As(err, &ve) || len(ve.Problems) != 4 {
+		t.Errorf("expected problems with issuer, client_id, redirect_url and scopes, got %v", err)
+	}
+}
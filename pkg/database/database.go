@@ -0,0 +1,49 @@
+// Package database 根据 database.name 选择 GORM 驱动并连接数据库。
+package database
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	"github.com/dspo/go-homework/pkg/config"
+)
+
+// Open 按 cfg.Name 选择驱动连接数据库，gormConfig 为 nil 时使用默认配置。
+func Open(cfg config.Database, gormConfig *gorm.Config) (*gorm.DB, error) {
+	if gormConfig == nil {
+		gormConfig = &gorm.Config{TranslateError: true}
+	}
+	switch cfg.Name {
+	case config.DatabaseMySQL:
+		return gorm.Open(mysql.Open(cfg.DSN()), gormConfig)
+	case config.DatabaseSQLite:
+		return openSQLite(cfg, gormConfig)
+	default:
+		return nil, fmt.Errorf("unsupported database %q", cfg.Name)
+	}
+}
+
+func openSQLite(cfg config.Database, gormConfig *gorm.Config) (*gorm.DB, error) {
+	if dir := filepath.Dir(cfg.Path); dir != "." {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, fmt.Errorf("failed to create directory for %s: %w", cfg.Path, err)
+		}
+	}
+	db, err := gorm.Open(sqlite.Open(cfg.DSN()), gormConfig)
+	if err != nil {
+		return nil, err
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	// SQLite 同一时刻只允许一个写事务，多个连接并发写入时会直接返回 SQLITE_BUSY，
+	// 因此只使用一个连接，由连接池排队。
+	sqlDB.SetMaxOpenConns(1)
+	return db, nil
+}
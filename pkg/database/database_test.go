@@ -0,0 +1,58 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"gorm.io/gorm"
+
+	"github.com/dspo/go-homework/pkg/config"
+	"github.com/dspo/go-homework/pkg/migrate"
+	"github.com/dspo/go-homework/pkg/models"
+)
+
+// TestSQLiteConstraints 确认 SQLite 上的约束与 MySQL 一致，服务层依赖这些错误返回 409 或拒绝悬空的引用。
+func TestSQLiteConstraints(t *testing.T) {
+	db, err := Open(config.Database{Name: config.DatabaseSQLite, Path: filepath.Join(t.TempDir(), "data", "app.db")}, nil)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	m, err := migrate.New(db)
+	if err != nil {
+		t.Fatalf("migrate.New() error = %v", err)
+	}
+	if _, err := m.Up(context.Background()); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+
+	alice := models.User{Username: "alice", PasswordHash: "x"}
+	if err := db.Create(&alice).Error; err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+	if err := db.Create(&models.User{Username: "Alice", PasswordHash: "x"}).Error; !errors.Is(err, gorm.ErrDuplicatedKey) {
+		t.Errorf("usernames must be unique case-insensitively, got %v", err)
+	}
+
+	teams := []models.Team{{Name: "red"}, {Name: "blue"}}
+	if err := db.Create(&teams).Error; err != nil {
+		t.Fatalf("failed to create teams: %v", err)
+	}
+	if err := db.Create(&models.Project{TeamID: teams[0].ID, Name: "apollo", Status: models.ProjectStatusInProgress}).Error; err != nil {
+		t.Fatalf("failed to create project: %v", err)
+	}
+	if err := db.Create(&models.Project{TeamID: teams[1].ID, Name: "apollo", Status: models.ProjectStatusInProgress}).Error; err != nil {
+		t.Errorf("project names are scoped by team, got %v", err)
+	}
+	if err := db.Create(&models.Project{TeamID: teams[0].ID, Name: "apollo", Status: models.ProjectStatusInProgress}).Error; !errors.Is(err, gorm.ErrDuplicatedKey) {
+		t.Errorf("project names must be unique within a team, got %v", err)
+	}
+
+	if err := db.Create(&models.TeamMember{TeamID: teams[0].ID, UserID: alice.ID + 100}).Error; !errors.Is(err, gorm.ErrForeignKeyViolated) {
+		t.Errorf("foreign keys must be enforced, got %v", err)
+	}
+	if err := db.Delete(&models.Team{ID: teams[0].ID}).Error; !errors.Is(err, gorm.ErrForeignKeyViolated) {
+		t.Errorf("team with projects must not be deleted directly, got %v", err)
+	}
+}
@@ -0,0 +1,54 @@
+// Package logging 按 log.level 初始化 zap，作为整个进程的日志出口。
+package logging
+
+import (
+	"fmt"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"gorm.io/gorm/logger"
+)
+
+// New 创建输出 JSON 到标准错误、最低级别为 level 的 Logger。
+func New(level string) (*zap.Logger, error) {
+	lvl, err := zapcore.ParseLevel(level)
+	if err != nil {
+		return nil, err
+	}
+	cfg := zap.NewProductionConfig()
+	cfg.Level = zap.NewAtomicLevelAt(lvl)
+	// 访问日志需要完整保留，不做采样。
+	cfg.Sampling = nil
+	cfg.EncoderConfig.TimeKey = "time"
+	cfg.EncoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
+	return cfg.Build()
+}
+
+// Setup 按 level 创建 Logger，替换 zap 的全局 Logger，并将标准库 log 的输出重定向到该 Logger。
+// 返回的函数用于在退出前刷新缓冲并恢复原有设置。
+func Setup(level string) (*zap.Logger, func(), error) {
+	l, err := New(level)
+	if err != nil {
+		return nil, nil, err
+	}
+	restoreGlobals := zap.ReplaceGlobals(l)
+	restoreStdLog := zap.RedirectStdLog(l)
+	return l, func() {
+		_ = l.Sync()
+		restoreStdLog()
+		restoreGlobals()
+	}, nil
+}
+
+// GormWriter 将 GORM 的日志（慢查询、错误等）以 warn 级别写入 logger。
+func GormWriter(l *zap.Logger) logger.Writer {
+	return gormWriter{logger: l}
+}
+
+type gormWriter struct {
+	logger *zap.Logger
+}
+
+func (w gormWriter) Printf(format string, args ...any) {
+	w.logger.Warn(fmt.Sprintf(format, args...), zap.String("component", "gorm"))
+}
@@ -0,0 +1,161 @@
+// Package metrics 定义服务暴露给 Prometheus 的指标。
+//
+// 每个 Metrics 使用独立的 Registry，同一进程内可以存在多个互不干扰的实例（如测试中）。
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"github.com/dspo/go-homework/pkg/models"
+)
+
+// Namespace 是全部业务指标名称的前缀。
+const Namespace = "go_homework"
+
+// UnknownOperation 是未匹配到任何路由的请求所使用的 operation 标签。
+const UnknownOperation = "unknown"
+
+// Metrics 持有服务的全部指标。
+type Metrics struct {
+	registry *prometheus.Registry
+	requests *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+	logins   *prometheus.CounterVec
+}
+
+// New 创建 Metrics 并注册 Go 运行时与进程指标。
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: Namespace,
+			Name:      "http_requests_total",
+			Help:      "Number of HTTP requests by openapi operationId and status code.",
+		}, []string{"operation", "status"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: Namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "Latency of HTTP requests by openapi operationId and status code.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"operation", "status"}),
+		logins: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: Namespace,
+			Name:      "logins_total",
+			Help:      "Number of login attempts by result.",
+		}, []string{"result"}),
+	}
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.requests,
+		m.duration,
+		m.logins,
+	)
+	return m
+}
+
+// Handler 返回以 Prometheus 文本格式输出全部指标的 http.Handler。
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// MustRegister 注册额外的指标。
+func (m *Metrics) MustRegister(cs ...prometheus.Collector) {
+	m.registry.MustRegister(cs...)
+}
+
+// ObserveRequest 记录一次 HTTP 请求。
+func (m *Metrics) ObserveRequest(operation string, status int, elapsed time.Duration) {
+	code := strconv.Itoa(status)
+	m.requests.WithLabelValues(operation, code).Inc()
+	m.duration.WithLabelValues(operation, code).Observe(elapsed.Seconds())
+}
+
+// ObserveLogin 记录一次登录尝试的结果。
+func (m *Metrics) ObserveLogin(succeeded bool) {
+	result := "failure"
+	if succeeded {
+		result = "success"
+	}
+	m.logins.WithLabelValues(result).Inc()
+}
+
+// ObserveLoginThrottled 记录一次因登录失败次数过多而被拒绝的登录尝试。
+func (m *Metrics) ObserveLoginThrottled() {
+	m.logins.WithLabelValues("throttled").Inc()
+}
+
+// NewDBStatsCollector 采集数据库连接池的状态。
+func NewDBStatsCollector(db *sql.DB, dbName string) prometheus.Collector {
+	return collectors.NewDBStatsCollector(db, dbName)
+}
+
+// ScrapeTimeout 是采集时查询数据库的超时时长。
+const ScrapeTimeout = 2 * time.Second
+
+// resourceCollector 在每次采集时从数据库统计 Users、Teams 与各状态的 Projects 的数量。
+type resourceCollector struct {
+	db       *gorm.DB
+	users    *prometheus.Desc
+	teams    *prometheus.Desc
+	projects *prometheus.Desc
+}
+
+// NewResourceCollector 创建统计业务资源数量的 Collector。
+func NewResourceCollector(db *gorm.DB) prometheus.Collector {
+	return &resourceCollector{
+		db:       db,
+		users:    prometheus.NewDesc(prometheus.BuildFQName(Namespace, "", "users"), "Number of users.", nil, nil),
+		teams:    prometheus.NewDesc(prometheus.BuildFQName(Namespace, "", "teams"), "Number of teams.", nil, nil),
+		projects: prometheus.NewDesc(prometheus.BuildFQName(Namespace, "", "projects"), "Number of projects by status.", []string{"status"}, nil),
+	}
+}
+
+func (c *resourceCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.users
+	ch <- c.teams
+	ch <- c.projects
+}
+
+// Collect 查询失败时跳过对应的指标，不影响其他指标的输出。
+func (c *resourceCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), ScrapeTimeout)
+	defer cancel()
+	db := c.db.WithContext(ctx)
+
+	for desc, model := range map[*prometheus.Desc]any{c.users: &models.User{}, c.teams: &models.Team{}} {
+		var count int64
+		if err := db.Model(model).Count(&count).Error; err != nil {
+			zap.L().Warn("failed to collect metric", zap.Stringer("desc", desc), zap.Error(err))
+			continue
+		}
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, float64(count))
+	}
+
+	var rows []struct {
+		Status string
+		Count  int64
+	}
+	if err := db.Model(&models.Project{}).Select("status, COUNT(*) AS count").Group("status").Scan(&rows).Error; err != nil {
+		zap.L().Warn("failed to collect metric", zap.Stringer("desc", c.projects), zap.Error(err))
+		return
+	}
+	counts := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		counts[row.Status] = row.Count
+	}
+	// 没有 Project 的状态也输出 0，便于告警规则与看板使用。
+	for _, status := range []string{models.ProjectStatusWaitForSchedule, models.ProjectStatusInProgress, models.ProjectStatusFinished} {
+		ch <- prometheus.MustNewConstMetric(c.projects, prometheus.GaugeValue, float64(counts[status]), status)
+	}
+}
@@ -0,0 +1,325 @@
+// Package migrate 管理数据库 schema 的版本化迁移。
+//
+// 迁移脚本以 {version}_{name}.up.sql / {version}_{name}.down.sql 的形式按数据库类型
+// 存放在 migrations/{dialect} 目录下并嵌入二进制，已执行的版本记录在 schema_migrations 表中。
+package migrate
+
+import (
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+//go:embed migrations
+var migrationsFS embed.FS
+
+// lockName 是 MySQL 上串行化多个副本同时迁移的命名锁。
+const (
+	lockName    = "go-homework.schema_migrations"
+	lockTimeout = 60
+)
+
+// ErrNewerSchema 表示数据库中存在本程序不认识的更高版本，通常是数据库已被更新版本的程序迁移过。
+var ErrNewerSchema = errors.New("database schema is newer than this binary supports")
+
+// Migration 是一个版本的迁移脚本。
+type Migration struct {
+	Version uint64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Status 是一个迁移版本的执行状态，AppliedAt 为 nil 表示尚未执行。
+type Status struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+// schemaMigration 是 schema_migrations 表中的一条记录。
+type schemaMigration struct {
+	Version   uint64 `gorm:"primaryKey;autoIncrement:false"`
+	Name      string `gorm:"size:255;not null"`
+	AppliedAt time.Time
+}
+
+func (schemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// Migrator 对 db 执行嵌入的迁移脚本。
+type Migrator struct {
+	db         *gorm.DB
+	migrations []Migration
+}
+
+// New 为 db 创建 Migrator，根据 db 的方言加载对应目录下的迁移脚本。
+func New(db *gorm.DB) (*Migrator, error) {
+	migrations, err := load(migrationsFS, path.Join("migrations", db.Dialector.Name()))
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, migrations: migrations}, nil
+}
+
+// Latest 返回本程序已知的最高版本。
+func (m *Migrator) Latest() uint64 {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Up 按版本顺序执行全部未执行的迁移，返回本次执行的迁移。
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	var done []Migration
+	err := m.withLock(ctx, func(tx *gorm.DB) error {
+		applied, err := m.applied(tx)
+		if err != nil {
+			return err
+		}
+		if err := m.checkNewer(applied); err != nil {
+			return err
+		}
+		for _, migration := range m.migrations {
+			if _, ok := applied[migration.Version]; ok {
+				continue
+			}
+			if err := m.run(tx, migration, migration.Up, true); err != nil {
+				return err
+			}
+			done = append(done, migration)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Down 回滚最近执行的一个迁移，没有可回滚的迁移时返回 nil。
+func (m *Migrator) Down(ctx context.Context) (*Migration, error) {
+	var done *Migration
+	err := m.withLock(ctx, func(tx *gorm.DB) error {
+		applied, err := m.applied(tx)
+		if err != nil {
+			return err
+		}
+		if err := m.checkNewer(applied); err != nil {
+			return err
+		}
+		for i := len(m.migrations) - 1; i >= 0; i-- {
+			migration := m.migrations[i]
+			if _, ok := applied[migration.Version]; !ok {
+				continue
+			}
+			if err := m.run(tx, migration, migration.Down, false); err != nil {
+				return err
+			}
+			done = &migration
+			return nil
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Status 返回每个已知版本的执行状态。
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	applied, err := m.applied(m.db.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	statuses := make([]Status, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		status := Status{Migration: migration}
+		if record, ok := applied[migration.Version]; ok {
+			status.AppliedAt = &record.AppliedAt
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// Pending 返回尚未执行的迁移；数据库 schema 高于本程序已知的版本时返回 ErrNewerSchema。
+func (m *Migrator) Pending(ctx context.Context) ([]Migration, error) {
+	applied, err := m.applied(m.db.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	if err := m.checkNewer(applied); err != nil {
+		return nil, err
+	}
+	var pending []Migration
+	for _, migration := range m.migrations {
+		if _, ok := applied[migration.Version]; !ok {
+			pending = append(pending, migration)
+		}
+	}
+	return pending, nil
+}
+
+// Check 确认数据库 schema 不高于本程序已知的最高版本，否则返回 ErrNewerSchema。
+func (m *Migrator) Check(ctx context.Context) error {
+	applied, err := m.applied(m.db.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	return m.checkNewer(applied)
+}
+
+func (m *Migrator) checkNewer(applied map[uint64]schemaMigration) error {
+	latest := m.Latest()
+	for version := range applied {
+		if version > latest {
+			return fmt.Errorf("%w: found version %d, latest known version is %d", ErrNewerSchema, version, latest)
+		}
+	}
+	return nil
+}
+
+// applied 返回已执行的迁移记录，schema_migrations 表不存在时先创建。
+func (m *Migrator) applied(tx *gorm.DB) (map[uint64]schemaMigration, error) {
+	if !tx.Migrator().HasTable(&schemaMigration{}) {
+		if err := tx.Migrator().CreateTable(&schemaMigration{}); err != nil {
+			return nil, fmt.Errorf("failed to create schema_migrations: %w", err)
+		}
+	}
+	var records []schemaMigration
+	if err := tx.Find(&records).Error; err != nil {
+		return nil, err
+	}
+	applied := make(map[uint64]schemaMigration, len(records))
+	for _, record := range records {
+		applied[record.Version] = record
+	}
+	return applied, nil
+}
+
+// run 执行一个迁移脚本并更新 schema_migrations。支持事务性 DDL 的数据库上整体原子执行。
+func (m *Migrator) run(tx *gorm.DB, migration Migration, script string, up bool) error {
+	err := tx.Transaction(func(tx *gorm.DB) error {
+		for _, stmt := range splitStatements(script) {
+			if err := tx.Exec(stmt).Error; err != nil {
+				return err
+			}
+		}
+		if up {
+			return tx.Create(&schemaMigration{Version: migration.Version, Name: migration.Name, AppliedAt: time.Now()}).Error
+		}
+		return tx.Delete(&schemaMigration{Version: migration.Version}).Error
+	})
+	if err != nil {
+		direction := "down"
+		if up {
+			direction = "up"
+		}
+		return fmt.Errorf("failed to migrate %s %04d_%s: %w", direction, migration.Version, migration.Name, err)
+	}
+	return nil
+}
+
+// withLock 在数据库级别的锁内执行 fn，避免多个副本同时启动时重复迁移。
+func (m *Migrator) withLock(ctx context.Context, fn func(tx *gorm.DB) error) error {
+	db := m.db.WithContext(ctx)
+	if db.Dialector.Name() != "mysql" {
+		return fn(db)
+	}
+	// GET_LOCK 与连接绑定，因此需在同一连接上加锁、迁移和释放锁。
+	return db.Connection(func(conn *gorm.DB) error {
+		var got int
+		if err := conn.Raw("SELECT GET_LOCK(?, ?)", lockName, lockTimeout).Scan(&got).Error; err != nil {
+			return err
+		}
+		if got != 1 {
+			return fmt.Errorf("timed out waiting for migration lock %q", lockName)
+		}
+		defer conn.Exec("SELECT RELEASE_LOCK(?)", lockName)
+		return fn(conn)
+	})
+}
+
+// load 读取 dir 下的迁移脚本，要求每个版本都同时具备 up 与 down 脚本且版本号不重复。
+func load(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, fmt.Errorf("no migrations for this database: %w", err)
+	}
+	byVersion := make(map[uint64]*Migration)
+	for _, entry := range entries {
+		version, name, direction, err := parseFilename(entry.Name())
+		if err != nil {
+			return nil, err
+		}
+		data, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: name}
+			byVersion[version] = migration
+		}
+		if migration.Name != name {
+			return nil, fmt.Errorf("migration version %d has conflicting names %q and %q", version, migration.Name, name)
+		}
+		if direction == "up" {
+			migration.Up = string(data)
+		} else {
+			migration.Down = string(data)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" || migration.Down == "" {
+			return nil, fmt.Errorf("migration %04d_%s must have both up and down scripts", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+var filenamePattern = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// parseFilename 解析形如 0001_create_users.up.sql 的文件名。
+func parseFilename(filename string) (version uint64, name, direction string, err error) {
+	match := filenamePattern.FindStringSubmatch(filename)
+	if match != nil {
+		if version, err = strconv.ParseUint(match[1], 10, 64); err == nil && version > 0 {
+			return version, match[2], match[3], nil
+		}
+	}
+	return 0, "", "", fmt.Errorf("invalid migration filename %q, want {version}_{name}.(up|down).sql", filename)
+}
+
+// splitStatements 按行尾的分号拆分脚本，忽略空语句与 -- 注释行。
+func splitStatements(script string) []string {
+	var stmts []string
+	var current strings.Builder
+	for _, line := range strings.Split(script, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "--") {
+			continue
+		}
+		current.WriteString(line)
+		current.WriteString("\n")
+		if strings.HasSuffix(trimmed, ";") {
+			stmts = append(stmts, strings.TrimSpace(current.String()))
+			current.Reset()
+		}
+	}
+	if rest := strings.TrimSpace(current.String()); rest != "" {
+		stmts = append(stmts, rest)
+	}
+	return stmts
+}
@@ -0,0 +1,140 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestEmbeddedMigrations(t *testing.T) {
+	mysql, err := load(migrationsFS, "migrations/mysql")
+	if err != nil {
+		t.Fatalf("failed to load embedded migrations: %v", err)
+	}
+	if len(mysql) == 0 {
+		t.Fatal("no embedded migrations")
+	}
+	for i, m := range mysql {
+		if m.Version != uint64(i+1) {
+			t.Errorf("migration versions must be contiguous from 1, got %d at position %d", m.Version, i)
+		}
+	}
+
+	// 各数据库的迁移必须一一对应，保证切换后端时 schema 版本含义一致。
+	sqlite, err := load(migrationsFS, "migrations/sqlite")
+	if err != nil {
+		t.Fatalf("failed to load embedded sqlite migrations: %v", err)
+	}
+	if len(sqlite) != len(mysql) {
+		t.Fatalf("sqlite has %d migrations, mysql has %d", len(sqlite), len(mysql))
+	}
+	for i := range mysql {
+		if sqlite[i].Version != mysql[i].Version || sqlite[i].Name != mysql[i].Name {
+			t.Errorf("sqlite migration %04d_%s does not match mysql %04d_%s",
+				sqlite[i].Version, sqlite[i].Name, mysql[i].Version, mysql[i].Name)
+		}
+	}
+}
+
+func TestMigratorSQLite(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "migrate.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open sqlite: %v", err)
+	}
+	ctx := context.Background()
+	m, err := New(db)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	done, err := m.Up(ctx)
+	if err != nil || len(done) != len(m.migrations) {
+		t.Fatalf("Up() = %d migrations, %v", len(done), err)
+	}
+	if done, err := m.Up(ctx); err != nil || len(done) != 0 {
+		t.Fatalf("second Up() = %d migrations, %v", len(done), err)
+	}
+
+	down, err := m.Down(ctx)
+	if err != nil || down == nil || down.Version != m.Latest() {
+		t.Fatalf("Down() = %+v, %v", down, err)
+	}
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		t.Fatalf("Status() error = %v", err)
+	}
+	for _, status := range statuses {
+		if applied := status.AppliedAt != nil; applied != (status.Version < m.Latest()) {
+			t.Errorf("version %d applied = %v", status.Version, applied)
+		}
+	}
+
+	if pending, err := m.Pending(ctx); err != nil || len(pending) != 1 || pending[0].Version != m.Latest() {
+		t.Errorf("Pending() = %+v, %v", pending, err)
+	}
+
+	if _, err := m.Up(ctx); err != nil {
+		t.Fatalf("Up() after Down() error = %v", err)
+	}
+	db.Create(&schemaMigration{Version: m.Latest() + 1, Name: "future"})
+	if err := m.Check(ctx); !errors.Is(err, ErrNewerSchema) {
+		t.Errorf("Check() = %v, want ErrNewerSchema", err)
+	}
+	if _, err := m.Pending(ctx); !errors.Is(err, ErrNewerSchema) {
+		t.Errorf("Pending() = %v, want ErrNewerSchema", err)
+	}
+	if _, err := m.Up(ctx); !errors.Is(err, ErrNewerSchema) {
+		t.Errorf("Up() = %v, want ErrNewerSchema", err)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	fsys := fstest.MapFS{
+		"m/0002_second.up.sql":   {Data: []byte("CREATE TABLE b (id INT);")},
+		"m/0002_second.down.sql": {Data: []byte("DROP TABLE b;")},
+		"m/0001_first.up.sql":    {Data: []byte("CREATE TABLE a (id INT);")},
+		"m/0001_first.down.sql":  {Data: []byte("DROP TABLE a;")},
+	}
+	migrations, err := load(fsys, "m")
+	if err != nil {
+		t.Fatalf("load() error = %v", err)
+	}
+	if len(migrations) != 2 || migrations[0].Name != "first" || migrations[1].Version != 2 || migrations[1].Down != "DROP TABLE b;" {
+		t.Errorf("load() = %+v", migrations)
+	}
+
+	for name, fsys := range map[string]fstest.MapFS{
+		"missing down": {"m/0001_first.up.sql": {Data: []byte("SELECT 1;")}},
+		"bad filename": {"m/first.up.sql": {Data: []byte("SELECT 1;")}},
+		"name conflict": {
+			"m/0001_first.up.sql":   {Data: []byte("SELECT 1;")},
+			"m/0001_other.down.sql": {Data: []byte("SELECT 1;")},
+		},
+	} {
+		if _, err := load(fsys, "m"); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestSplitStatements(t *testing.T) {
+	script := strings.Join([]string{
+		"-- comment",
+		"CREATE TABLE a (",
+		"    id INT",
+		");",
+		"",
+		"DROP TABLE b;",
+	}, "\n")
+	want := []string{"CREATE TABLE a (\n    id INT\n);", "DROP TABLE b;"}
+	if got := splitStatements(script); !reflect.DeepEqual(got, want) {
+		t.Errorf("splitStatements() = %q, want %q", got, want)
+	}
+}
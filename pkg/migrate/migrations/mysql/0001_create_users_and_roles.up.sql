@@ -0,0 +1,34 @@
+CREATE TABLE users (
+    id                   BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    username             VARCHAR(30)     NOT NULL,
+    email                VARCHAR(255)    NULL,
+    nickname             VARCHAR(255)    NOT NULL DEFAULT '',
+    logo                 TEXT            NULL,
+    password_hash        VARCHAR(255)    NOT NULL,
+    must_change_password TINYINT(1)      NOT NULL DEFAULT 0,
+    created_at           DATETIME(3)     NULL,
+    updated_at           DATETIME(3)     NULL,
+    PRIMARY KEY (id),
+    UNIQUE KEY idx_users_username (username),
+    UNIQUE KEY idx_users_email (email)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4;
+
+CREATE TABLE roles (
+    id          BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    name        VARCHAR(64)     NOT NULL,
+    type        VARCHAR(16)     NOT NULL,
+    description VARCHAR(255)    NOT NULL DEFAULT '',
+    created_at  DATETIME(3)     NULL,
+    updated_at  DATETIME(3)     NULL,
+    PRIMARY KEY (id),
+    UNIQUE KEY idx_roles_name (name)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4;
+
+CREATE TABLE user_roles (
+    user_id BIGINT UNSIGNED NOT NULL,
+    role_id BIGINT UNSIGNED NOT NULL,
+    PRIMARY KEY (user_id, role_id),
+    KEY idx_user_roles_role_id (role_id),
+    CONSTRAINT fk_user_roles_user FOREIGN KEY (user_id) REFERENCES users (id),
+    CONSTRAINT fk_user_roles_role FOREIGN KEY (role_id) REFERENCES roles (id)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4;
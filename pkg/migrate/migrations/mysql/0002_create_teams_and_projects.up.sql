@@ -0,0 +1,45 @@
+CREATE TABLE teams (
+    id          BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    name        VARCHAR(64)     NOT NULL,
+    description VARCHAR(255)    NOT NULL DEFAULT '',
+    leader_id   BIGINT UNSIGNED NULL,
+    created_at  DATETIME(3)     NULL,
+    updated_at  DATETIME(3)     NULL,
+    PRIMARY KEY (id),
+    UNIQUE KEY idx_teams_name (name),
+    KEY idx_teams_leader_id (leader_id),
+    CONSTRAINT fk_teams_leader FOREIGN KEY (leader_id) REFERENCES users (id)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4;
+
+CREATE TABLE team_members (
+    team_id    BIGINT UNSIGNED NOT NULL,
+    user_id    BIGINT UNSIGNED NOT NULL,
+    created_at DATETIME(3)     NULL,
+    PRIMARY KEY (team_id, user_id),
+    KEY idx_team_members_user_id (user_id),
+    CONSTRAINT fk_team_members_team FOREIGN KEY (team_id) REFERENCES teams (id),
+    CONSTRAINT fk_team_members_user FOREIGN KEY (user_id) REFERENCES users (id)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4;
+
+CREATE TABLE projects (
+    id          BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    team_id     BIGINT UNSIGNED NOT NULL,
+    name        VARCHAR(64)     NOT NULL,
+    description VARCHAR(255)    NOT NULL DEFAULT '',
+    status      VARCHAR(32)     NOT NULL,
+    created_at  DATETIME(3)     NULL,
+    updated_at  DATETIME(3)     NULL,
+    PRIMARY KEY (id),
+    UNIQUE KEY idx_projects_team_name (team_id, name),
+    CONSTRAINT fk_projects_team FOREIGN KEY (team_id) REFERENCES teams (id)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4;
+
+CREATE TABLE project_members (
+    project_id BIGINT UNSIGNED NOT NULL,
+    user_id    BIGINT UNSIGNED NOT NULL,
+    created_at DATETIME(3)     NULL,
+    PRIMARY KEY (project_id, user_id),
+    KEY idx_project_members_user_id (user_id),
+    CONSTRAINT fk_project_members_project FOREIGN KEY (project_id) REFERENCES projects (id),
+    CONSTRAINT fk_project_members_user FOREIGN KEY (user_id) REFERENCES users (id)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4;
@@ -0,0 +1,7 @@
+CREATE TABLE audits (
+    id         BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    content    TEXT            NOT NULL,
+    created_at DATETIME(3)     NULL,
+    PRIMARY KEY (id),
+    KEY idx_audits_created_at (created_at)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4;
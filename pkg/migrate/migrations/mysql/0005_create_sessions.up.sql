@@ -0,0 +1,12 @@
+-- 会话由 session.DBStore 读写，id 是 Cookie 中令牌的 SHA-256，不保存令牌本身。
+-- 不对 user_id 建外键：用户被删除后其会话由应用显式清理，残留的会话也会因用户不存在而认证失败。
+CREATE TABLE sessions (
+    id           CHAR(64)        NOT NULL,
+    user_id      BIGINT UNSIGNED NOT NULL,
+    created_at   DATETIME(3)     NOT NULL,
+    last_seen_at DATETIME(3)     NOT NULL,
+    expires_at   DATETIME(3)     NOT NULL,
+    PRIMARY KEY (id),
+    KEY idx_sessions_user_id (user_id),
+    KEY idx_sessions_expires_at (expires_at)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4;
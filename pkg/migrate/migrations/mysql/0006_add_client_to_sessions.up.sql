@@ -0,0 +1,3 @@
+ALTER TABLE sessions
+    ADD COLUMN ip         VARCHAR(64)  NOT NULL DEFAULT '' AFTER user_id,
+    ADD COLUMN user_agent VARCHAR(255) NOT NULL DEFAULT '' AFTER ip;
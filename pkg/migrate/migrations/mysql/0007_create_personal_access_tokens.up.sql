@@ -0,0 +1,14 @@
+-- 只保存令牌的 SHA-256，令牌明文仅在创建时返回一次。
+CREATE TABLE personal_access_tokens (
+    id           BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    user_id      BIGINT UNSIGNED NOT NULL,
+    name         VARCHAR(64)     NOT NULL,
+    token_hash   CHAR(64)        NOT NULL,
+    expires_at   DATETIME(3)     NOT NULL,
+    last_used_at DATETIME(3)     NULL,
+    created_at   DATETIME(3)     NULL,
+    PRIMARY KEY (id),
+    UNIQUE KEY idx_personal_access_tokens_token_hash (token_hash),
+    UNIQUE KEY idx_personal_access_tokens_user_name (user_id, name),
+    CONSTRAINT fk_personal_access_tokens_user FOREIGN KEY (user_id) REFERENCES users (id)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4;
@@ -0,0 +1,8 @@
+-- subject 形如 "account:user:1"、"ip:192.0.2.1"，由 throttle.Limiter 读写。
+CREATE TABLE login_failures (
+    subject        VARCHAR(255) NOT NULL,
+    failures       INT UNSIGNED NOT NULL DEFAULT 0,
+    last_failed_at DATETIME(3)  NOT NULL,
+    locked_until   DATETIME(3)  NULL,
+    PRIMARY KEY (subject)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4;
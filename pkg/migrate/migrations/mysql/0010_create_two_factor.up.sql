@@ -0,0 +1,33 @@
+-- enabled_at 为空表示用户已开始启用但尚未用验证码确认。
+CREATE TABLE two_factor_credentials (
+    user_id    BIGINT UNSIGNED NOT NULL,
+    secret     VARCHAR(64)     NOT NULL,
+    enabled_at DATETIME(3)     NULL,
+    created_at DATETIME(3)     NULL,
+    PRIMARY KEY (user_id),
+    CONSTRAINT fk_two_factor_credentials_user FOREIGN KEY (user_id) REFERENCES users (id)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4;
+
+-- 只保存恢复码的 SHA-256。
+CREATE TABLE recovery_codes (
+    id         BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    user_id    BIGINT UNSIGNED NOT NULL,
+    code_hash  CHAR(64)        NOT NULL,
+    used_at    DATETIME(3)     NULL,
+    created_at DATETIME(3)     NULL,
+    PRIMARY KEY (id),
+    KEY idx_recovery_codes_user (user_id),
+    CONSTRAINT fk_recovery_codes_user FOREIGN KEY (user_id) REFERENCES users (id)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4;
+
+-- 密码校验通过、等待两步验证的登录，id 是挑战令牌的 SHA-256。
+CREATE TABLE login_challenges (
+    id         CHAR(64)        NOT NULL,
+    user_id    BIGINT UNSIGNED NOT NULL,
+    attempts   INT             NOT NULL DEFAULT 0,
+    expires_at DATETIME(3)     NOT NULL,
+    created_at DATETIME(3)     NULL,
+    PRIMARY KEY (id),
+    KEY idx_login_challenges_expires_at (expires_at),
+    CONSTRAINT fk_login_challenges_user FOREIGN KEY (user_id) REFERENCES users (id)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4;
@@ -0,0 +1,23 @@
+-- 用户在 OIDC 身份提供方中的身份，(issuer, subject) 唯一标识一个身份。
+CREATE TABLE user_identities (
+    id         BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    user_id    BIGINT UNSIGNED NOT NULL,
+    issuer     VARCHAR(255)    NOT NULL,
+    subject    VARCHAR(255)    NOT NULL,
+    created_at DATETIME(3)     NULL,
+    PRIMARY KEY (id),
+    UNIQUE KEY idx_user_identities_subject (issuer, subject),
+    KEY idx_user_identities_user (user_id),
+    CONSTRAINT fk_user_identities_user FOREIGN KEY (user_id) REFERENCES users (id)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4;
+
+-- 跳转到身份提供方、尚未回调的登录，id 是 state 的 SHA-256。
+CREATE TABLE oidc_logins (
+    id         CHAR(64)     NOT NULL,
+    nonce      VARCHAR(64)  NOT NULL,
+    verifier   VARCHAR(128) NOT NULL,
+    expires_at DATETIME(3)  NOT NULL,
+    created_at DATETIME(3)  NULL,
+    PRIMARY KEY (id),
+    KEY idx_oidc_logins_expires_at (expires_at)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4;
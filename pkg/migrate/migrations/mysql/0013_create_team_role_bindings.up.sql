@@ -0,0 +1,13 @@
+-- 限定在一个 Team 内的 Role 绑定，对该 Team 的 Projects 同样生效。
+CREATE TABLE team_role_bindings (
+    team_id    BIGINT UNSIGNED NOT NULL,
+    user_id    BIGINT UNSIGNED NOT NULL,
+    role_id    BIGINT UNSIGNED NOT NULL,
+    created_at DATETIME(3)     NULL,
+    PRIMARY KEY (team_id, user_id, role_id),
+    KEY idx_team_role_bindings_user_id (user_id),
+    KEY idx_team_role_bindings_role_id (role_id),
+    CONSTRAINT fk_team_role_bindings_team FOREIGN KEY (team_id) REFERENCES teams (id),
+    CONSTRAINT fk_team_role_bindings_user FOREIGN KEY (user_id) REFERENCES users (id),
+    CONSTRAINT fk_team_role_bindings_role FOREIGN KEY (role_id) REFERENCES roles (id)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4;
@@ -0,0 +1 @@
+ALTER TABLE two_factor_credentials DROP COLUMN last_step;
@@ -0,0 +1,34 @@
+-- 与 MySQL 的 utf8mb4 默认排序规则一致，唯一约束不区分大小写；
+-- CHECK 约束对应 MySQL 严格模式下 VARCHAR 的长度限制。
+CREATE TABLE users (
+    id                   INTEGER      NOT NULL PRIMARY KEY AUTOINCREMENT,
+    username             VARCHAR(30)  NOT NULL COLLATE NOCASE CHECK (length(username) <= 30),
+    email                VARCHAR(255) NULL COLLATE NOCASE CHECK (length(email) <= 255),
+    nickname             VARCHAR(255) NOT NULL DEFAULT '' CHECK (length(nickname) <= 255),
+    logo                 TEXT         NULL,
+    password_hash        VARCHAR(255) NOT NULL,
+    must_change_password BOOLEAN      NOT NULL DEFAULT 0,
+    created_at           DATETIME     NULL,
+    updated_at           DATETIME     NULL
+);
+CREATE UNIQUE INDEX idx_users_username ON users (username);
+CREATE UNIQUE INDEX idx_users_email ON users (email);
+
+CREATE TABLE roles (
+    id          INTEGER      NOT NULL PRIMARY KEY AUTOINCREMENT,
+    name        VARCHAR(64)  NOT NULL COLLATE NOCASE CHECK (length(name) <= 64),
+    type        VARCHAR(16)  NOT NULL,
+    description VARCHAR(255) NOT NULL DEFAULT '' CHECK (length(description) <= 255),
+    created_at  DATETIME     NULL,
+    updated_at  DATETIME     NULL
+);
+CREATE UNIQUE INDEX idx_roles_name ON roles (name);
+
+CREATE TABLE user_roles (
+    user_id INTEGER NOT NULL,
+    role_id INTEGER NOT NULL,
+    PRIMARY KEY (user_id, role_id),
+    CONSTRAINT fk_user_roles_user FOREIGN KEY (user_id) REFERENCES users (id),
+    CONSTRAINT fk_user_roles_role FOREIGN KEY (role_id) REFERENCES roles (id)
+);
+CREATE INDEX idx_user_roles_role_id ON user_roles (role_id);
@@ -0,0 +1,43 @@
+CREATE TABLE teams (
+    id          INTEGER      NOT NULL PRIMARY KEY AUTOINCREMENT,
+    name        VARCHAR(64)  NOT NULL COLLATE NOCASE CHECK (length(name) <= 64),
+    description VARCHAR(255) NOT NULL DEFAULT '' CHECK (length(description) <= 255),
+    leader_id   INTEGER      NULL,
+    created_at  DATETIME     NULL,
+    updated_at  DATETIME     NULL,
+    CONSTRAINT fk_teams_leader FOREIGN KEY (leader_id) REFERENCES users (id)
+);
+CREATE UNIQUE INDEX idx_teams_name ON teams (name);
+CREATE INDEX idx_teams_leader_id ON teams (leader_id);
+
+CREATE TABLE team_members (
+    team_id    INTEGER  NOT NULL,
+    user_id    INTEGER  NOT NULL,
+    created_at DATETIME NULL,
+    PRIMARY KEY (team_id, user_id),
+    CONSTRAINT fk_team_members_team FOREIGN KEY (team_id) REFERENCES teams (id),
+    CONSTRAINT fk_team_members_user FOREIGN KEY (user_id) REFERENCES users (id)
+);
+CREATE INDEX idx_team_members_user_id ON team_members (user_id);
+
+CREATE TABLE projects (
+    id          INTEGER      NOT NULL PRIMARY KEY AUTOINCREMENT,
+    team_id     INTEGER      NOT NULL,
+    name        VARCHAR(64)  NOT NULL COLLATE NOCASE CHECK (length(name) <= 64),
+    description VARCHAR(255) NOT NULL DEFAULT '' CHECK (length(description) <= 255),
+    status      VARCHAR(32)  NOT NULL,
+    created_at  DATETIME     NULL,
+    updated_at  DATETIME     NULL,
+    CONSTRAINT fk_projects_team FOREIGN KEY (team_id) REFERENCES teams (id)
+);
+CREATE UNIQUE INDEX idx_projects_team_name ON projects (team_id, name);
+
+CREATE TABLE project_members (
+    project_id INTEGER  NOT NULL,
+    user_id    INTEGER  NOT NULL,
+    created_at DATETIME NULL,
+    PRIMARY KEY (project_id, user_id),
+    CONSTRAINT fk_project_members_project FOREIGN KEY (project_id) REFERENCES projects (id),
+    CONSTRAINT fk_project_members_user FOREIGN KEY (user_id) REFERENCES users (id)
+);
+CREATE INDEX idx_project_members_user_id ON project_members (user_id);
@@ -0,0 +1,6 @@
+CREATE TABLE audits (
+    id         INTEGER  NOT NULL PRIMARY KEY AUTOINCREMENT,
+    content    TEXT     NOT NULL,
+    created_at DATETIME NULL
+);
+CREATE INDEX idx_audits_created_at ON audits (created_at);
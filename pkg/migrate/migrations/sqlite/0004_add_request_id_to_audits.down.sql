@@ -0,0 +1 @@
+ALTER TABLE audits DROP COLUMN request_id;
@@ -0,0 +1 @@
+ALTER TABLE audits ADD COLUMN request_id VARCHAR(64) NOT NULL DEFAULT '';
@@ -0,0 +1,11 @@
+-- 会话由 session.DBStore 读写，id 是 Cookie 中令牌的 SHA-256，不保存令牌本身。
+-- 不对 user_id 建外键：用户被删除后其会话由应用显式清理，残留的会话也会因用户不存在而认证失败。
+CREATE TABLE sessions (
+    id           CHAR(64) NOT NULL PRIMARY KEY,
+    user_id      INTEGER  NOT NULL,
+    created_at   DATETIME NOT NULL,
+    last_seen_at DATETIME NOT NULL,
+    expires_at   DATETIME NOT NULL
+);
+CREATE INDEX idx_sessions_user_id ON sessions (user_id);
+CREATE INDEX idx_sessions_expires_at ON sessions (expires_at);
@@ -0,0 +1,13 @@
+-- 只保存令牌的 SHA-256，令牌明文仅在创建时返回一次。
+CREATE TABLE personal_access_tokens (
+    id           INTEGER     NOT NULL PRIMARY KEY AUTOINCREMENT,
+    user_id      INTEGER     NOT NULL,
+    name         VARCHAR(64) NOT NULL COLLATE NOCASE CHECK (length(name) <= 64),
+    token_hash   CHAR(64)    NOT NULL,
+    expires_at   DATETIME    NOT NULL,
+    last_used_at DATETIME    NULL,
+    created_at   DATETIME    NULL,
+    CONSTRAINT fk_personal_access_tokens_user FOREIGN KEY (user_id) REFERENCES users (id)
+);
+CREATE UNIQUE INDEX idx_personal_access_tokens_token_hash ON personal_access_tokens (token_hash);
+CREATE UNIQUE INDEX idx_personal_access_tokens_user_name ON personal_access_tokens (user_id, name);
@@ -0,0 +1,7 @@
+-- subject 形如 "account:user:1"、"ip:192.0.2.1"，由 throttle.Limiter 读写。
+CREATE TABLE login_failures (
+    subject        VARCHAR(255) NOT NULL PRIMARY KEY,
+    failures       INTEGER      NOT NULL DEFAULT 0,
+    last_failed_at DATETIME     NOT NULL,
+    locked_until   DATETIME     NULL
+);
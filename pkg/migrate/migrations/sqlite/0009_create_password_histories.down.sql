@@ -0,0 +1,2 @@
+DROP TABLE password_histories;
+ALTER TABLE users DROP COLUMN password_changed_at;
@@ -0,0 +1,14 @@
+ALTER TABLE users ADD COLUMN password_changed_at DATETIME NULL;
+UPDATE users SET password_changed_at = updated_at;
+
+-- 只记录用户自己设置的密码，不包括 admin 设置的初始密码与临时密码。
+CREATE TABLE password_histories (
+    id            INTEGER      NOT NULL PRIMARY KEY AUTOINCREMENT,
+    user_id       INTEGER      NOT NULL,
+    password_hash VARCHAR(255) NOT NULL,
+    created_at    DATETIME     NULL,
+    CONSTRAINT fk_password_histories_user FOREIGN KEY (user_id) REFERENCES users (id)
+);
+CREATE INDEX idx_password_histories_user ON password_histories (user_id);
+INSERT INTO password_histories (user_id, password_hash, created_at)
+SELECT id, password_hash, updated_at FROM users WHERE must_change_password = 0;
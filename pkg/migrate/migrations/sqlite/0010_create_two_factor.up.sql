@@ -0,0 +1,30 @@
+-- enabled_at 为空表示用户已开始启用但尚未用验证码确认。
+CREATE TABLE two_factor_credentials (
+    user_id    INTEGER     NOT NULL PRIMARY KEY,
+    secret     VARCHAR(64) NOT NULL,
+    enabled_at DATETIME    NULL,
+    created_at DATETIME    NULL,
+    CONSTRAINT fk_two_factor_credentials_user FOREIGN KEY (user_id) REFERENCES users (id)
+);
+
+-- 只保存恢复码的 SHA-256。
+CREATE TABLE recovery_codes (
+    id         INTEGER  NOT NULL PRIMARY KEY AUTOINCREMENT,
+    user_id    INTEGER  NOT NULL,
+    code_hash  CHAR(64) NOT NULL,
+    used_at    DATETIME NULL,
+    created_at DATETIME NULL,
+    CONSTRAINT fk_recovery_codes_user FOREIGN KEY (user_id) REFERENCES users (id)
+);
+CREATE INDEX idx_recovery_codes_user ON recovery_codes (user_id);
+
+-- 密码校验通过、等待两步验证的登录，id 是挑战令牌的 SHA-256。
+CREATE TABLE login_challenges (
+    id         CHAR(64) NOT NULL PRIMARY KEY,
+    user_id    INTEGER  NOT NULL,
+    attempts   INTEGER  NOT NULL DEFAULT 0,
+    expires_at DATETIME NOT NULL,
+    created_at DATETIME NULL,
+    CONSTRAINT fk_login_challenges_user FOREIGN KEY (user_id) REFERENCES users (id)
+);
+CREATE INDEX idx_login_challenges_expires_at ON login_challenges (expires_at);
@@ -0,0 +1,21 @@
+-- 用户在 OIDC 身份提供方中的身份，(issuer, subject) 唯一标识一个身份。
+CREATE TABLE user_identities (
+    id         INTEGER      NOT NULL PRIMARY KEY AUTOINCREMENT,
+    user_id    INTEGER      NOT NULL,
+    issuer     VARCHAR(255) NOT NULL,
+    subject    VARCHAR(255) NOT NULL,
+    created_at DATETIME     NULL,
+    CONSTRAINT fk_user_identities_user FOREIGN KEY (user_id) REFERENCES users (id)
+);
+CREATE UNIQUE INDEX idx_user_identities_subject ON user_identities (issuer, subject);
+CREATE INDEX idx_user_identities_user ON user_identities (user_id);
+
+-- 跳转到身份提供方、尚未回调的登录，id 是 state 的 SHA-256。
+CREATE TABLE oidc_logins (
+    id         CHAR(64)     NOT NULL PRIMARY KEY,
+    nonce      VARCHAR(64)  NOT NULL,
+    verifier   VARCHAR(128) NOT NULL,
+    expires_at DATETIME     NOT NULL,
+    created_at DATETIME     NULL
+);
+CREATE INDEX idx_oidc_logins_expires_at ON oidc_logins (expires_at);
@@ -0,0 +1,8 @@
+-- Custom Role 拥有的权限，permission 是形如 "audits:read" 的权限名称。
+CREATE TABLE role_permissions (
+    role_id    INTEGER     NOT NULL,
+    permission VARCHAR(64) NOT NULL,
+    PRIMARY KEY (role_id, permission),
+    CONSTRAINT fk_role_permissions_role FOREIGN KEY (role_id) REFERENCES roles (id)
+);
+CREATE INDEX idx_role_permissions_permission ON role_permissions (permission);
@@ -0,0 +1 @@
+DROP TABLE team_role_bindings;
@@ -0,0 +1,13 @@
+-- 限定在一个 Team 内的 Role 绑定，对该 Team 的 Projects 同样生效。
+CREATE TABLE team_role_bindings (
+    team_id    INTEGER  NOT NULL,
+    user_id    INTEGER  NOT NULL,
+    role_id    INTEGER  NOT NULL,
+    created_at DATETIME NULL,
+    PRIMARY KEY (team_id, user_id, role_id),
+    CONSTRAINT fk_team_role_bindings_team FOREIGN KEY (team_id) REFERENCES teams (id),
+    CONSTRAINT fk_team_role_bindings_user FOREIGN KEY (user_id) REFERENCES users (id),
+    CONSTRAINT fk_team_role_bindings_role FOREIGN KEY (role_id) REFERENCES roles (id)
+);
+CREATE INDEX idx_team_role_bindings_user_id ON team_role_bindings (user_id);
+CREATE INDEX idx_team_role_bindings_role_id ON team_role_bindings (role_id);
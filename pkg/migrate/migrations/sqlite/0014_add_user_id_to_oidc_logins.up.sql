@@ -0,0 +1,2 @@
+-- 非空时表示已登录的用户发起的关联身份，回调时将身份关联到该用户而不是登录。
+ALTER TABLE oidc_logins ADD COLUMN user_id INTEGER NULL;
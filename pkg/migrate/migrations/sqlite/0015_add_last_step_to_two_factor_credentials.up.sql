@@ -0,0 +1,2 @@
+-- 最近一次接受的 TOTP 验证码所在的时间步，不大于它的验证码不再接受，以防重放。
+ALTER TABLE two_factor_credentials ADD COLUMN last_step BIGINT NOT NULL DEFAULT 0;
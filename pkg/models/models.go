@@ -0,0 +1,247 @@
+// Package models 定义了服务端持久化使用的 GORM 模型。
+package models
+
+import (
+	"time"
+)
+
+// System Role 的名称，系统初始化时创建，由系统自动管理绑定关系。
+const (
+	RoleAdmin      = "admin"
+	RoleTeamLeader = "team leader"
+	RoleNormalUser = "normal user"
+)
+
+// Role 的类型。
+const (
+	RoleTypeSystem = "System"
+	RoleTypeCustom = "Custom"
+)
+
+// Project 的状态。
+const (
+	ProjectStatusWaitForSchedule = "WAIT_FOR_SCHEDULE"
+	ProjectStatusInProgress      = "IN_PROGRESS"
+	ProjectStatusFinished        = "FINISHED"
+)
+
+// AdminUsername 是系统初始化时创建的超级用户的用户名。
+const AdminUsername = "admin"
+
+// User 是系统用户。
+type User struct {
+	ID       uint    `gorm:"primaryKey"`
+	Username string  `gorm:"size:30;not null;uniqueIndex"`
+	Email    *string `gorm:"size:255;uniqueIndex"`
+	Nickname string  `gorm:"size:255"`
+	Logo     string  `gorm:"type:text"`
+	// PasswordHash 保存密码的哈希值，永远不保存明文。
+	PasswordHash string `gorm:"size:255;not null"`
+	// MustChangePassword 为 true 时，用户在修改密码前只能访问修改密码和登出接口。
+	MustChangePassword bool `gorm:"not null;default:false"`
+	// PasswordChangedAt 是最近一次设置密码的时间，用于判断密码是否过期。
+	PasswordChangedAt time.Time `gorm:"autoCreateTime"`
+	Roles             []Role    `gorm:"many2many:user_roles"`
+	CreatedAt         time.Time
+	UpdatedAt         time.Time
+}
+
+// HasPassword 判断用户是否设置了本地密码。通过单点登录自动创建的用户没有本地密码，只能通过身份提供方登录。
+func (u *User) HasPassword() bool {
+	return u.PasswordHash != ""
+}
+
+// HasRole 判断用户是否绑定了指定名称的 Role，要求 Roles 已被加载。
+func (u *User) HasRole(name string) bool {
+	for _, role := range u.Roles {
+		if role.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// IsAdmin 判断用户是否拥有 admin 权限。
+func (u *User) IsAdmin() bool {
+	return u.HasRole(RoleAdmin)
+}
+
+// Role 是全局的角色。Custom Role 可以拥有权限，绑定它的用户即被授予这些权限。
+type Role struct {
+	ID          uint   `gorm:"primaryKey"`
+	Name        string `gorm:"size:64;not null;uniqueIndex"`
+	Type        string `gorm:"size:16;not null"`
+	Description string `gorm:"size:255"`
+	// Permissions 按名称排序。
+	Permissions []RolePermission `gorm:"foreignKey:RoleID"`
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// RolePermission 是 Role 拥有的一个权限，Permission 是 policy.Permission 的名称。
+type RolePermission struct {
+	RoleID     uint   `gorm:"primaryKey"`
+	Permission string `gorm:"primaryKey;size:64;index"`
+}
+
+// UserRole 是 User 与 Role 的多对多关联，即全局的 Role 绑定。
+type UserRole struct {
+	UserID uint `gorm:"primaryKey"`
+	RoleID uint `gorm:"primaryKey;index"`
+}
+
+// TeamRoleBinding 是限定在一个 Team 内的 Role 绑定。它不授予 Role 的权限，只在按 role_name 筛选该 Team 及其 Projects 的成员时计入。
+// 只有 Team 的成员可以被绑定，用户离开 Team 或 Team 被删除时绑定随之解除。
+type TeamRoleBinding struct {
+	TeamID    uint  `gorm:"primaryKey"`
+	UserID    uint  `gorm:"primaryKey;index"`
+	RoleID    uint  `gorm:"primaryKey;index"`
+	Team      *Team `gorm:"foreignKey:TeamID"`
+	User      *User `gorm:"foreignKey:UserID"`
+	Role      *Role `gorm:"foreignKey:RoleID"`
+	CreatedAt time.Time
+}
+
+// Team 是团队，可以有至多一个 Leader。
+type Team struct {
+	ID          uint   `gorm:"primaryKey"`
+	Name        string `gorm:"size:64;not null;uniqueIndex"`
+	Description string `gorm:"size:255"`
+	LeaderID    *uint  `gorm:"index"`
+	Leader      *User  `gorm:"foreignKey:LeaderID"`
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// TeamMember 是 Team 与 User 的多对多关联。
+type TeamMember struct {
+	TeamID    uint `gorm:"primaryKey"`
+	UserID    uint `gorm:"primaryKey;index"`
+	CreatedAt time.Time
+}
+
+// Project 属于且只属于一个 Team，名称在 Team 内唯一。
+type Project struct {
+	ID          uint   `gorm:"primaryKey"`
+	TeamID      uint   `gorm:"not null;uniqueIndex:idx_projects_team_name"`
+	Name        string `gorm:"size:64;not null;uniqueIndex:idx_projects_team_name"`
+	Description string `gorm:"size:255"`
+	Status      string `gorm:"size:32;not null"`
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// ProjectMember 是 Project 与 User 的多对多关联。
+type ProjectMember struct {
+	ProjectID uint `gorm:"primaryKey"`
+	UserID    uint `gorm:"primaryKey;index"`
+	CreatedAt time.Time
+}
+
+// Audit 是一条审计日志。
+type Audit struct {
+	ID      uint   `gorm:"primaryKey"`
+	Content string `gorm:"type:text;not null"`
+	// RequestID 是产生该审计记录的请求的 ID，与访问日志中的 request_id 对应。
+	RequestID string    `gorm:"size:64;not null;default:''"`
+	CreatedAt time.Time `gorm:"index"`
+}
+
+// Session 是持久化的登录会话，由 session.DBStore 读写。
+type Session struct {
+	// ID 是会话令牌的 SHA-256 摘要（十六进制），数据库中不保存令牌本身。
+	ID         string `gorm:"primaryKey;size:64"`
+	UserID     uint   `gorm:"not null;index"`
+	IP         string `gorm:"size:64;not null;default:''"`
+	UserAgent  string `gorm:"size:255;not null;default:''"`
+	CreatedAt  time.Time
+	LastSeenAt time.Time
+	// ExpiresAt 是会话的绝对过期时间，与空闲超时无关。
+	ExpiresAt time.Time `gorm:"index"`
+}
+
+// PersonalAccessToken 是用户为脚本等非浏览器客户端创建的访问令牌，通过 Authorization: Bearer 使用。
+type PersonalAccessToken struct {
+	ID     uint   `gorm:"primaryKey"`
+	UserID uint   `gorm:"not null;uniqueIndex:idx_personal_access_tokens_user_name"`
+	Name   string `gorm:"size:64;not null;uniqueIndex:idx_personal_access_tokens_user_name"`
+	// TokenHash 是令牌的 SHA-256 摘要（十六进制），数据库中不保存令牌本身。
+	TokenHash  string `gorm:"size:64;not null;uniqueIndex"`
+	ExpiresAt  time.Time
+	LastUsedAt *time.Time
+	CreatedAt  time.Time
+}
+
+// PasswordHistory 记录用户自己设置过的密码哈希，用于禁止重复使用最近的密码。
+type PasswordHistory struct {
+	ID           uint   `gorm:"primaryKey"`
+	UserID       uint   `gorm:"not null;index"`
+	PasswordHash string `gorm:"size:255;not null"`
+	CreatedAt    time.Time
+}
+
+// TwoFactorCredential 是用户的 TOTP 密钥。EnabledAt 为 nil 表示尚未用验证码确认，两步验证未生效。
+type TwoFactorCredential struct {
+	UserID    uint   `gorm:"primaryKey;autoIncrement:false"`
+	Secret    string `gorm:"size:64;not null"`
+	EnabledAt *time.Time
+	// LastStep 是最近一次接受的 TOTP 验证码所在的时间步，不大于它的验证码不再接受。
+	LastStep  int64 `gorm:"not null;default:0"`
+	CreatedAt time.Time
+}
+
+// Enabled 判断两步验证是否已生效。
+func (c *TwoFactorCredential) Enabled() bool {
+	return c != nil && c.EnabledAt != nil
+}
+
+// RecoveryCode 是两步验证的一次性恢复码，只保存其 SHA-256 摘要。
+type RecoveryCode struct {
+	ID        uint   `gorm:"primaryKey"`
+	UserID    uint   `gorm:"not null;index"`
+	CodeHash  string `gorm:"size:64;not null"`
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
+
+// LoginChallenge 是密码校验通过、等待两步验证的登录。ID 是挑战令牌的 SHA-256 摘要（十六进制）。
+type LoginChallenge struct {
+	ID        string `gorm:"primaryKey;size:64"`
+	UserID    uint   `gorm:"not null"`
+	Attempts  int    `gorm:"not null;default:0"`
+	ExpiresAt time.Time
+	CreatedAt time.Time
+}
+
+// UserIdentity 是用户在 OIDC 身份提供方中的身份，(Issuer, Subject) 唯一。
+type UserIdentity struct {
+	ID        uint   `gorm:"primaryKey"`
+	UserID    uint   `gorm:"not null;index"`
+	Issuer    string `gorm:"size:255;not null;uniqueIndex:idx_user_identities_subject"`
+	Subject   string `gorm:"size:255;not null;uniqueIndex:idx_user_identities_subject"`
+	CreatedAt time.Time
+}
+
+// OIDCLogin 是已跳转到身份提供方、等待回调的登录。ID 是 state 的 SHA-256 摘要（十六进制）。
+type OIDCLogin struct {
+	ID       string `gorm:"primaryKey;size:64"`
+	Nonce    string `gorm:"size:64;not null"`
+	Verifier string `gorm:"size:128;not null"`
+	// UserID 非空时表示已登录的用户发起的关联身份。
+	UserID    *uint
+	ExpiresAt time.Time
+	CreatedAt time.Time
+}
+
+// TableName 避免 GORM 将 OIDC 拆分为 o_id_c。
+func (OIDCLogin) TableName() string {
+	return "oidc_logins"
+}
+
+// LoginFailure 记录某个账号或 IP 在当前窗口内的连续登录失败，由 throttle.Limiter 读写。
+type LoginFailure struct {
+	Subject      string `gorm:"primaryKey;size:255"`
+	Failures     int    `gorm:"not null;default:0"`
+	LastFailedAt time.Time
+	LockedUntil  *time.Time
+}
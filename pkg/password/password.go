@@ -0,0 +1,142 @@
+// Package password 负责密码的哈希与校验，服务端永远只保存密码的哈希值。
+//
+// 新密码使用 argon2id 哈希，参数与盐一并编码在哈希字符串中（PHC 格式）：
+//
+//	$argon2id$v=19$m=19456,t=2,p=1$<salt>$<key>
+//
+// 调整 DefaultParams 后，已有的哈希仍可校验，NeedsRehash 会报告其参数已过时，
+// 由调用方在用户下次登录成功时重新哈希。早期版本保存的 bcrypt 哈希同样可以校验，并会被迁移为 argon2id。
+package password
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Params 是 argon2id 的参数。
+type Params struct {
+	// Memory 是内存开销，单位 KiB。
+	Memory uint32
+	// Iterations 是迭代次数。
+	Iterations uint32
+	// Parallelism 是并行度。
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+// DefaultParams 取自 OWASP Password Storage Cheat Sheet 推荐的 argon2id 最低配置，
+// 可通过 BenchmarkHash 在部署的硬件上评估后调高。
+var DefaultParams = Params{
+	Memory:      19 * 1024,
+	Iterations:  2,
+	Parallelism: 1,
+	SaltLength:  16,
+	KeyLength:   32,
+}
+
+// ErrInvalidHash 表示哈希字符串不是可识别的格式。
+var ErrInvalidHash = errors.New("invalid password hash")
+
+const argon2idPrefix = "$argon2id$"
+
+// Hash 使用 argon2id 与 DefaultParams 返回明文密码的哈希值。
+func Hash(password string) (string, error) {
+	return hashWith(password, DefaultParams)
+}
+
+func hashWith(password string, p Params) (string, error) {
+	salt := make([]byte, p.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key := argon2.IDKey([]byte(password), salt, p.Iterations, p.Memory, p.Parallelism, p.KeyLength)
+	return fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s$%s", argon2idPrefix, argon2.Version, p.Memory, p.Iterations, p.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+// Verify 校验明文密码是否与哈希匹配，不匹配时返回 false 而非错误。支持 argon2id 与 bcrypt 哈希。
+// 比较以常量时间进行，耗时不随匹配的前缀长度变化。
+func Verify(hash, password string) (bool, error) {
+	if isBcrypt(hash) {
+		err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return false, nil
+		}
+		return err == nil, err
+	}
+
+	p, salt, key, err := decode(hash)
+	if err != nil {
+		return false, err
+	}
+	other := argon2.IDKey([]byte(password), salt, p.Iterations, p.Memory, p.Parallelism, p.KeyLength)
+	return subtle.ConstantTimeCompare(key, other) == 1, nil
+}
+
+// NeedsRehash 判断 hash 是否应以当前的算法与参数重新生成，即它是 bcrypt 哈希或参数与 DefaultParams 不同。
+// 无法识别的哈希返回 false，交由 Verify 报告错误。
+func NeedsRehash(hash string) bool {
+	if isBcrypt(hash) {
+		return true
+	}
+	p, _, _, err := decode(hash)
+	if err != nil {
+		return false
+	}
+	return p != DefaultParams
+}
+
+var (
+	dummyOnce sync.Once
+	dummyHash string
+)
+
+// Dummy 以一次 Verify 的代价校验 password 并丢弃结果。
+// 登录时账号不存在也应调用，使响应时间不会暴露账号是否存在。
+func Dummy(password string) {
+	dummyOnce.Do(func() {
+		dummyHash, _ = Hash("dummy password")
+	})
+	_, _ = Verify(dummyHash, password)
+}
+
+func isBcrypt(hash string) bool {
+	return strings.HasPrefix(hash, "$2a$") || strings.HasPrefix(hash, "$2b$") || strings.HasPrefix(hash, "$2y$")
+}
+
+// decode 解析 argon2id 哈希字符串。
+func decode(hash string) (p Params, salt, key []byte, err error) {
+	parts := strings.Split(hash, "$")
+	// "", "argon2id", "v=19", "m=...,t=...,p=...", salt, key
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return p, nil, nil, ErrInvalidHash
+	}
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return p, nil, nil, fmt.Errorf("%w: unsupported argon2 version %q", ErrInvalidHash, parts[2])
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &p.Memory, &p.Iterations, &p.Parallelism); err != nil {
+		return p, nil, nil, fmt.Errorf("%w: %v", ErrInvalidHash, err)
+	}
+	if salt, err = base64.RawStdEncoding.DecodeString(parts[4]); err != nil {
+		return p, nil, nil, fmt.Errorf("%w: %v", ErrInvalidHash, err)
+	}
+	if key, err = base64.RawStdEncoding.DecodeString(parts[5]); err != nil {
+		return p, nil, nil, fmt.Errorf("%w: %v", ErrInvalidHash, err)
+	}
+	if p.Memory == 0 || p.Iterations == 0 || p.Parallelism == 0 || len(salt) == 0 || len(key) == 0 {
+		return p, nil, nil, ErrInvalidHash
+	}
+	p.SaltLength = uint32(len(salt))
+	p.KeyLength = uint32(len(key))
+	return p, salt, key, nil
+}
@@ -0,0 +1,100 @@
+package password
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestHashAndVerify(t *testing.T) {
+	hash, err := Hash("correct horse")
+	if err != nil {
+		t.Fatalf("Hash() failed: %v", err)
+	}
+	if !strings.HasPrefix(hash, "$argon2id$v=19$m=19456,t=2,p=1$") {
+		t.Errorf("Hash() = %q, want an encoded argon2id hash with DefaultParams", hash)
+	}
+	if other, _ := Hash("correct horse"); other == hash {
+		t.Error("Hash() should use a random salt")
+	}
+
+	for password, want := range map[string]bool{"correct horse": true, "correct horsE": false, "": false} {
+		if ok, err := Verify(hash, password); err != nil || ok != want {
+			t.Errorf("Verify(%q) = %v, %v, want %v", password, ok, err, want)
+		}
+	}
+	if NeedsRehash(hash) {
+		t.Error("a hash with DefaultParams should not need rehash")
+	}
+}
+
+func TestVerifyBcrypt(t *testing.T) {
+	legacy, err := bcrypt.GenerateFromPassword([]byte("adminadmin"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := Verify(string(legacy), "adminadmin"); err != nil || !ok {
+		t.Errorf("Verify() = %v, %v, want true", ok, err)
+	}
+	if ok, err := Verify(string(legacy), "admin"); err != nil || ok {
+		t.Errorf("Verify() = %v, %v, want false", ok, err)
+	}
+	if !NeedsRehash(string(legacy)) {
+		t.Error("bcrypt hashes should be migrated to argon2id")
+	}
+}
+
+func TestNeedsRehashWhenParamsChange(t *testing.T) {
+	weak := DefaultParams
+	weak.Memory /= 2
+	hash, err := hashWith("correct horse", weak)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := Verify(hash, "correct horse"); err != nil || !ok {
+		t.Errorf("hashes with old params should still verify: %v, %v", ok, err)
+	}
+	if !NeedsRehash(hash) {
+		t.Error("a hash with outdated params should need rehash")
+	}
+}
+
+func TestVerifyInvalidHash(t *testing.T) {
+	for _, hash := range []string{
+		"",
+		"plain text",
+		"$argon2i$v=19$m=19456,t=2,p=1$c2FsdA$a2V5",
+		"$argon2id$v=16$m=19456,t=2,p=1$c2FsdA$a2V5",
+		"$argon2id$v=19$m=0,t=2,p=1$c2FsdA$a2V5",
+		"$argon2id$v=19$m=19456,t=2,p=1$not base64!$a2V5",
+	} {
+		if _, err := Verify(hash, "password"); !errors.Is(err, ErrInvalidHash) {
+			t.Errorf("Verify(%q) error = %v, want ErrInvalidHash", hash, err)
+		}
+		if NeedsRehash(hash) {
+			t.Errorf("NeedsRehash(%q) = true, want false", hash)
+		}
+	}
+}
+
+// BenchmarkHash 用于在部署的硬件上评估 DefaultParams 的耗时，一次登录的哈希耗时建议在数十毫秒量级。
+//
+//	go test ./pkg/password -run '^$' -bench . -benchmem
+func BenchmarkHash(b *testing.B) {
+	b.Run("argon2id", func(b *testing.B) {
+		for b.Loop() {
+			if _, err := Hash("correct horse battery staple"); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("bcrypt", func(b *testing.B) {
+		for b.Loop() {
+			if _, err := bcrypt.GenerateFromPassword([]byte("correct horse battery staple"), bcrypt.DefaultCost); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -0,0 +1,146 @@
+package password
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/dspo/go-homework/pkg/config"
+)
+
+// Policy 按 config.PasswordPolicy 校验用户设置的密码。
+type Policy struct {
+	cfg    config.PasswordPolicy
+	banned map[string]bool
+}
+
+// Violation 是密码违反的一条规则。Rule 与 config.PasswordPolicy 的配置项同名，便于客户端区分处理。
+type Violation struct {
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// NewPolicy 创建按 cfg 校验的 Policy，cfg 须已通过 config 的校验。
+func NewPolicy(cfg config.PasswordPolicy) *Policy {
+	banned := make(map[string]bool, len(cfg.Banned))
+	for _, b := range cfg.Banned {
+		banned[strings.ToLower(b)] = true
+	}
+	return &Policy{cfg: cfg, banned: banned}
+}
+
+// History 返回不允许重复使用的最近密码个数。
+func (p *Policy) History() int {
+	return p.cfg.History
+}
+
+// Expired 判断自 changedAt 修改的密码是否已超过最长使用时间。changedAt 为零值时视为未过期。
+func (p *Policy) Expired(changedAt, now time.Time) bool {
+	return p.cfg.MaxAge > 0 && !changedAt.IsZero() && now.Sub(changedAt) > p.cfg.MaxAge
+}
+
+// Validate 返回 password 违反的全部规则，满足策略时返回 nil。不检查历史密码，见 Reused。
+func (p *Policy) Validate(password string) []Violation {
+	var violations []Violation
+	add := func(rule, format string, args ...any) {
+		violations = append(violations, Violation{Rule: rule, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if n := utf8.RuneCountInString(password); n < p.cfg.MinLength {
+		add("min_length", "password must be at least %d characters", p.cfg.MinLength)
+	} else if n > p.cfg.MaxLength {
+		add("max_length", "password must be at most %d characters", p.cfg.MaxLength)
+	}
+
+	present := make(map[string]bool, len(classNames))
+	invalid := false
+	for _, r := range password {
+		if class := p.classOf(r); class != "" {
+			present[class] = true
+		} else {
+			invalid = true
+		}
+	}
+	if invalid {
+		add("allowed_symbols", "password may only contain letters, digits and %q", p.cfg.AllowedSymbols)
+	}
+	for _, class := range p.cfg.RequiredClasses {
+		if !present[class] {
+			add("required_classes", "password must contain at least one %s", classNames[class])
+		}
+	}
+
+	if p.banned[strings.ToLower(password)] {
+		add("banned", "password is too common")
+	}
+	return violations
+}
+
+// Reused 判断 password 是否与 hashes（最近使用过的密码哈希）中的任意一个匹配。
+func (p *Policy) Reused(password string, hashes []string) (bool, error) {
+	for _, hash := range hashes {
+		ok, err := Verify(hash, password)
+		if err != nil {
+			return false, err
+		}
+		if ok {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// ReuseViolation 是密码与最近使用过的密码重复时的违规。
+func (p *Policy) ReuseViolation() Violation {
+	return Violation{Rule: "history", Message: fmt.Sprintf("password must differ from the last %d passwords", p.cfg.History)}
+}
+
+// Generate 生成满足策略的随机密码，用于 admin 重置密码时的临时密码。
+func (p *Policy) Generate() (string, error) {
+	// 长度尽量取 20 以保证足够的随机性，同时不超出策略的范围。
+	length := min(max(20, p.cfg.MinLength), p.cfg.MaxLength)
+	alphabet := "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789"
+	if slices.Contains(p.cfg.RequiredClasses, config.CharacterClassSymbol) {
+		alphabet += p.cfg.AllowedSymbols
+	}
+	for {
+		buf := make([]rune, length)
+		for i := range buf {
+			n, err := rand.Int(rand.Reader, big.NewInt(int64(len(alphabet))))
+			if err != nil {
+				return "", err
+			}
+			buf[i] = rune(alphabet[n.Int64()])
+		}
+		// 随机结果恰好缺少某一类字符的概率很低，重新生成即可。
+		if password := string(buf); p.Validate(password) == nil {
+			return password, nil
+		}
+	}
+}
+
+var classNames = map[string]string{
+	config.CharacterClassLower:  "lowercase letter",
+	config.CharacterClassUpper:  "uppercase letter",
+	config.CharacterClassDigit:  "digit",
+	config.CharacterClassSymbol: "symbol",
+}
+
+// classOf 返回字符 r 的类别，不允许使用的字符返回空字符串。
+func (p *Policy) classOf(r rune) string {
+	switch {
+	case r >= 'a' && r <= 'z':
+		return config.CharacterClassLower
+	case r >= 'A' && r <= 'Z':
+		return config.CharacterClassUpper
+	case r >= '0' && r <= '9':
+		return config.CharacterClassDigit
+	case strings.ContainsRune(p.cfg.AllowedSymbols, r):
+		return config.CharacterClassSymbol
+	}
+	return ""
+}
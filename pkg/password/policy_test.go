@@ -0,0 +1,93 @@
+package password
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/dspo/go-homework/pkg/config"
+)
+
+func rules(violations []Violation) []string {
+	var rules []string
+	for _, v := range violations {
+		rules = append(rules, v.Rule)
+	}
+	return rules
+}
+
+func TestPolicyValidate(t *testing.T) {
+	cfg := config.DefaultPasswordPolicy
+	cfg.RequiredClasses = []string{config.CharacterClassUpper, config.CharacterClassDigit}
+	p := NewPolicy(cfg)
+
+	for password, want := range map[string][]string{
+		"Correct-Horse-1":                 nil,
+		"Short1":                          {"min_length"},
+		"Correct Horse 1":                 {"allowed_symbols"},
+		"correct-horse":                   {"required_classes", "required_classes"},
+		"PASSWORD1":                       {"banned"},
+		"Ab1" + "xxxxxxx!":                {"allowed_symbols"},
+		"x":                               {"min_length", "required_classes", "required_classes"},
+		"Correct-Horse-Battery-Staple-42": {"max_length"},
+	} {
+		if got := rules(p.Validate(password)); !slices.Equal(got, want) {
+			t.Errorf("Validate(%q) = %v, want %v", password, got, want)
+		}
+	}
+}
+
+func TestPolicyDefaultsMatchLegacyRule(t *testing.T) {
+	p := NewPolicy(config.DefaultPasswordPolicy)
+	for password, ok := range map[string]bool{"pass1234": true, "admin_123-x": true, "pass 1234": false, "pass123": false, "12345678": false} {
+		if got := p.Validate(password) == nil; got != ok {
+			t.Errorf("Validate(%q) ok = %v, want %v", password, got, ok)
+		}
+	}
+}
+
+func TestPolicyReused(t *testing.T) {
+	p := NewPolicy(config.DefaultPasswordPolicy)
+	var hashes []string
+	for _, password := range []string{"first-pass", "second-pass"} {
+		hash, err := Hash(password)
+		if err != nil {
+			t.Fatal(err)
+		}
+		hashes = append(hashes, hash)
+	}
+	for password, want := range map[string]bool{"first-pass": true, "second-pass": true, "third-pass": false} {
+		if got, err := p.Reused(password, hashes); err != nil || got != want {
+			t.Errorf("Reused(%q) = %v, %v, want %v", password, got, err, want)
+		}
+	}
+}
+
+func TestPolicyExpired(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	if NewPolicy(config.DefaultPasswordPolicy).Expired(now.AddDate(-10, 0, 0), now) {
+		t.Error("passwords should never expire without max_age")
+	}
+	cfg := config.DefaultPasswordPolicy
+	cfg.MaxAge = 24 * time.Hour
+	p := NewPolicy(cfg)
+	if p.Expired(now.Add(-time.Hour), now) || !p.Expired(now.Add(-25*time.Hour), now) || p.Expired(time.Time{}, now) {
+		t.Error("unexpected result of Expired")
+	}
+}
+
+func TestPolicyGenerate(t *testing.T) {
+	cfg := config.DefaultPasswordPolicy
+	cfg.MaxLength = 12
+	cfg.RequiredClasses = []string{config.CharacterClassLower, config.CharacterClassUpper, config.CharacterClassDigit, config.CharacterClassSymbol}
+	p := NewPolicy(cfg)
+	for range 20 {
+		password, err := p.Generate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(password) != 12 || p.Validate(password) != nil {
+			t.Fatalf("Generate() = %q, which violates the policy: %v", password, p.Validate(password))
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package policy
+
+// Permission 是可以授予 Custom Role 的权限，名称形如“资源:动作”。
+// 绑定了拥有某权限的 Role 的用户，可以执行规则中声明了该权限的操作，admin 天然拥有全部权限。
+// Team 内的权限（见 PermissionInfo.TeamScoped）也可以通过限定在某个 Team 内的绑定授予，只作用于该 Team 的 Projects。
+type Permission string
+
+// 可授予的权限。只有不会借以获得 admin 权限的操作才可以委派，例如删除用户、重置密码与管理 Roles 不在其列。
+const (
+	UsersCreate Permission = "users:create"
+	UsersUnlock Permission = "users:unlock"
+	TeamsCreate Permission = "teams:create"
+	AuditsRead  Permission = "audits:read"
+
+	ProjectsRead   Permission = "projects:read"
+	ProjectsCreate Permission = "projects:create"
+	ProjectsUpdate Permission = "projects:update"
+)
+
+// PermissionInfo 描述一个权限。
+type PermissionInfo struct {
+	Name        Permission
+	Description string
+	// TeamScoped 表示权限作用于 Team 内的资源，限定在 Team 内的绑定授予该 Team 内的该权限，全局绑定授予所有 Teams 内的该权限。
+	TeamScoped bool
+}
+
+// Permissions 是全部可授予的权限，按名称排序。
+var Permissions = []PermissionInfo{
+	{Name: AuditsRead, Description: "查询审计日志"},
+	{Name: ProjectsCreate, Description: "在 Team 内创建 Project", TeamScoped: true},
+	{Name: ProjectsRead, Description: "查看 Team 内的 Projects 及其参与者", TeamScoped: true},
+	{Name: ProjectsUpdate, Description: "更新 Team 内的 Projects", TeamScoped: true},
+	{Name: TeamsCreate, Description: "创建 Team"},
+	{Name: UsersCreate, Description: "创建用户"},
+	{Name: UsersUnlock, Description: "解除用户或客户端 IP 因登录失败过多导致的锁定"},
+}
+
+// LookupPermission 返回名为 name 的可授予的权限。
+func LookupPermission(name string) (PermissionInfo, bool) {
+	for _, p := range Permissions {
+		if string(p.Name) == name {
+			return p, true
+		}
+	}
+	return PermissionInfo{}, false
+}
+
+// ValidPermission 判断 name 是否是可授予的权限。
+func ValidPermission(name string) bool {
+	_, ok := LookupPermission(name)
+	return ok
+}
@@ -0,0 +1,207 @@
+// Package policy 集中定义各 API 操作的授权规则。
+//
+// 每个 openapi operationId 对应一条声明式的 Rule：操作作用于哪类资源，actor 与该资源之间
+// 具备哪些关系之一时允许执行，以及 actor 的 Roles 拥有哪个 Permission 时同样允许执行。
+// 关系与权限的判定（如 actor 是否为 Team 的 Leader）由调用方通过 Relations 提供，本包不访问数据库。README 中的权限角色对照表由 Rules 实现，二者的一致性由测试保证。
+//
+// Rules 只回答“能否执行该操作”。列表接口按可见范围过滤结果、admin 不能删除自身等与数据相关的约束仍由各接口处理，
+// 其中只取决于 actor 与资源的约束由 server 包的 constraints 集中定义。
+package policy
+
+import (
+	"context"
+	"fmt"
+	"sort"
+)
+
+// Resource 是操作作用的资源类型，由路径参数确定。
+type Resource string
+
+const (
+	// NoResource 表示操作不作用于路径参数指定的资源，如创建 Team、查询 Me。
+	NoResource Resource = ""
+	// User 是路径参数 user_id 指定的用户。
+	User Resource = "user"
+	// Team 是路径参数 team_id 指定的 Team。
+	Team Resource = "team"
+	// Project 是路径参数 project_id 指定的 Project，其 Team 是 Project 所属的 Team。
+	Project Resource = "project"
+)
+
+// Relation 是 actor 与资源之间的关系。
+type Relation string
+
+const (
+	// Authenticated 对任何已登录的用户成立。
+	Authenticated Relation = "authenticated"
+	// Admin 在 actor 是 admin 时成立，与资源无关。
+	Admin Relation = "admin"
+	// TeamLeader 在 actor 是资源所属 Team 的 Leader 时成立。
+	TeamLeader Relation = "team leader"
+	// TeamMember 在 actor 是资源所属 Team 的成员时成立。
+	TeamMember Relation = "team member"
+	// ProjectMember 在 actor 是资源 Project 的参与者时成立。
+	ProjectMember Relation = "project member"
+	// Visible 在资源用户对 actor 可见时成立，即二者是同一用户或同处于至少一个 Team。
+	Visible Relation = "visible"
+)
+
+// Relations 判断 actor 与资源之间是否具有某种关系，以及 actor 是否被授予了某个权限。
+type Relations interface {
+	Has(ctx context.Context, relation Relation) (bool, error)
+	Granted(ctx context.Context, permission Permission) (bool, error)
+}
+
+// Rule 是一个操作的授权规则：actor 与 Resource 之间具有 AnyOf 中任一关系，
+// 或 actor 绑定的 Roles 拥有 Permission 时允许执行。作用于 Team 或 Project 的规则只使用 Team 内的权限，
+// 限定在资源所属 Team 内的绑定同样授予该权限。
+type Rule struct {
+	Resource Resource
+	// AnyOf 按顺序检查，不依赖资源的关系（如 Admin）应排在前面，以免无谓地加载资源。
+	AnyOf []Relation
+	// Permission 为空时，该操作不能通过 Role 授权。
+	Permission Permission
+}
+
+// Allow 判断 rel 是否满足 r。
+func (r Rule) Allow(ctx context.Context, rel Relations) (bool, error) {
+	for _, relation := range r.AnyOf {
+		ok, err := rel.Has(ctx, relation)
+		if err != nil {
+			return false, err
+		}
+		if ok {
+			return true, nil
+		}
+	}
+	if r.Permission == "" {
+		return false, nil
+	}
+	return rel.Granted(ctx, r.Permission)
+}
+
+func (r Rule) String() string {
+	s := fmt.Sprintf("any of %v", r.AnyOf)
+	if r.Resource != NoResource {
+		s += " on " + string(r.Resource)
+	}
+	if r.Permission != "" {
+		s += " or permission " + string(r.Permission)
+	}
+	return s
+}
+
+// adminOr 返回 admin 或被授予 permission 的 actor 可以执行的规则。
+func adminOr(permission Permission) Rule {
+	return Rule{AnyOf: []Relation{Admin}, Permission: permission}
+}
+
+var (
+	anyone        = Rule{AnyOf: []Relation{Authenticated}}
+	adminOnly     = Rule{AnyOf: []Relation{Admin}}
+	adminOnUser   = Rule{Resource: User, AnyOf: []Relation{Admin}}
+	visibleUser   = Rule{Resource: User, AnyOf: []Relation{Admin, Visible}}
+	viewTeam      = Rule{Resource: Team, AnyOf: []Relation{Admin, TeamMember}}
+	manageTeam    = Rule{Resource: Team, AnyOf: []Relation{Admin, TeamLeader}}
+	viewProject   = Rule{Resource: Project, AnyOf: []Relation{Admin, TeamLeader, ProjectMember}, Permission: ProjectsRead}
+	manageProject = Rule{Resource: Project, AnyOf: []Relation{Admin, TeamLeader}}
+	updateProject = Rule{Resource: Project, AnyOf: []Relation{Admin, TeamLeader}, Permission: ProjectsUpdate}
+)
+
+// Rules 是需要登录的全部操作的授权规则，键为 operationId。未列出的操作一律拒绝。
+var Rules = map[string]Rule{
+	// Me 相关的接口只操作 Me 自身的数据。
+	"logout":                    anyone,
+	"updateMyPassword":          anyone,
+	"getMyTwoFactor":            anyone,
+	"enrollMyTwoFactor":         anyone,
+	"disableMyTwoFactor":        anyone,
+	"activateMyTwoFactor":       anyone,
+	"regenerateMyRecoveryCodes": anyone,
+	"me":                        anyone,
+	"updateMe":                  anyone,
+	"getMyTeams":                anyone,
+	"exitTeam":                  anyone,
+	"getMyProjects":             anyone,
+	"exitProject":               anyone,
+	"listMySessions":            anyone,
+	"deleteMySessions":          anyone,
+	"getMySession":              anyone,
+	"deleteMySession":           anyone,
+	"listMyAccessTokens":        anyone,
+	"createMyAccessToken":       anyone,
+	"deleteMyAccessToken":       anyone,
+	"getMyPermissions":          anyone,
+	"linkMyOIDCIdentity":        anyone,
+
+	// 用户管理。列表只返回可见的用户，由接口过滤。
+	"createUser":         adminOr(UsersCreate),
+	"listUsers":          anyone,
+	"getUser":            visibleUser,
+	"deleteUser":         adminOnUser,
+	"getUserTeams":       visibleUser,
+	"getUserProjects":    visibleUser,
+	"listUserRoles":      visibleUser,
+	"addUserRole":        adminOnUser,
+	"removeUserRole":     adminOnUser,
+	"deleteUserSessions": adminOnUser,
+	"unlockUser":         {Resource: User, AnyOf: []Relation{Admin}, Permission: UsersUnlock},
+	"unlockIP":           adminOr(UsersUnlock),
+	"resetUserPassword":  adminOnUser,
+	"resetUserTwoFactor": adminOnUser,
+
+	// 可见性说明用于排查不可见的用户，由接口限制非 admin 只能查询自己对该用户的可见性。
+	"getUserVisibility":   {Resource: User, AnyOf: []Relation{Authenticated}},
+	"getVisibilityReport": adminOnly,
+
+	// 团队管理。列表只返回 Me 所在的 Teams，由接口过滤。
+	"listTeams":         anyone,
+	"createTeam":        adminOr(TeamsCreate),
+	"getTeam":           viewTeam,
+	"updateTeam":        manageTeam,
+	"updateTeamLeader":  manageTeam,
+	"deleteTeam":        manageTeam,
+	"getTeamUsers":      viewTeam,
+	"listTeamRoles":     viewTeam,
+	"addTeamUser":       manageTeam,
+	"removeTeamUser":    manageTeam,
+	"getTeamProjects":   viewTeam,
+	"createTeamProject": {Resource: Team, AnyOf: []Relation{Admin, TeamLeader}, Permission: ProjectsCreate},
+
+	// 项目管理。
+	"getProject":        viewProject,
+	"updateProject":     updateProject,
+	"patchProject":      updateProject,
+	"deleteProject":     manageProject,
+	"getProjectUsers":   viewProject,
+	"addProjectUser":    manageProject,
+	"removeProjectUser": manageProject,
+
+	// 角色管理。能够修改 Role 权限即能为自己授予任意权限，因此只有 admin 可以管理 Roles。
+	"listRoles":       anyone,
+	"createRole":      adminOnly,
+	"updateRole":      adminOnly,
+	"deleteRole":      adminOnly,
+	"listPermissions": anyone,
+
+	// 审计日志。
+	"audits": adminOr(AuditsRead),
+}
+
+// Lookup 返回 operationID 的授权规则。
+func Lookup(operationID string) (Rule, bool) {
+	rule, ok := Rules[operationID]
+	return rule, ok
+}
+
+// Operations 返回作用于 resource 类型资源的全部操作，按 operationId 排序。
+func Operations(resource Resource) []string {
+	var operations []string
+	for operationID, rule := range Rules {
+		if rule.Resource == resource {
+			operations = append(operations, operationID)
+		}
+	}
+	sort.Strings(operations)
+	return operations
+}
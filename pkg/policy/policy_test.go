@@ -0,0 +1,177 @@
+package policy
+
+import (
+	"bufio"
+	"context"
+	"os"
+	"strings"
+	"testing"
+)
+
+// matrixOperations 将 README 权限角色对照表中的每一行（“分组/操作”）映射到它所涵盖的 operationId。
+var matrixOperations = map[string][]string{
+	"用户管理/创建用户":   {"createUser"},
+	"用户管理/删除用户":   {"deleteUser"},
+	"用户管理/查看用户列表": {"listUsers"},
+	"用户管理/查看用户详情": {"getUser", "getUserTeams", "getUserProjects", "listUserRoles"},
+
+	"团队管理/创建团队":      {"createTeam"},
+	"团队管理/删除团队":      {"deleteTeam"},
+	"团队管理/更新团队":      {"updateTeam"},
+	"团队管理/设置 Leader": {"updateTeamLeader"},
+	"团队管理/添加成员":      {"addTeamUser"},
+	"团队管理/移除成员":      {"removeTeamUser"},
+	"团队管理/查看团队":      {"getTeam", "getTeamUsers", "getTeamProjects", "listTeamRoles"},
+
+	"项目管理/创建项目": {"createTeamProject"},
+	"项目管理/删除项目": {"deleteProject"},
+	"项目管理/更新项目": {"updateProject", "patchProject"},
+	"项目管理/添加成员": {"addProjectUser"},
+	"项目管理/移除成员": {"removeProjectUser"},
+	"项目管理/查看项目": {"getProject", "getProjectUsers"},
+
+	"角色管理/创建角色": {"createRole"},
+	"角色管理/删除角色": {"deleteRole"},
+	"角色管理/修改角色": {"updateRole"},
+	"角色管理/绑定角色": {"addUserRole", "removeUserRole"},
+	"角色管理/查看角色": {"listRoles"},
+
+	"审计日志/查看审计日志": {"audits"},
+}
+
+// personas 是对照表各列所代表的 actor 与资源之间的关系：Team Leader 与普通成员均位于资源所属的 Team，
+// 普通成员参与资源 Project；非成员与资源没有任何关系。
+var personas = map[string]fakeRelations{
+	"admin":       {Authenticated: true, Admin: true},
+	"Team Leader": {Authenticated: true, TeamLeader: true, TeamMember: true, Visible: true},
+	"普通成员":        {Authenticated: true, TeamMember: true, ProjectMember: true, Visible: true},
+	"非成员":         {Authenticated: true},
+}
+
+type fakeRelations map[Relation]bool
+
+func (f fakeRelations) Has(_ context.Context, relation Relation) (bool, error) {
+	return f[relation], nil
+}
+
+// Granted 对照表中的各角色均未绑定拥有权限的 Custom Role。
+func (fakeRelations) Granted(context.Context, Permission) (bool, error) {
+	return false, nil
+}
+
+// cell 是对照表中的一格。
+type cell struct {
+	row, persona string
+	allowed      bool
+}
+
+// readMatrix 解析 README 中的权限角色对照表。
+func readMatrix(t *testing.T) []cell {
+	t.Helper()
+	f, err := os.Open("../../README.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	var cells []cell
+	var header []string
+	var group string
+	inTable := false
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "### 权限角色对照表" {
+			inTable = true
+			continue
+		}
+		if !inTable || !strings.HasPrefix(line, "|") {
+			if inTable && header != nil {
+				break
+			}
+			continue
+		}
+		columns := strings.Split(strings.Trim(line, "|"), "|")
+		for i := range columns {
+			columns[i] = strings.TrimSpace(columns[i])
+		}
+		switch {
+		case header == nil:
+			header = columns
+		case strings.HasPrefix(columns[0], "---"):
+		case strings.HasPrefix(columns[0], "**"):
+			group = strings.Trim(columns[0], "*")
+		default:
+			if len(columns) != len(header) {
+				t.Fatalf("row %q has %d columns, want %d", line, len(columns), len(header))
+			}
+			for i, value := range columns[1:] {
+				if !strings.HasPrefix(value, "✅") && !strings.HasPrefix(value, "❌") {
+					t.Fatalf("cell %q of row %q is neither allowed nor denied", value, columns[0])
+				}
+				cells = append(cells, cell{row: group + "/" + columns[0], persona: header[i+1], allowed: strings.HasPrefix(value, "✅")})
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if len(cells) == 0 {
+		t.Fatal("permission matrix not found in README.md")
+	}
+	return cells
+}
+
+func TestRulesMatchReadme(t *testing.T) {
+	seen := make(map[string]bool)
+	for _, cell := range readMatrix(t) {
+		seen[cell.row] = true
+		operations, ok := matrixOperations[cell.row]
+		if !ok {
+			t.Errorf("README row %q is not mapped to any operation", cell.row)
+			continue
+		}
+		rel, ok := personas[cell.persona]
+		if !ok {
+			t.Fatalf("unknown column %q", cell.persona)
+		}
+		for _, operation := range operations {
+			rule, ok := Lookup(operation)
+			if !ok {
+				t.Errorf("%s: no rule for %s", cell.row, operation)
+				continue
+			}
+			allowed, err := rule.Allow(context.Background(), rel)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if allowed != cell.allowed {
+				t.Errorf("%s: %s on %s (%s) = %v, README says %v", cell.row, cell.persona, operation, rule, allowed, cell.allowed)
+			}
+		}
+	}
+	for row := range matrixOperations {
+		if !seen[row] {
+			t.Errorf("row %q is no longer in the README", row)
+		}
+	}
+}
+
+// 限定在 Team 内的绑定只授予 Team 内的权限，且只作用于该 Team 及其 Projects，
+// 因此 Team 内的权限只能用于作用于 Team 或 Project 的规则，反之亦然。
+func TestTeamScopedPermissions(t *testing.T) {
+	for operation, rule := range Rules {
+		if rule.Permission == "" {
+			continue
+		}
+		info, ok := LookupPermission(string(rule.Permission))
+		if !ok {
+			t.Errorf("%s uses unknown permission %s", operation, rule.Permission)
+			continue
+		}
+		onTeam := rule.Resource == Team || rule.Resource == Project
+		if info.TeamScoped != onTeam {
+			t.Errorf("%s on %q uses permission %s, team scoped = %v", operation, rule.Resource, rule.Permission, info.TeamScoped)
+		}
+	}
+}
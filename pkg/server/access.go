@@ -0,0 +1,141 @@
+package server
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+
+	"github.com/dspo/go-homework/pkg/models"
+)
+
+func loadUser(tx *gorm.DB, id uint) (*models.User, error) {
+	var user models.User
+	if err := tx.Preload("Roles").First(&user, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, notFound("user %d not found", id)
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+func loadTeam(tx *gorm.DB, id uint) (*models.Team, error) {
+	var team models.Team
+	if err := tx.Preload("Leader.Roles").First(&team, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, notFound("team %d not found", id)
+		}
+		return nil, err
+	}
+	return &team, nil
+}
+
+func loadProject(tx *gorm.DB, id uint) (*models.Project, error) {
+	var project models.Project
+	if err := tx.First(&project, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, notFound("project %d not found", id)
+		}
+		return nil, err
+	}
+	return &project, nil
+}
+
+func loadRole(tx *gorm.DB, id uint) (*models.Role, error) {
+	var role models.Role
+	if err := preloadPermissions(tx).First(&role, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, notFound("role %d not found", id)
+		}
+		return nil, err
+	}
+	return &role, nil
+}
+
+func exists(tx *gorm.DB, model any, query string, args ...any) (bool, error) {
+	var n int64
+	if err := tx.Model(model).Where(query, args...).Limit(1).Count(&n).Error; err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+func isTeamMember(tx *gorm.DB, teamID, userID uint) (bool, error) {
+	return exists(tx, &models.TeamMember{}, "team_id = ? AND user_id = ?", teamID, userID)
+}
+
+func isProjectMember(tx *gorm.DB, projectID, userID uint) (bool, error) {
+	return exists(tx, &models.ProjectMember{}, "project_id = ? AND user_id = ?", projectID, userID)
+}
+
+// isTeamLeader 判断 user 是否是 team 的 Leader。
+func isTeamLeader(team *models.Team, user *models.User) bool {
+	return team.LeaderID != nil && *team.LeaderID == user.ID
+}
+
+// myTeamIDs 返回 user 所在 Teams 的子查询。
+func myTeamIDs(tx *gorm.DB, userID uint) *gorm.DB {
+	return tx.Model(&models.TeamMember{}).Select("team_id").Where("user_id = ?", userID)
+}
+
+// myProjectIDs 返回 user 参与的 Projects 的子查询。
+func myProjectIDs(tx *gorm.DB, userID uint) *gorm.DB {
+	return tx.Model(&models.ProjectMember{}).Select("project_id").Where("user_id = ?", userID)
+}
+
+// syncLeaderRole 根据 user 当前是否担任任一 Team 的 Leader 绑定或解绑 team leader Role。
+func syncLeaderRole(tx *gorm.DB, userID uint) error {
+	var role models.Role
+	if err := tx.Where("name = ?", models.RoleTeamLeader).First(&role).Error; err != nil {
+		return err
+	}
+	leading, err := exists(tx, &models.Team{}, "leader_id = ?", userID)
+	if err != nil {
+		return err
+	}
+	if leading {
+		return bindRole(tx, userID, role.ID)
+	}
+	return tx.Where("user_id = ? AND role_id = ?", userID, role.ID).Delete(&models.UserRole{}).Error
+}
+
+// bindRole 幂等地为 user 绑定 role。
+func bindRole(tx *gorm.DB, userID, roleID uint) error {
+	bound, err := exists(tx, &models.UserRole{}, "user_id = ? AND role_id = ?", userID, roleID)
+	if err != nil || bound {
+		return err
+	}
+	return tx.Create(&models.UserRole{UserID: userID, RoleID: roleID}).Error
+}
+
+// addTeamMember 幂等地将 user 加入 team。
+func addTeamMember(tx *gorm.DB, teamID, userID uint) error {
+	member, err := isTeamMember(tx, teamID, userID)
+	if err != nil || member {
+		return err
+	}
+	return tx.Create(&models.TeamMember{TeamID: teamID, UserID: userID}).Error
+}
+
+// removeTeamMember 将 user 移出 team：同时退出该 Team 下的所有 Projects、解除限定在该 Team 内的 Role 绑定，
+// 若 user 是 Leader 则清空 Leader 职位。
+func removeTeamMember(tx *gorm.DB, team *models.Team, userID uint) error {
+	projectIDs := tx.Model(&models.Project{}).Select("id").Where("team_id = ?", team.ID)
+	if err := tx.Where("user_id = ? AND project_id IN (?)", userID, projectIDs).Delete(&models.ProjectMember{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("team_id = ? AND user_id = ?", team.ID, userID).Delete(&models.TeamRoleBinding{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("team_id = ? AND user_id = ?", team.ID, userID).Delete(&models.TeamMember{}).Error; err != nil {
+		return err
+	}
+	if team.LeaderID == nil || *team.LeaderID != userID {
+		return nil
+	}
+	if err := tx.Model(&models.Team{}).Where("id = ?", team.ID).Update("leader_id", nil).Error; err != nil {
+		return err
+	}
+	team.LeaderID, team.Leader = nil, nil
+	return syncLeaderRole(tx, userID)
+}
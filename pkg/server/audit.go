@@ -0,0 +1,65 @@
+package server
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/dspo/go-homework/pkg/models"
+)
+
+const auditTimeLayout = "2006-01-02 15:04:05"
+
+// audit 记录一条 "{谁} 于 {时间} {做了什么} - {结果}" 格式的审计日志。
+// 审计失败不影响业务结果，仅记录错误日志。
+func (s *Server) audit(c *gin.Context, actor *models.User, succeeded bool, format string, args ...any) {
+	audit := newAudit(c, actor, succeeded, format, args...)
+	if err := s.db.WithContext(c).Create(audit).Error; err != nil {
+		zap.L().Error("failed to write audit", zap.String("request_id", audit.RequestID), zap.String("content", audit.Content), zap.Error(err))
+	}
+}
+
+// newAudit 返回一条审计日志而不写入。须与业务数据一同提交的审计日志（如授予 admin 权限）由调用方在事务中写入。
+func newAudit(c *gin.Context, actor *models.User, succeeded bool, format string, args ...any) *models.Audit {
+	result := "成功"
+	if !succeeded {
+		result = "失败"
+	}
+	now := time.Now()
+	content := fmt.Sprintf("%s 于 %s %s - %s", describeUser(actor), now.Format(auditTimeLayout), fmt.Sprintf(format, args...), result)
+	return &models.Audit{Content: content, RequestID: requestID(c), CreatedAt: now}
+}
+
+// describeUser 返回审计日志中描述用户的片段，如 "admin (ID:1)"。
+func describeUser(u *models.User) string {
+	if u == nil {
+		return "匿名用户"
+	}
+	return fmt.Sprintf("%s (ID:%d)", u.Username, u.ID)
+}
+
+func describeTeam(t *models.Team) string {
+	return fmt.Sprintf("团队 %s (ID:%d)", t.Name, t.ID)
+}
+
+func describeProject(p *models.Project) string {
+	return fmt.Sprintf("项目 %s (ID:%d)", p.Name, p.ID)
+}
+
+// describeSession 只使用会话 ID 的前 8 位，足以辨认且不在审计日志中暴露完整 ID。
+func describeSession(id string) string {
+	if len(id) > 8 {
+		id = id[:8]
+	}
+	return fmt.Sprintf("会话 %s", id)
+}
+
+func describeAccessToken(t *models.PersonalAccessToken) string {
+	return fmt.Sprintf("个人访问令牌 %s (ID:%d)", t.Name, t.ID)
+}
+
+func describeRole(r *models.Role) string {
+	return fmt.Sprintf("角色 %s (ID:%d)", r.Name, r.ID)
+}
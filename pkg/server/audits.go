@@ -0,0 +1,57 @@
+package server
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/dspo/go-homework/pkg/models"
+)
+
+// audits 查询审计日志。未指定 order_by 时按时间倒序返回。
+func (s *Server) audits(c *gin.Context) error {
+	p, err := parsePagination(c)
+	if err != nil {
+		return err
+	}
+	startAt, err := queryInt64(c, "start_at")
+	if err != nil {
+		return err
+	}
+	endAt, err := queryInt64(c, "end_at")
+	if err != nil {
+		return err
+	}
+
+	query := s.db.WithContext(c).Model(&models.Audit{})
+	if keyword := c.Query("keyword"); keyword != "" {
+		query = query.Where("audits.content LIKE ?", likePattern(keyword))
+	}
+	if startAt != nil {
+		query = query.Where("audits.created_at >= ?", time.Unix(*startAt, 0))
+	}
+	if endAt != nil {
+		// end_at 精确到秒，包含该秒内产生的记录。
+		query = query.Where("audits.created_at < ?", time.Unix(*endAt+1, 0))
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return err
+	}
+	switch p.orderBy {
+	case "":
+		query = query.Order("audits.created_at DESC").Order("audits.id DESC").Offset((p.page - 1) * p.pageSize).Limit(p.pageSize)
+	default:
+		// 审计日志写入后不再修改，updated_at 即 created_at。
+		p.orderBy = "created_at"
+		query = p.apply(query, "audits")
+	}
+	var audits []models.Audit
+	if err := query.Find(&audits).Error; err != nil {
+		return err
+	}
+	c.JSON(http.StatusOK, newListResponse(total, audits, toAuditResponse))
+	return nil
+}
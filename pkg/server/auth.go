@@ -0,0 +1,156 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"github.com/dspo/go-homework/pkg/models"
+	"github.com/dspo/go-homework/pkg/password"
+	"github.com/dspo/go-homework/pkg/session"
+)
+
+type loginRequest struct {
+	Username *string `json:"username"`
+	Email    *string `json:"email"`
+	Password string  `json:"password"`
+}
+
+func (s *Server) login(c *gin.Context) error {
+	var req loginRequest
+	if err := bindJSON(c, &req); err != nil {
+		return err
+	}
+	if (req.Username == nil) == (req.Email == nil) {
+		return badRequest("exactly one of username and email is required")
+	}
+	if req.Password == "" {
+		return badRequest("password is required")
+	}
+
+	db := s.db.WithContext(c)
+	query, account := db.Where("username = ?", req.Username), "用户名"
+	if req.Email != nil {
+		query, account = db.Where("email = ?", req.Email), "邮箱"
+	}
+	var found models.User
+	var user *models.User
+	if err := query.First(&found).Error; err == nil {
+		user = &found
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	accountKey := loginAccountKey(&req, user)
+
+	// 被锁定时即使密码正确也拒绝登录，且不校验密码，避免锁定期间继续被猜测。
+	lockout, err := s.loginLockout(c, accountKey)
+	if err != nil {
+		return err
+	}
+	if lockout > 0 {
+		s.metrics.ObserveLoginThrottled()
+		return tooManyLoginAttempts(c, lockout)
+	}
+
+	// 仅通过单点登录的用户没有密码，不能使用密码登录。
+	if user == nil || !user.HasPassword() {
+		password.Dummy(req.Password)
+		s.metrics.ObserveLogin(false)
+		if err := s.recordLoginFailure(c, accountKey, nil); err != nil {
+			return err
+		}
+		return newError(http.StatusUnauthorized, "用户名或密码错误")
+	}
+
+	ok, err := password.Verify(user.PasswordHash, req.Password)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		s.metrics.ObserveLogin(false)
+		s.audit(c, user, false, "使用%s登录", account)
+		if err := s.recordLoginFailure(c, accountKey, user); err != nil {
+			return err
+		}
+		return newError(http.StatusUnauthorized, "用户名或密码错误")
+	}
+	s.rehashPassword(c, user, req.Password)
+
+	// 启用了两步验证的用户须再提交验证码，此时不清零登录失败计数，避免以正确的密码绕过对验证码的限流。
+	cred, err := twoFactorCredential(db, user.ID)
+	if err != nil {
+		return err
+	}
+	if cred.Enabled() {
+		return s.issueLoginChallenge(c, user)
+	}
+	return s.finishLogin(c, user, accountKey, fmt.Sprintf("使用%s登录", account))
+}
+
+// finishLogin 在用户通过全部认证步骤后清零登录失败计数并创建会话。
+func (s *Server) finishLogin(c *gin.Context, user *models.User, accountKey, action string) error {
+	if err := s.resetLoginFailures(c, accountKey); err != nil {
+		return err
+	}
+	if err := s.expirePassword(c, user); err != nil {
+		return err
+	}
+
+	sess, err := s.sessions.Create(c, user.ID, session.Client{IP: c.ClientIP(), UserAgent: c.Request.UserAgent()})
+	if err != nil {
+		return err
+	}
+	s.setSessionCookie(c, sess.Token)
+	s.metrics.ObserveLogin(true)
+	s.audit(c, user, true, "%s", action)
+	c.Status(http.StatusOK)
+	return nil
+}
+
+func (s *Server) logout(c *gin.Context) error {
+	// 使用个人访问令牌认证时没有会话，登出不会撤销令牌。
+	if sess := currentSession(c); sess != nil {
+		if err := s.sessions.Delete(c, sess.Token); err != nil {
+			return err
+		}
+	}
+	s.clearSessionCookie(c)
+	s.audit(c, currentUser(c), true, "登出")
+	c.Status(http.StatusOK)
+	return nil
+}
+
+// rehashPassword 在登录成功后将 bcrypt 或参数过时的密码哈希更新为当前的 argon2id 参数。
+// 更新失败不影响本次登录，下次登录时会再次尝试。
+func (s *Server) rehashPassword(c *gin.Context, user *models.User, plain string) {
+	if !password.NeedsRehash(user.PasswordHash) {
+		return
+	}
+	hash, err := password.Hash(plain)
+	if err == nil {
+		// 使用 UpdateColumn 不改变 updated_at，重新哈希对用户不可见。
+		err = s.db.WithContext(c).Model(&models.User{ID: user.ID}).UpdateColumn("password_hash", hash).Error
+	}
+	if err != nil {
+		zap.L().Warn("failed to rehash password", zap.String("request_id", requestID(c)), zap.Uint("user_id", user.ID), zap.Error(err))
+		return
+	}
+	user.PasswordHash = hash
+}
+
+// expirePassword 在登录成功时检查密码是否过期，过期则要求用户先修改密码，与首次登录的限制相同。
+func (s *Server) expirePassword(c *gin.Context, user *models.User) error {
+	if user.MustChangePassword || !s.passwordExpired(user) {
+		return nil
+	}
+	if err := s.db.WithContext(c).Model(&models.User{ID: user.ID}).UpdateColumn("must_change_password", true).Error; err != nil {
+		return err
+	}
+	user.MustChangePassword = true
+	s.audit(c, user, true, "密码已过期，须修改密码")
+	return nil
+}
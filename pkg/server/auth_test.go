@@ -0,0 +1,219 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/dspo/go-homework/pkg/bootstrap"
+	"github.com/dspo/go-homework/pkg/config"
+	"github.com/dspo/go-homework/pkg/models"
+	"github.com/dspo/go-homework/pkg/password"
+	"github.com/dspo/go-homework/pkg/twofactor"
+)
+
+func TestLoginRehashesLegacyPassword(t *testing.T) {
+	ts, db := newTestServer(t)
+	legacy, err := bcrypt.GenerateFromPassword([]byte(bootstrap.AdminInitialPassword), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Model(&models.User{}).Where("username = ?", models.AdminUsername).UpdateColumn("password_hash", string(legacy)).Error; err != nil {
+		t.Fatal(err)
+	}
+	var before models.User
+	db.Where("username = ?", models.AdminUsername).Take(&before)
+
+	resp, err := http.Post(ts.URL+"/api/login", "application/json",
+		strings.NewReader(`{"username":"admin","password":"`+bootstrap.AdminInitialPassword+`"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("login with a bcrypt hash = %d, want 200", resp.StatusCode)
+	}
+
+	var after models.User
+	db.Where("username = ?", models.AdminUsername).Take(&after)
+	if !strings.HasPrefix(after.PasswordHash, "$argon2id$") || password.NeedsRehash(after.PasswordHash) {
+		t.Errorf("password hash was not upgraded, got %q", after.PasswordHash)
+	}
+	if ok, err := password.Verify(after.PasswordHash, bootstrap.AdminInitialPassword); err != nil || !ok {
+		t.Errorf("upgraded hash does not verify: %v, %v", ok, err)
+	}
+	if !after.UpdatedAt.Equal(before.UpdatedAt) {
+		t.Errorf("rehash should not touch updated_at: %v -> %v", before.UpdatedAt, after.UpdatedAt)
+	}
+}
+
+func TestPasswordPolicy(t *testing.T) {
+	policy := config.DefaultPasswordPolicy
+	policy.MaxAge = 24 * time.Hour
+	ts, db := newTestServer(t, WithPasswordPolicy(policy))
+	jar, _ := cookiejar.New(nil)
+	client := &http.Client{Jar: jar}
+	do := func(method, path, body string) (int, string) {
+		t.Helper()
+		req, _ := http.NewRequest(method, ts.URL+path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		withCSRFToken(jar, req)
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		b, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(b)
+	}
+	login := func(pass string) {
+		t.Helper()
+		if code, body := do(http.MethodPost, "/api/login", `{"username":"admin","password":"`+pass+`"}`); code != http.StatusOK {
+			t.Fatalf("login = %d %s", code, body)
+		}
+	}
+	changePassword := func(old, new string) (int, string) {
+		t.Helper()
+		return do(http.MethodPut, "/api/me/password", `{"old_password":"`+old+`","new_password":"`+new+`"}`)
+	}
+
+	login(bootstrap.AdminInitialPassword)
+	code, body := changePassword(bootstrap.AdminInitialPassword, "password")
+	if code != http.StatusBadRequest || !strings.Contains(body, `"rule":"banned"`) {
+		t.Errorf("a banned password = %d %s, want 400 with a banned violation", code, body)
+	}
+	if code, body := changePassword(bootstrap.AdminInitialPassword, "first-pass"); code != http.StatusOK {
+		t.Fatalf("change password = %d %s", code, body)
+	}
+	login("first-pass")
+	if code, body := changePassword("first-pass", "second-pass"); code != http.StatusOK {
+		t.Fatalf("change password = %d %s", code, body)
+	}
+	login("second-pass")
+	code, body = changePassword("second-pass", "first-pass")
+	if code != http.StatusBadRequest || !strings.Contains(body, `"rule":"history"`) {
+		t.Errorf("reusing a recent password = %d %s, want 400 with a history violation", code, body)
+	}
+
+	// 密码过期后，登录仍会成功，但须先修改密码。
+	if err := db.Model(&models.User{}).Where("username = ?", models.AdminUsername).
+		UpdateColumn("password_changed_at", time.Now().Add(-25*time.Hour)).Error; err != nil {
+		t.Fatal(err)
+	}
+	if code, _ := do(http.MethodGet, "/api/me/2fa", ""); code != http.StatusForbidden {
+		t.Errorf("GET /api/me/2fa with an expired password = %d, want 403", code)
+	}
+	login("second-pass")
+	var admin models.User
+	db.Where("username = ?", models.AdminUsername).Take(&admin)
+	if !admin.MustChangePassword {
+		t.Error("login with an expired password should re-arm the must-change gate")
+	}
+	if code, body := changePassword("second-pass", "third-pass"); code != http.StatusOK {
+		t.Fatalf("change password = %d %s", code, body)
+	}
+	login("third-pass")
+	if code, body := do(http.MethodGet, "/api/me/2fa", ""); code != http.StatusOK {
+		t.Errorf("GET /api/me/2fa after changing the expired password = %d %s", code, body)
+	}
+}
+
+func TestTwoFactorLogin(t *testing.T) {
+	ts, db := newTestServer(t)
+	if err := db.Model(&models.User{}).Where("username = ?", models.AdminUsername).
+		UpdateColumn("must_change_password", false).Error; err != nil {
+		t.Fatal(err)
+	}
+	jar, _ := cookiejar.New(nil)
+	client := &http.Client{Jar: jar}
+	do := func(method, path string, body any) (int, map[string]any) {
+		t.Helper()
+		data, _ := json.Marshal(body)
+		req, _ := http.NewRequest(method, ts.URL+path, bytes.NewReader(data))
+		req.Header.Set("Content-Type", "application/json")
+		withCSRFToken(jar, req)
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		var out map[string]any
+		_ = json.NewDecoder(resp.Body).Decode(&out)
+		return resp.StatusCode, out
+	}
+	credentials := map[string]string{"username": "admin", "password": bootstrap.AdminInitialPassword}
+
+	if code, body := do(http.MethodPost, "/api/login", credentials); code != http.StatusOK {
+		t.Fatalf("login = %d %v", code, body)
+	}
+	if code, _ := do(http.MethodGet, "/api/me", nil); code != http.StatusForbidden {
+		t.Errorf("GET /api/me before admin enables 2FA = %d, want 403", code)
+	}
+	code, enrollment := do(http.MethodPost, "/api/me/2fa", nil)
+	if code != http.StatusOK || !strings.HasPrefix(enrollment["provisioning_uri"].(string), "otpauth://totp/") {
+		t.Fatalf("enroll = %d %v", code, enrollment)
+	}
+	secret := enrollment["secret"].(string)
+	if code, _ := do(http.MethodPost, "/api/me/2fa/activate", map[string]string{"code": "000000"}); code != http.StatusBadRequest {
+		t.Errorf("activate with a wrong code = %d, want 400", code)
+	}
+	totpCode, _ := twofactor.Code(secret, time.Now())
+	code, activated := do(http.MethodPost, "/api/me/2fa/activate", map[string]string{"code": totpCode})
+	if code != http.StatusOK {
+		t.Fatalf("activate = %d %v", code, activated)
+	}
+	recoveryCodes := activated["recovery_codes"].([]any)
+	if len(recoveryCodes) != twofactor.RecoveryCodeCount {
+		t.Errorf("got %d recovery codes, want %d", len(recoveryCodes), twofactor.RecoveryCodeCount)
+	}
+	if code, _ := do(http.MethodGet, "/api/me", nil); code != http.StatusOK {
+		t.Errorf("GET /api/me after enabling 2FA = %d, want 200", code)
+	}
+
+	challenge := func() string {
+		t.Helper()
+		do(http.MethodPost, "/api/logout", nil)
+		code, body := do(http.MethodPost, "/api/login", credentials)
+		if code != http.StatusAccepted || body["challenge"] == "" {
+			t.Fatalf("login with 2FA enabled = %d %v, want 202 with a challenge", code, body)
+		}
+		if code, _ := do(http.MethodGet, "/api/me", nil); code != http.StatusUnauthorized {
+			t.Errorf("GET /api/me before completing the login = %d, want 401", code)
+		}
+		return body["challenge"].(string)
+	}
+
+	id := challenge()
+	if code, _ := do(http.MethodPost, "/api/login/2fa", map[string]string{"challenge": id, "code": "000000"}); code != http.StatusUnauthorized {
+		t.Errorf("complete login with a wrong code = %d, want 401", code)
+	}
+	if code, _ := do(http.MethodPost, "/api/login/2fa", map[string]string{"challenge": id, "code": totpCode}); code != http.StatusUnauthorized {
+		t.Errorf("complete login with the code used for activation = %d, want 401", code)
+	}
+	// 下一个时间步的验证码在允许的时钟偏差内。
+	totpCode, _ = twofactor.Code(secret, time.Now().Add(30*time.Second))
+	if code, body := do(http.MethodPost, "/api/login/2fa", map[string]string{"challenge": id, "code": totpCode}); code != http.StatusOK {
+		t.Fatalf("complete login = %d %v", code, body)
+	}
+	if code, _ := do(http.MethodPost, "/api/login/2fa", map[string]string{"challenge": id, "code": totpCode}); code != http.StatusUnauthorized {
+		t.Errorf("reusing a completed challenge = %d, want 401", code)
+	}
+	if code, _ := do(http.MethodPost, "/api/login/2fa", map[string]string{"challenge": challenge(), "code": totpCode}); code != http.StatusUnauthorized {
+		t.Errorf("reusing a TOTP code = %d, want 401", code)
+	}
+
+	recoveryCode := recoveryCodes[0].(string)
+	if code, body := do(http.MethodPost, "/api/login/2fa", map[string]string{"challenge": challenge(), "code": recoveryCode}); code != http.StatusOK {
+		t.Fatalf("complete login with a recovery code = %d %v", code, body)
+	}
+	if code, _ := do(http.MethodPost, "/api/login/2fa", map[string]string{"challenge": challenge(), "code": recoveryCode}); code != http.StatusUnauthorized {
+		t.Errorf("reusing a recovery code = %d, want 401", code)
+	}
+}
@@ -0,0 +1,223 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"github.com/dspo/go-homework/pkg/models"
+	"github.com/dspo/go-homework/pkg/policy"
+)
+
+// authorize 按 policy.Rules 中请求的 operationId 对应的规则授权，没有规则的操作一律拒绝。
+// 路径参数指定的资源在规则需要时才加载，不存在时返回 404。
+func (s *Server) authorize(c *gin.Context) {
+	operationID := s.operationID(c)
+	rule, ok := policy.Lookup(operationID)
+	if !ok {
+		zap.L().Error("no authorization rule for operation", zap.String("operation", operationID), zap.String("request_id", requestID(c)))
+		abortWithError(c, errForbidden)
+		return
+	}
+	id := func() (uint, error) { return pathID(c, resourceParams[rule.Resource]) }
+	allowed, err := rule.Allow(c, &relations{db: s.db.WithContext(c), me: currentUser(c), resource: rule.Resource, id: id})
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	if !allowed {
+		abortWithError(c, errForbidden)
+		return
+	}
+	c.Next()
+}
+
+// resourceParams 是各类资源在路径中的参数名。
+var resourceParams = map[policy.Resource]string{
+	policy.User:    "user_id",
+	policy.Team:    "team_id",
+	policy.Project: "project_id",
+}
+
+// relations 实现 policy.Relations，判断 Me 与 id 指定的资源之间的关系，以及 Me 的 Roles 拥有的权限。
+type relations struct {
+	db       *gorm.DB
+	me       *models.User
+	resource policy.Resource
+	// id 返回资源的 ID，仅在规则需要加载资源时调用。
+	id func() (uint, error)
+
+	loaded  bool
+	user    *models.User
+	team    *models.Team
+	project *models.Project
+}
+
+func (r *relations) Has(_ context.Context, relation policy.Relation) (bool, error) {
+	switch relation {
+	case policy.Authenticated:
+		return true, nil
+	case policy.Admin:
+		return r.me.IsAdmin(), nil
+	}
+
+	if err := r.load(); err != nil {
+		return false, err
+	}
+	switch relation {
+	case policy.TeamLeader:
+		return r.team != nil && isTeamLeader(r.team, r.me), nil
+	case policy.TeamMember:
+		if r.team == nil {
+			return false, nil
+		}
+		return isTeamMember(r.db, r.team.ID, r.me.ID)
+	case policy.ProjectMember:
+		if r.project == nil {
+			return false, nil
+		}
+		return isProjectMember(r.db, r.project.ID, r.me.ID)
+	case policy.Visible:
+		if r.user == nil {
+			return false, nil
+		}
+		return canSee(r.db, r.me, r.user.ID)
+	}
+	return false, fmt.Errorf("unknown relation %q", relation)
+}
+
+// Granted 判断 Me 的 Roles 是否拥有 permission。作用于 Team 或 Project 的规则同样计入限定在资源所属 Team 内的绑定。
+func (r *relations) Granted(_ context.Context, permission policy.Permission) (bool, error) {
+	var teamID uint
+	if r.resource == policy.Team || r.resource == policy.Project {
+		if err := r.load(); err != nil {
+			return false, err
+		}
+		teamID = r.team.ID
+	}
+	return hasPermission(r.db, r.me.ID, permission, teamID)
+}
+
+// load 加载规则作用的资源，只加载一次。
+func (r *relations) load() error {
+	if r.loaded {
+		return nil
+	}
+	r.loaded = true
+	if r.resource == policy.NoResource {
+		return nil
+	}
+	id, err := r.id()
+	if err != nil {
+		return err
+	}
+	switch r.resource {
+	case policy.User:
+		r.user, err = loadUser(r.db, id)
+	case policy.Team:
+		r.team, err = loadTeam(r.db, id)
+	case policy.Project:
+		if r.project, err = loadProject(r.db, id); err != nil {
+			return err
+		}
+		r.team, err = loadTeam(r.db, r.project.TeamID)
+	}
+	return err
+}
+
+// hasPermission 判断用户绑定的 Roles 中是否有拥有 permission 的。teamID 不为 0 时计入全局绑定与限定在该 Team 内的绑定，
+// 否则只计入全局绑定。
+func hasPermission(tx *gorm.DB, userID uint, permission policy.Permission, teamID uint) (bool, error) {
+	roleIDs := tx.Model(&models.UserRole{}).Select("role_id").Where("user_id = ?", userID)
+	if teamID == 0 {
+		return exists(tx, &models.RolePermission{}, "permission = ? AND role_id IN (?)", string(permission), roleIDs)
+	}
+	scoped := tx.Model(&models.TeamRoleBinding{}).Select("role_id").Where("user_id = ? AND team_id = ?", userID, teamID)
+	return exists(tx, &models.RolePermission{}, "permission = ? AND (role_id IN (?) OR role_id IN (?))", string(permission), roleIDs, scoped)
+}
+
+// maxPermissionResources 是一次最多查询的资源数。
+const maxPermissionResources = 100
+
+// resourceTypes 是 resource 参数中各类资源的前缀，与资源路径一致，如 teams/12。
+var resourceTypes = map[string]policy.Resource{
+	"users":    policy.User,
+	"teams":    policy.Team,
+	"projects": policy.Project,
+}
+
+// getMyPermissions 按与 authorize 相同的规则及各接口共用的 constraints，返回 Me 对每个 resource 参数指定的资源可以执行的操作。
+// 未指定 resource 时返回不作用于具体资源的操作，如创建 Team。不存在的资源不影响其他资源的结果。
+func (s *Server) getMyPermissions(c *gin.Context) error {
+	resources := c.QueryArray("resource")
+	if len(resources) == 0 {
+		resources = []string{""}
+	}
+	if len(resources) > maxPermissionResources {
+		return badRequest("at most %d resources can be queried at once", maxPermissionResources)
+	}
+
+	db := s.db.WithContext(c)
+	me := currentUser(c)
+	list := make([]resourcePermissionsResponse, 0, len(resources))
+	for _, resource := range resources {
+		kind, id, err := parseResource(resource)
+		if err != nil {
+			return err
+		}
+		item := resourcePermissionsResponse{Resource: resource, Actions: []string{}}
+		rel := &relations{db: db, me: me, resource: kind, id: func() (uint, error) { return id, nil }}
+		// 先加载资源，使不存在的资源没有可以执行的操作，而不是 admin 可以执行全部操作。
+		if err := rel.load(); err != nil {
+			var e *apiError
+			if !errors.As(err, &e) || e.status != http.StatusNotFound {
+				return err
+			}
+			item.Error = e.message
+			list = append(list, item)
+			continue
+		}
+		for _, operationID := range policy.Operations(kind) {
+			rule, _ := policy.Lookup(operationID)
+			allowed, err := rule.Allow(c, rel)
+			if err != nil {
+				return err
+			}
+			if !allowed {
+				continue
+			}
+			if err := checkConstraint(db, operationID, me, rel.user); err != nil {
+				var e *apiError
+				if !errors.As(err, &e) {
+					return err
+				}
+				continue
+			}
+			item.Actions = append(item.Actions, operationID)
+		}
+		list = append(list, item)
+	}
+	c.JSON(http.StatusOK, listResponse[resourcePermissionsResponse]{Total: int64(len(list)), List: list})
+	return nil
+}
+
+// parseResource 解析形如 teams/12 的资源，空字符串表示不作用于具体资源。
+func parseResource(resource string) (policy.Resource, uint, error) {
+	if resource == "" {
+		return policy.NoResource, 0, nil
+	}
+	prefix, rawID, _ := strings.Cut(resource, "/")
+	kind, ok := resourceTypes[prefix]
+	id, err := strconv.ParseUint(rawID, 10, 64)
+	if !ok || err != nil || id == 0 {
+		return "", 0, badRequest("invalid resource: %q", resource)
+	}
+	return kind, uint(id), nil
+}
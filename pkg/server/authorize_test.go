@@ -0,0 +1,29 @@
+package server
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dspo/go-homework/pkg/config"
+	"github.com/dspo/go-homework/pkg/database"
+	"github.com/dspo/go-homework/pkg/policy"
+)
+
+func TestEveryAPIRouteHasRule(t *testing.T) {
+	db, err := database.Open(config.Database{Name: config.DatabaseSQLite, Path: filepath.Join(t.TempDir(), "app.db")}, nil)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	s := New(db, nil)
+	s.Handler()
+	public := map[string]bool{"login": true, "completeLogin": true, "startOIDCLogin": true, "completeOIDCLogin": true}
+	for route, operation := range s.operations {
+		if !strings.Contains(route, " /api/") || public[operation] {
+			continue
+		}
+		if _, ok := policy.Lookup(operation); !ok {
+			t.Errorf("%s (%s) has no authorization rule", route, operation)
+		}
+	}
+}
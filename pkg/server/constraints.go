@@ -0,0 +1,85 @@
+package server
+
+import (
+	"gorm.io/gorm"
+
+	"github.com/dspo/go-homework/pkg/models"
+)
+
+// constraint 是操作在授权规则之外、只取决于 Me 与路径参数指定的用户的约束，违反时返回接口会返回的错误。
+// target 是路径参数指定的用户，不作用于用户的操作为 nil。
+type constraint func(tx *gorm.DB, me, target *models.User) error
+
+// constraints 以 operationId 为键，由各接口与 getMyPermissions 共用，使 getMyPermissions 不会报告必然被接口拒绝的操作。
+// 取决于请求体或其他参数的约束（如新密码是否满足策略）仍只由接口检查。
+var constraints = map[string]constraint{
+	"updateMyPassword":   checkUpdateMyPassword,
+	"disableMyTwoFactor": checkDisableMyTwoFactor,
+	"deleteUser":         checkDeleteUser,
+	"removeUserRole":     checkRemoveAnyRole,
+	"resetUserPassword":  checkResetUserPassword,
+}
+
+// checkConstraint 检查 operationID 的约束，没有约束的操作总是通过。
+func checkConstraint(tx *gorm.DB, operationID string, me, target *models.User) error {
+	check, ok := constraints[operationID]
+	if !ok {
+		return nil
+	}
+	return check(tx, me, target)
+}
+
+func checkUpdateMyPassword(_ *gorm.DB, me, _ *models.User) error {
+	if !me.HasPassword() {
+		return badRequest("users signed in through single sign-on have no password to change")
+	}
+	return nil
+}
+
+func checkDisableMyTwoFactor(_ *gorm.DB, me, _ *models.User) error {
+	if me.IsAdmin() {
+		return forbidden("admin 必须启用两步验证")
+	}
+	return nil
+}
+
+// checkDeleteUser 禁止删除自身与初始化时创建的 admin 用户。
+func checkDeleteUser(_ *gorm.DB, me, target *models.User) error {
+	if target.ID == me.ID || target.Username == models.AdminUsername {
+		return forbidden("the admin user can not be deleted")
+	}
+	return nil
+}
+
+func checkResetUserPassword(_ *gorm.DB, me, target *models.User) error {
+	if target.ID == me.ID {
+		return badRequest("use PUT /api/me/password to change your own password")
+	}
+	return nil
+}
+
+// checkRevokeAdmin 禁止解除初始化时创建的 admin 用户与 Me 自身的 admin Role。
+func checkRevokeAdmin(me, target *models.User) error {
+	if target.Username == models.AdminUsername {
+		return forbidden("the bootstrap admin user always keeps the admin role")
+	}
+	if target.ID == me.ID {
+		return forbidden("you can not revoke your own admin role")
+	}
+	return nil
+}
+
+// checkRemoveAnyRole 要求 target 至少有一个 Me 可以解绑的 Role 绑定：Custom Role 的绑定，或 checkRevokeAdmin 允许解除的 admin Role。
+// 接口按请求解绑的 Role 检查，只与 getMyPermissions 共用 checkRevokeAdmin。
+func checkRemoveAnyRole(tx *gorm.DB, me, target *models.User) error {
+	for _, role := range target.Roles {
+		if role.Type == models.RoleTypeCustom || role.Name == models.RoleAdmin && checkRevokeAdmin(me, target) == nil {
+			return nil
+		}
+	}
+	scoped, err := exists(tx, &models.TeamRoleBinding{}, "user_id = ?", target.ID)
+	if err != nil || scoped {
+		return err
+	}
+	return notFound("user %d has no role that can be removed", target.ID)
+}
@@ -0,0 +1,97 @@
+package server
+
+import (
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dspo/go-homework/pkg/bootstrap"
+	"github.com/dspo/go-homework/pkg/config"
+	"github.com/dspo/go-homework/pkg/models"
+)
+
+func TestCSRF(t *testing.T) {
+	ts, db := newTestServer(t)
+	jar, _ := cookiejar.New(nil)
+	client := &http.Client{Jar: jar}
+	do := func(method, path, body string, header http.Header) int {
+		t.Helper()
+		req, _ := http.NewRequest(method, ts.URL+path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		for k, v := range header {
+			req.Header[k] = v
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	if code := do(http.MethodPost, "/api/login", `{"username":"admin","password":"`+bootstrap.AdminInitialPassword+`"}`, nil); code != http.StatusOK {
+		t.Fatalf("login = %d", code)
+	}
+	var token string
+	u, _ := url.Parse(ts.URL)
+	for _, cookie := range jar.Cookies(u) {
+		if cookie.Name == csrfCookieName {
+			token = cookie.Value
+		}
+	}
+	if token == "" {
+		t.Fatal("login should set the CSRF token cookie")
+	}
+
+	changePassword := `{"old_password":"` + bootstrap.AdminInitialPassword + `","new_password":"first-pass"}`
+	for name, header := range map[string]http.Header{
+		"no token":    nil,
+		"wrong token": {csrfHeader: {strings.Repeat("0", len(token))}},
+	} {
+		if code := do(http.MethodPut, "/api/me/password", changePassword, header); code != http.StatusForbidden {
+			t.Errorf("%s: PUT /api/me/password = %d, want 403", name, code)
+		}
+	}
+	if code := do(http.MethodPut, "/api/me/password", changePassword, http.Header{csrfHeader: {token}}); code != http.StatusOK {
+		t.Errorf("PUT /api/me/password with the CSRF token = %d, want 200", code)
+	}
+
+	// 个人访问令牌不依赖 Cookie，无须 CSRF token。
+	var admin models.User
+	db.Where("username = ?", models.AdminUsername).Take(&admin)
+	secret := "pat_csrf"
+	if err := db.Create(&models.PersonalAccessToken{UserID: admin.ID, Name: "ci", TokenHash: hashAccessToken(secret), ExpiresAt: time.Now().Add(time.Hour)}).Error; err != nil {
+		t.Fatal(err)
+	}
+	if code := do(http.MethodPost, "/api/logout", "", http.Header{"Authorization": {"Bearer " + secret}}); code != http.StatusOK {
+		t.Errorf("POST /api/logout with an access token = %d, want 200", code)
+	}
+}
+
+func TestSessionCookieAttributes(t *testing.T) {
+	ts, _ := newTestServer(t, WithSessionCookie(config.SessionCookie{Secure: true, SameSite: config.SameSiteStrict, Domain: "example.com"}))
+	resp, err := http.Post(ts.URL+"/api/login", "application/json",
+		strings.NewReader(`{"username":"admin","password":"`+bootstrap.AdminInitialPassword+`"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	cookies := make(map[string]*http.Cookie)
+	for _, cookie := range resp.Cookies() {
+		cookies[cookie.Name] = cookie
+	}
+	for name, httpOnly := range map[string]bool{sessionCookieName: true, csrfCookieName: false} {
+		cookie := cookies[name]
+		if cookie == nil {
+			t.Errorf("login did not set the %s cookie", name)
+			continue
+		}
+		if !cookie.Secure || cookie.SameSite != http.SameSiteStrictMode || cookie.Domain != "example.com" || cookie.Path != "/" || cookie.HttpOnly != httpOnly {
+			t.Errorf("unexpected %s cookie attributes: %+v", name, cookie)
+		}
+	}
+}
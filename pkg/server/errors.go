@@ -0,0 +1,78 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// apiError 是带有 HTTP 状态码的业务错误，会被渲染为 {"error": "..."}。
+type apiError struct {
+	status  int
+	message string
+	// details 与 error 一并渲染到响应体中，用于提供结构化的错误信息。
+	details gin.H
+}
+
+func (e *apiError) Error() string {
+	return e.message
+}
+
+func newError(status int, format string, args ...any) error {
+	return &apiError{status: status, message: fmt.Sprintf(format, args...)}
+}
+
+func badRequest(format string, args ...any) error {
+	return newError(http.StatusBadRequest, format, args...)
+}
+
+func forbidden(format string, args ...any) error {
+	return newError(http.StatusForbidden, format, args...)
+}
+
+func notFound(format string, args ...any) error {
+	return newError(http.StatusNotFound, format, args...)
+}
+
+func conflict(format string, args ...any) error {
+	return newError(http.StatusConflict, format, args...)
+}
+
+var (
+	errUnauthorized = newError(http.StatusUnauthorized, "未登录或会话已过期")
+	errForbidden    = newError(http.StatusForbidden, "无权限执行此操作")
+)
+
+// handlerFunc 是返回 error 的 gin handler，由 wrap 统一渲染错误。
+type handlerFunc func(c *gin.Context) error
+
+func wrap(h handlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if err := h(c); err != nil {
+			abortWithError(c, err)
+		}
+	}
+}
+
+func abortWithError(c *gin.Context, err error) {
+	var ae *apiError
+	switch {
+	case errors.As(err, &ae):
+		body := gin.H{"error": ae.message}
+		for k, v := range ae.details {
+			body[k] = v
+		}
+		c.AbortWithStatusJSON(ae.status, body)
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "资源不存在"})
+	case errors.Is(err, gorm.ErrDuplicatedKey):
+		c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": "资源已存在或存在冲突"})
+	default:
+		// 由访问日志连同请求 ID 一起记录。
+		_ = c.Error(err)
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+	}
+}
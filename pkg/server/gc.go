@@ -0,0 +1,44 @@
+package server
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// RunGC 每隔 interval 清理一次过期的会话、登录失败计数与登录挑战，直到 ctx 结束。
+func (s *Server) RunGC(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.collectGarbage(ctx)
+		}
+	}
+}
+
+func (s *Server) collectGarbage(ctx context.Context) {
+	deleters := map[string]func(context.Context) (int64, error){
+		"sessions":         s.sessions.DeleteExpired,
+		"login challenges": s.deleteExpiredLoginChallenges,
+		"oidc logins":      s.deleteExpiredOIDCLogins,
+	}
+	if s.loginThrottle != nil {
+		deleters["account login failures"] = s.loginThrottle.account.DeleteExpired
+		deleters["ip login failures"] = s.loginThrottle.ip.DeleteExpired
+	}
+	for what, deleteExpired := range deleters {
+		n, err := deleteExpired(ctx)
+		if err != nil {
+			zap.L().Warn("failed to delete expired "+what, zap.Error(err))
+			continue
+		}
+		if n > 0 {
+			zap.L().Debug("deleted expired "+what, zap.Int64("count", n))
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// readinessTimeout 是一次就绪检查的总时长，需小于 readinessProbe 的 timeoutSeconds。
+const readinessTimeout = time.Second
+
+// 健康检查的结果状态。
+const (
+	healthOK   = "ok"
+	healthFail = "fail"
+)
+
+type readinessCheck struct {
+	name  string
+	check func(context.Context) error
+}
+
+type healthResponse struct {
+	Status string              `json:"status"`
+	Checks []healthCheckResult `json:"checks,omitempty"`
+}
+
+type healthCheckResult struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// AddReadinessCheck 为 /readyz 增加一项名为 name 的检查，check 返回 nil 表示该项就绪。
+// 应在 Handler 开始处理请求前调用。
+func (s *Server) AddReadinessCheck(name string, check func(context.Context) error) {
+	s.readiness = append(s.readiness, readinessCheck{name: name, check: check})
+}
+
+// pingDatabase 是内置的数据库连通性检查。
+func (s *Server) pingDatabase(ctx context.Context) error {
+	sqlDB, err := s.db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
+}
+
+// livez 只表示进程存活，不检查任何依赖，避免依赖故障导致进程被反复重启。
+func (s *Server) livez(c *gin.Context) {
+	c.JSON(http.StatusOK, healthResponse{Status: healthOK})
+}
+
+// readyz 执行全部就绪检查，任一项失败时返回 503，响应体中列出每一项的结果。
+func (s *Server) readyz(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c, readinessTimeout)
+	defer cancel()
+
+	resp := healthResponse{Status: healthOK, Checks: make([]healthCheckResult, 0, len(s.readiness))}
+	for _, rc := range s.readiness {
+		result := healthCheckResult{Name: rc.name, Status: healthOK}
+		if err := rc.check(ctx); err != nil {
+			result.Status, result.Error = healthFail, err.Error()
+			resp.Status = healthFail
+		}
+		resp.Checks = append(resp.Checks, result)
+	}
+
+	code := http.StatusOK
+	if resp.Status != healthOK {
+		code = http.StatusServiceUnavailable
+	}
+	c.JSON(code, resp)
+}
@@ -0,0 +1,84 @@
+package server
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"regexp"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/dspo/go-homework/pkg/models"
+)
+
+// requestIDHeader 用于传递请求 ID，请求中携带合法的值时沿用，否则由服务端生成。
+const requestIDHeader = "X-Request-ID"
+
+const ctxKeyRequestID = "request_id"
+
+// validRequestID 限制沿用的请求 ID 的字符与长度，长度与 audits.request_id 列宽度一致。
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,64}$`)
+
+// quietOperations 是探针与指标采集接口，访问频繁且无业务含义，只在 debug 级别记录访问日志。
+var quietOperations = map[string]bool{"livez": true, "readyz": true, "healthz": true, "metrics": true}
+
+// assignRequestID 为请求分配 ID，写入响应头并供访问日志与审计日志使用。
+func assignRequestID(c *gin.Context) {
+	id := c.GetHeader(requestIDHeader)
+	if !validRequestID.MatchString(id) {
+		id = newRequestID()
+	}
+	c.Set(ctxKeyRequestID, id)
+	c.Header(requestIDHeader, id)
+	c.Next()
+}
+
+func newRequestID() string {
+	buf := make([]byte, 16)
+	_, _ = rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
+
+// requestID 返回 assignRequestID 分配的请求 ID。
+func requestID(c *gin.Context) string {
+	return c.GetString(ctxKeyRequestID)
+}
+
+// accessLog 在请求结束后记录一条访问日志。
+func (s *Server) accessLog(c *gin.Context) {
+	start := time.Now()
+	c.Next()
+
+	operation := s.operationID(c)
+	status := c.Writer.Status()
+	level := zapcore.InfoLevel
+	switch {
+	case status >= 500:
+		level = zapcore.ErrorLevel
+	case quietOperations[operation]:
+		level = zapcore.DebugLevel
+	}
+	ce := zap.L().Check(level, "access")
+	if ce == nil {
+		return
+	}
+
+	fields := []zap.Field{
+		zap.String("request_id", requestID(c)),
+		zap.String("method", c.Request.Method),
+		zap.String("path", c.Request.URL.Path),
+		zap.String("operation", operation),
+		zap.Int("status", status),
+		zap.Duration("latency", time.Since(start)),
+		zap.String("client_ip", c.ClientIP()),
+	}
+	if me, ok := c.Get(ctxKeyMe); ok {
+		fields = append(fields, zap.Uint("user_id", me.(*models.User).ID))
+	}
+	if errs := c.Errors.ByType(gin.ErrorTypePrivate); len(errs) > 0 {
+		fields = append(fields, zap.String("error", errs.String()))
+	}
+	ce.Write(fields...)
+}
@@ -0,0 +1,62 @@
+package server
+
+import (
+	"net/http"
+	"regexp"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+
+	"github.com/dspo/go-homework/pkg/models"
+)
+
+func TestRequestIDIsPropagatedToLogsAndAudits(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	defer zap.ReplaceGlobals(zap.New(core))()
+	ts, db := newTestServer(t)
+
+	login := func(requestID string) *http.Response {
+		req, _ := http.NewRequest(http.MethodPost, ts.URL+"/api/login", strings.NewReader(`{"username":"admin","password":"wrong"}`))
+		req.Header.Set("Content-Type", "application/json")
+		if requestID != "" {
+			req.Header.Set(requestIDHeader, requestID)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp
+	}
+
+	if got := login("trace-123").Header.Get(requestIDHeader); got != "trace-123" {
+		t.Errorf("incoming request ID was not honoured, got %q", got)
+	}
+	generated := regexp.MustCompile(`^[0-9a-f]{32}$`)
+	if got := login("").Header.Get(requestIDHeader); !generated.MatchString(got) {
+		t.Errorf("request ID was not generated, got %q", got)
+	}
+	if got := login("not a valid id").Header.Get(requestIDHeader); !generated.MatchString(got) {
+		t.Errorf("invalid request ID was not replaced, got %q", got)
+	}
+
+	var audit models.Audit
+	if err := db.Where("request_id = ?", "trace-123").First(&audit).Error; err != nil {
+		t.Errorf("audit entry does not carry the request ID: %v", err)
+	}
+
+	entries := logs.FilterMessage("access").FilterField(zap.String("request_id", "trace-123")).All()
+	if len(entries) != 1 {
+		t.Fatalf("got %d access log entries for the request, want 1", len(entries))
+	}
+	fields := entries[0].ContextMap()
+	if fields["operation"] != "login" || fields["status"] != int64(http.StatusUnauthorized) || fields["method"] != http.MethodPost {
+		t.Errorf("unexpected access log fields: %v", fields)
+	}
+	if _, ok := fields["latency"]; !ok {
+		t.Error("access log has no latency")
+	}
+}
@@ -0,0 +1,224 @@
+package server
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/dspo/go-homework/pkg/models"
+	"github.com/dspo/go-homework/pkg/password"
+)
+
+func (s *Server) me(c *gin.Context) error {
+	c.JSON(http.StatusOK, toUserResponse(currentUser(c)))
+	return nil
+}
+
+type updateMeRequest struct {
+	Email    *string `json:"email"`
+	Nickname *string `json:"nickname"`
+	Logo     *string `json:"logo"`
+}
+
+func (s *Server) updateMe(c *gin.Context) error {
+	var req updateMeRequest
+	if err := bindJSON(c, &req); err != nil {
+		return err
+	}
+
+	me := currentUser(c)
+	updates := map[string]any{}
+	if req.Email != nil {
+		if !emailPattern.MatchString(*req.Email) {
+			return badRequest("invalid email: %q", *req.Email)
+		}
+		taken, err := exists(s.db.WithContext(c), &models.User{}, "email = ? AND id <> ?", *req.Email, me.ID)
+		if err != nil {
+			return err
+		}
+		if taken {
+			return conflict("email %q is already in use", *req.Email)
+		}
+		updates["email"] = *req.Email
+	}
+	if req.Nickname != nil {
+		updates["nickname"] = *req.Nickname
+	}
+	if req.Logo != nil {
+		updates["logo"] = *req.Logo
+	}
+	if len(updates) > 0 {
+		if err := s.db.WithContext(c).Model(&models.User{ID: me.ID}).Updates(updates).Error; err != nil {
+			return err
+		}
+		s.audit(c, me, true, "更新了个人信息")
+	}
+
+	user, err := loadUser(s.db.WithContext(c), me.ID)
+	if err != nil {
+		return err
+	}
+	c.JSON(http.StatusOK, toUserResponse(user))
+	return nil
+}
+
+type updatePasswordRequest struct {
+	OldPassword string `json:"old_password"`
+	NewPassword string `json:"new_password"`
+}
+
+// updateMyPassword 修改密码后使该用户的全部会话失效，需要重新登录。
+func (s *Server) updateMyPassword(c *gin.Context) error {
+	var req updatePasswordRequest
+	if err := bindJSON(c, &req); err != nil {
+		return err
+	}
+	if err := s.validatePassword(req.NewPassword); err != nil {
+		return err
+	}
+
+	me := currentUser(c)
+	if err := checkConstraint(s.db.WithContext(c), "updateMyPassword", me, nil); err != nil {
+		return err
+	}
+	ok, err := password.Verify(me.PasswordHash, req.OldPassword)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		s.audit(c, me, false, "修改密码")
+		return badRequest("old password is incorrect")
+	}
+	if req.OldPassword == req.NewPassword {
+		return badRequest("new password must differ from the old one")
+	}
+
+	hash, err := password.Hash(req.NewPassword)
+	if err != nil {
+		return err
+	}
+	err = s.db.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		if err := s.checkPasswordHistory(tx, me.ID, req.NewPassword); err != nil {
+			return err
+		}
+		if err := tx.Model(&models.User{ID: me.ID}).Updates(map[string]any{
+			"password_hash":        hash,
+			"must_change_password": false,
+			"password_changed_at":  time.Now(),
+		}).Error; err != nil {
+			return err
+		}
+		return s.recordPasswordHistory(tx, me.ID, hash)
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := s.revokeCredentials(c, me.ID); err != nil {
+		return err
+	}
+	s.clearSessionCookie(c)
+	s.audit(c, me, true, "修改密码")
+	c.Status(http.StatusOK)
+	return nil
+}
+
+func (s *Server) getMyTeams(c *gin.Context) error {
+	p, err := parsePagination(c)
+	if err != nil {
+		return err
+	}
+	leading, err := queryBool(c, "leading")
+	if err != nil {
+		return err
+	}
+
+	me := currentUser(c)
+	query := s.db.WithContext(c).Model(&models.Team{}).Where("teams.id IN (?)", myTeamIDs(s.db, me.ID))
+	if leading != nil {
+		if *leading {
+			query = query.Where("teams.leader_id = ?", me.ID)
+		} else {
+			query = query.Where("(teams.leader_id IS NULL OR teams.leader_id <> ?)", me.ID)
+		}
+	}
+	return s.renderTeams(c, query, p)
+}
+
+func (s *Server) exitTeam(c *gin.Context) error {
+	teamID, err := pathID(c, "team_id")
+	if err != nil {
+		return err
+	}
+
+	me := currentUser(c)
+	var team *models.Team
+	err = s.db.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		if team, err = loadTeam(tx, teamID); err != nil {
+			return err
+		}
+		member, err := isTeamMember(tx, team.ID, me.ID)
+		if err != nil {
+			return err
+		}
+		if !member {
+			return notFound("you are not a member of team %d", teamID)
+		}
+		return removeTeamMember(tx, team, me.ID)
+	})
+	if err != nil {
+		return err
+	}
+
+	s.audit(c, me, true, "退出了%s", describeTeam(team))
+	c.Status(http.StatusOK)
+	return nil
+}
+
+func (s *Server) getMyProjects(c *gin.Context) error {
+	p, err := parsePagination(c)
+	if err != nil {
+		return err
+	}
+	teamIDs, err := queryIDs(c, "team_id")
+	if err != nil {
+		return err
+	}
+
+	me := currentUser(c)
+	query := s.db.WithContext(c).Model(&models.Project{}).Where("projects.id IN (?)", myProjectIDs(s.db, me.ID))
+	if len(teamIDs) > 0 {
+		query = query.Where("projects.team_id IN ?", teamIDs)
+	}
+	if name := c.Query("name"); name != "" {
+		query = query.Where("projects.name LIKE ?", likePattern(name))
+	}
+	return s.renderProjects(c, query, p)
+}
+
+func (s *Server) exitProject(c *gin.Context) error {
+	projectID, err := pathID(c, "project_id")
+	if err != nil {
+		return err
+	}
+
+	me := currentUser(c)
+	db := s.db.WithContext(c)
+	project, err := loadProject(db, projectID)
+	if err != nil {
+		return err
+	}
+	result := db.Where("project_id = ? AND user_id = ?", project.ID, me.ID).Delete(&models.ProjectMember{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return notFound("you are not a member of project %d", projectID)
+	}
+
+	s.audit(c, me, true, "退出了%s", describeProject(project))
+	c.Status(http.StatusOK)
+	return nil
+}
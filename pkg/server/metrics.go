@@ -0,0 +1,54 @@
+package server
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+
+	"github.com/dspo/go-homework/pkg/metrics"
+)
+
+// registerMetrics 注册依赖 Server 状态的指标。
+func (s *Server) registerMetrics() {
+	s.metrics.MustRegister(
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: metrics.Namespace,
+			Name:      "active_sessions",
+			Help:      "Number of active login sessions.",
+		}, s.countSessions),
+		metrics.NewResourceCollector(s.db),
+	)
+	if sqlDB, err := s.db.DB(); err == nil {
+		s.metrics.MustRegister(metrics.NewDBStatsCollector(sqlDB, s.db.Dialector.Name()))
+	} else {
+		zap.L().Warn("failed to collect database pool stats", zap.Error(err))
+	}
+}
+
+// countSessions 统计未过期的会话数量，失败时返回 NaN 以免误报为 0。
+func (s *Server) countSessions() float64 {
+	ctx, cancel := context.WithTimeout(context.Background(), metrics.ScrapeTimeout)
+	defer cancel()
+	n, err := s.sessions.Count(ctx)
+	if err != nil {
+		zap.L().Warn("failed to count sessions", zap.Error(err))
+		return math.NaN()
+	}
+	return float64(n)
+}
+
+// observe 按 operationId 与状态码记录请求数量与耗时。
+func (s *Server) observe(c *gin.Context) {
+	start := time.Now()
+	c.Next()
+
+	operation := s.operationID(c)
+	if operation == "" {
+		operation = metrics.UnknownOperation
+	}
+	s.metrics.ObserveRequest(operation, c.Writer.Status(), time.Since(start))
+}
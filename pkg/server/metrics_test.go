@@ -0,0 +1,47 @@
+package server
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/dspo/go-homework/pkg/bootstrap"
+	"github.com/dspo/go-homework/sdk"
+)
+
+func TestMetrics(t *testing.T) {
+	ts, _ := newTestServer(t)
+	client := sdk.NewSDK(ts.URL)
+
+	if _, err := client.LoginWithUsername("admin", "wrong password"); err == nil {
+		t.Fatal("login with a wrong password should fail")
+	}
+	if _, err := client.LoginWithUsername("admin", bootstrap.AdminInitialPassword); err != nil {
+		t.Fatalf("failed to login: %v", err)
+	}
+	if resp, err := http.Get(ts.URL + "/no/such/path"); err == nil {
+		resp.Body.Close()
+	}
+
+	text, err := client.Metrics()
+	if err != nil {
+		t.Fatalf("failed to scrape /metrics: %v", err)
+	}
+	for _, want := range []string{
+		`go_homework_http_requests_total{operation="login",status="200"} 1`,
+		`go_homework_http_requests_total{operation="login",status="401"} 1`,
+		`go_homework_http_requests_total{operation="unknown",status="404"} 1`,
+		`go_homework_http_request_duration_seconds_count{operation="login",status="200"} 1`,
+		`go_homework_logins_total{result="failure"} 1`,
+		`go_homework_logins_total{result="success"} 1`,
+		`go_homework_active_sessions 1`,
+		`go_homework_users 1`,
+		`go_homework_teams 0`,
+		`go_homework_projects{status="IN_PROGRESS"} 0`,
+		`go_sql_open_connections{db_name="sqlite"}`,
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("/metrics does not contain %s", want)
+		}
+	}
+}
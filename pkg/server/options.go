@@ -0,0 +1,77 @@
+package server
+
+import (
+	"github.com/dspo/go-homework/pkg/config"
+	"github.com/dspo/go-homework/pkg/password"
+	"github.com/dspo/go-homework/pkg/sso"
+	"github.com/dspo/go-homework/pkg/throttle"
+)
+
+// Option 定制 Server 的可选行为。
+type Option func(*Server)
+
+// WithLoginThrottle 按 cfg 分别对账号与客户端 IP 限制登录失败，未设置时登录不限流。
+func WithLoginThrottle(cfg config.LoginThrottle) Option {
+	return func(s *Server) {
+		s.loginThrottle = &loginThrottle{
+			account: throttle.New(s.db, "account", throttle.Policy{
+				MaxFailures: cfg.AccountMaxFailures,
+				Window:      cfg.Window,
+				Lockout:     cfg.Lockout,
+				MaxLockout:  cfg.MaxLockout,
+			}),
+			ip: throttle.New(s.db, "ip", throttle.Policy{
+				MaxFailures: cfg.IPMaxFailures,
+				Window:      cfg.Window,
+				Lockout:     cfg.Lockout,
+				MaxLockout:  cfg.MaxLockout,
+			}),
+		}
+	}
+}
+
+// WithServing 设置服务是否已可以处理 /api 下的请求，serving 返回 false 时这些接口返回 503，
+// 健康检查与指标不受影响。用于在启动时先提供 /livez、/readyz，待数据库迁移与初始化完成后再开放 API。
+func WithServing(serving func() bool) Option {
+	return func(s *Server) {
+		s.serving = serving
+	}
+}
+
+// WithPasswordPolicy 设置用户设置密码时须满足的策略。
+func WithPasswordPolicy(cfg config.PasswordPolicy) Option {
+	return func(s *Server) {
+		s.passwordPolicy = password.NewPolicy(cfg)
+	}
+}
+
+// WithTrustedProxies 设置可信的反向代理，见 config.Server.TrustedProxies。
+// 未设置时不信任任何代理，客户端 IP 总是取连接的对端地址。
+func WithTrustedProxies(proxies []string) Option {
+	return func(s *Server) {
+		s.trustedProxies = proxies
+	}
+}
+
+// WithAppName 设置应用名称，用作身份验证器中 TOTP 账号的 issuer，未设置时为 config.DefaultAppName。
+func WithAppName(name string) Option {
+	return func(s *Server) {
+		s.appName = name
+	}
+}
+
+// WithOIDC 按 cfg 启用 OIDC 单点登录，cfg.Enabled 为 false 时不启用。
+func WithOIDC(cfg config.OIDC) Option {
+	return func(s *Server) {
+		if cfg.Enabled {
+			s.sso = &singleSignOn{provider: sso.New(cfg), autoProvision: cfg.AutoProvision}
+		}
+	}
+}
+
+// WithSessionCookie 设置 session Cookie 的属性，未设置时为不带 Secure 的 SameSite=Lax Cookie。
+func WithSessionCookie(cfg config.SessionCookie) Option {
+	return func(s *Server) {
+		s.cookie = cfg
+	}
+}
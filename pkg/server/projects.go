@@ -0,0 +1,246 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/dspo/go-homework/pkg/models"
+)
+
+var projectStatuses = map[string]bool{
+	models.ProjectStatusWaitForSchedule: true,
+	models.ProjectStatusInProgress:      true,
+	models.ProjectStatusFinished:        true,
+}
+
+func (s *Server) getProject(c *gin.Context) error {
+	project, _, err := loadProjectAndTeam(c, s.db.WithContext(c))
+	if err != nil {
+		return err
+	}
+	c.JSON(http.StatusOK, toProjectResponse(project))
+	return nil
+}
+
+type updateProjectRequest struct {
+	Name   string  `json:"name"`
+	Desc   string  `json:"desc"`
+	Status *string `json:"status"`
+}
+
+// updateProject 整体更新 Project：name 必填，desc 被替换，未传 status 时保持不变。
+func (s *Server) updateProject(c *gin.Context) error {
+	var req updateProjectRequest
+	if err := bindJSON(c, &req); err != nil {
+		return err
+	}
+	updates := map[string]any{"name": req.Name, "description": req.Desc}
+	if req.Status != nil {
+		updates["status"] = *req.Status
+	}
+	return s.saveProject(c, updates)
+}
+
+// projectPatch 是 patchProject 接受的 JSON Patch 操作。
+type projectPatch struct {
+	Op    string  `json:"op"`
+	Path  string  `json:"path"`
+	Value *string `json:"value"`
+}
+
+func (s *Server) patchProject(c *gin.Context) error {
+	var patches []projectPatch
+	if err := bindJSON(c, &patches); err != nil {
+		return err
+	}
+	if len(patches) == 0 {
+		return badRequest("at least one patch operation is required")
+	}
+	columns := map[string]string{"/name": "name", "/desc": "description", "/status": "status"}
+	updates := map[string]any{}
+	for _, patch := range patches {
+		column, ok := columns[patch.Path]
+		if patch.Op != "replace" || !ok {
+			return badRequest("unsupported patch operation: %s %s", patch.Op, patch.Path)
+		}
+		if patch.Value == nil {
+			return badRequest("value is required for %s", patch.Path)
+		}
+		updates[column] = *patch.Value
+	}
+	return s.saveProject(c, updates)
+}
+
+// saveProject 校验并保存 updateProject 与 patchProject 的变更。
+func (s *Server) saveProject(c *gin.Context, updates map[string]any) error {
+	if name, ok := updates["name"].(string); ok {
+		if err := validateName("project", name); err != nil {
+			return err
+		}
+	}
+	if status, ok := updates["status"].(string); ok && !projectStatuses[status] {
+		return badRequest("invalid project status: %q", status)
+	}
+
+	db := s.db.WithContext(c)
+	project, team, err := loadProjectAndTeam(c, db)
+	if err != nil {
+		return err
+	}
+	if name, ok := updates["name"].(string); ok {
+		taken, err := exists(db, &models.Project{}, "team_id = ? AND name = ? AND id <> ?", project.TeamID, name, project.ID)
+		if err != nil {
+			return err
+		}
+		if taken {
+			return conflict("project name %q is already in use in team %d", name, team.ID)
+		}
+	}
+	if err := db.Model(project).Updates(updates).Error; err != nil {
+		return err
+	}
+
+	if project, err = loadProject(db, project.ID); err != nil {
+		return err
+	}
+	s.audit(c, currentUser(c), true, "修改了%s", describeProject(project))
+	c.JSON(http.StatusOK, toProjectResponse(project))
+	return nil
+}
+
+// deleteProject 删除 Project 并解除所有参与者的关联。
+func (s *Server) deleteProject(c *gin.Context) error {
+	var project *models.Project
+	err := s.db.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		var err error
+		if project, _, err = loadProjectAndTeam(c, tx); err != nil {
+			return err
+		}
+		if err := tx.Where("project_id = ?", project.ID).Delete(&models.ProjectMember{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(project).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	s.audit(c, currentUser(c), true, "删除了%s", describeProject(project))
+	c.Status(http.StatusOK)
+	return nil
+}
+
+func (s *Server) getProjectUsers(c *gin.Context) error {
+	p, err := parsePagination(c)
+	if err != nil {
+		return err
+	}
+	db := s.db.WithContext(c)
+	project, _, err := loadProjectAndTeam(c, db)
+	if err != nil {
+		return err
+	}
+
+	query := db.Model(&models.User{}).Where("users.id IN (?)",
+		s.db.Model(&models.ProjectMember{}).Select("user_id").Where("project_id = ?", project.ID))
+	// 按 role_name 筛选时，限定在 Project 所属 Team 内的 Role 绑定同样计入。
+	query = filterByRoles(c, s.db, query, []uint{project.TeamID})
+	return s.renderUsers(c, searchUsers(c, query), p)
+}
+
+// addProjectUser 为 Project 添加参与者，参与者若尚不是 Project 所属 Team 的成员则自动加入该 Team。
+func (s *Server) addProjectUser(c *gin.Context) error {
+	var req addMemberRequest
+	if err := bindJSON(c, &req); err != nil {
+		return err
+	}
+
+	me := currentUser(c)
+	var project *models.Project
+	var target *models.User
+	err := s.db.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		var err error
+		if project, _, err = loadProjectAndTeam(c, tx); err != nil {
+			return err
+		}
+		if target, err = s.loadAddableUser(tx, me, req.UserID); err != nil {
+			return err
+		}
+		if err := addTeamMember(tx, project.TeamID, target.ID); err != nil {
+			return err
+		}
+		member, err := isProjectMember(tx, project.ID, target.ID)
+		if err != nil || member {
+			return err
+		}
+		return tx.Create(&models.ProjectMember{ProjectID: project.ID, UserID: target.ID}).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	s.audit(c, me, true, "将用户 %s 加入了%s", describeUser(target), describeProject(project))
+	c.Status(http.StatusOK)
+	return nil
+}
+
+// removeProjectUser 清退 Project 的参与者，不影响其 Team 成员身份。
+func (s *Server) removeProjectUser(c *gin.Context) error {
+	userID, err := pathID(c, "user_id")
+	if err != nil {
+		return err
+	}
+
+	db := s.db.WithContext(c)
+	project, _, err := loadProjectAndTeam(c, db)
+	if err != nil {
+		return err
+	}
+	target, err := loadUser(db, userID)
+	if err != nil {
+		return err
+	}
+	result := db.Where("project_id = ? AND user_id = ?", project.ID, target.ID).Delete(&models.ProjectMember{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return notFound("user %d is not a member of project %d", target.ID, project.ID)
+	}
+
+	s.audit(c, currentUser(c), true, "将用户 %s 移出了%s", describeUser(target), describeProject(project))
+	c.Status(http.StatusOK)
+	return nil
+}
+
+// loadProjectAndTeam 加载路径参数 project_id 指定的 Project 及其所属 Team，Me 对它的权限已由 authorize 校验。
+func loadProjectAndTeam(c *gin.Context, tx *gorm.DB) (*models.Project, *models.Team, error) {
+	projectID, err := pathID(c, "project_id")
+	if err != nil {
+		return nil, nil, err
+	}
+	project, err := loadProject(tx, projectID)
+	if err != nil {
+		return nil, nil, err
+	}
+	team, err := loadTeam(tx, project.TeamID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return project, team, nil
+}
+
+func (s *Server) renderProjects(c *gin.Context, query *gorm.DB, p *pagination) error {
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return err
+	}
+	var projects []models.Project
+	if err := p.apply(query, "projects").Find(&projects).Error; err != nil {
+		return err
+	}
+	c.JSON(http.StatusOK, newListResponse(total, projects, toProjectResponse))
+	return nil
+}
@@ -0,0 +1,139 @@
+package server
+
+import (
+	"encoding/json"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+var (
+	usernamePattern = regexp.MustCompile(`^[a-zA-Z0-9_-]{4,30}$`)
+	emailPattern    = regexp.MustCompile(`^[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}$`)
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+// bindJSON 严格解析请求体，拒绝未声明的字段。
+func bindJSON(c *gin.Context, out any) error {
+	dec := json.NewDecoder(c.Request.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(out); err != nil {
+		return badRequest("invalid request body: %v", err)
+	}
+	return nil
+}
+
+// pathID 解析路径参数中的资源 ID。
+func pathID(c *gin.Context, name string) (uint, error) {
+	id, err := strconv.ParseUint(c.Param(name), 10, 64)
+	if err != nil || id == 0 {
+		return 0, badRequest("invalid %s: %q", name, c.Param(name))
+	}
+	return uint(id), nil
+}
+
+// queryIDs 解析可多传的整型 query 参数，如 team_id=1&team_id=2。
+func queryIDs(c *gin.Context, name string) ([]uint, error) {
+	var ids []uint
+	for _, v := range c.QueryArray(name) {
+		id, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			return nil, badRequest("invalid %s: %q", name, v)
+		}
+		ids = append(ids, uint(id))
+	}
+	return ids, nil
+}
+
+// queryBool 解析可选的布尔 query 参数，未传时返回 nil。
+func queryBool(c *gin.Context, name string) (*bool, error) {
+	v, ok := c.GetQuery(name)
+	if !ok || v == "" {
+		return nil, nil
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return nil, badRequest("invalid %s: %q", name, v)
+	}
+	return &b, nil
+}
+
+// queryInt64 解析可选的整型 query 参数，未传时返回 nil。
+func queryInt64(c *gin.Context, name string) (*int64, error) {
+	v, ok := c.GetQuery(name)
+	if !ok || v == "" {
+		return nil, nil
+	}
+	n, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return nil, badRequest("invalid %s: %q", name, v)
+	}
+	return &n, nil
+}
+
+// pagination 是列表接口通用的分页与排序参数。
+type pagination struct {
+	page     int
+	pageSize int
+	orderBy  string
+}
+
+func parsePagination(c *gin.Context) (*pagination, error) {
+	p := &pagination{page: 1, pageSize: defaultPageSize}
+	if v, ok := c.GetQuery("page"); ok {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return nil, badRequest("invalid page: %q", v)
+		}
+		p.page = n
+	}
+	if v, ok := c.GetQuery("page_size"); ok {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxPageSize {
+			return nil, badRequest("invalid page_size: %q", v)
+		}
+		p.pageSize = n
+	}
+	switch v := c.Query("order_by"); v {
+	case "", "created_at", "updated_at":
+		p.orderBy = v
+	default:
+		return nil, badRequest("invalid order_by: %q", v)
+	}
+	return p, nil
+}
+
+// apply 为查询附加排序与分页，table 用于限定排序列所属的表。
+func (p *pagination) apply(tx *gorm.DB, table string) *gorm.DB {
+	if p.orderBy != "" {
+		tx = tx.Order(table + "." + p.orderBy)
+	}
+	return tx.Order(table + ".id").Offset((p.page - 1) * p.pageSize).Limit(p.pageSize)
+}
+
+// likePattern 构造模糊搜索的 LIKE 参数。
+func likePattern(s string) string {
+	return "%" + s + "%"
+}
+
+// maxNameLength 与 Team、Project、Role 的 name 列宽度一致。
+const maxNameLength = 64
+
+// validateName 校验 Team、Project、Role 的名称：不能为空，且不超过 maxNameLength 个字符。
+func validateName(kind, name string) error {
+	if strings.TrimSpace(name) == "" {
+		return badRequest("%s name is required", kind)
+	}
+	if utf8.RuneCountInString(name) > maxNameLength {
+		return badRequest("%s name must be at most %d characters", kind, maxNameLength)
+	}
+	return nil
+}
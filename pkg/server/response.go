@@ -0,0 +1,249 @@
+package server
+
+import (
+	"github.com/dspo/go-homework/pkg/models"
+	"github.com/dspo/go-homework/pkg/policy"
+	"github.com/dspo/go-homework/pkg/session"
+)
+
+// 以下类型是 openapi.yaml 中 components.schemas 对应的响应体。
+
+type userResponse struct {
+	ID        uint           `json:"id"`
+	Username  string         `json:"username"`
+	Email     *string        `json:"email,omitempty"`
+	Nickname  string         `json:"nickname"`
+	Logo      string         `json:"logo,omitempty"`
+	Roles     []roleResponse `json:"roles"`
+	CreatedAt int64          `json:"created_at"`
+	UpdatedAt int64          `json:"updated_at"`
+}
+
+type roleResponse struct {
+	ID   uint   `json:"id"`
+	Name string `json:"name"`
+	Type string `json:"type"`
+	Desc string `json:"desc,omitempty"`
+	// Permissions 仅在 Roles 接口中返回，用户的 roles 属性不包含权限。
+	Permissions []string `json:"permissions,omitempty"`
+}
+
+// roleBindingResponse 是一个 Role 绑定，Team 为空表示全局绑定。
+type roleBindingResponse struct {
+	User userResponse  `json:"user"`
+	Role roleResponse  `json:"role"`
+	Team *teamResponse `json:"team,omitempty"`
+}
+
+type permissionResponse struct {
+	Name string `json:"name"`
+	Desc string `json:"desc"`
+	// TeamScoped 表示权限可以通过限定在 Team 内的绑定授予。
+	TeamScoped bool `json:"team_scoped"`
+}
+
+// visibilityResponse 说明 viewer 是否可见 user。
+type visibilityResponse struct {
+	ViewerID      uint   `json:"viewer_id"`
+	UserID        uint   `json:"user_id"`
+	Visible       bool   `json:"visible"`
+	Reason        string `json:"reason"`
+	SharedTeamIDs []uint `json:"shared_team_ids"`
+}
+
+// resourcePermissionsResponse 是 Me 对一个资源可以执行的操作，操作以 operationId 表示。资源不存在时 Actions 为空，Error 说明原因。
+type resourcePermissionsResponse struct {
+	Resource string   `json:"resource"`
+	Actions  []string `json:"actions"`
+	Error    string   `json:"error,omitempty"`
+}
+
+type teamResponse struct {
+	ID        uint          `json:"id"`
+	Name      string        `json:"name"`
+	Desc      string        `json:"desc,omitempty"`
+	Leader    *userResponse `json:"leader,omitempty"`
+	CreatedAt int64         `json:"created_at"`
+	UpdatedAt int64         `json:"updated_at"`
+}
+
+type teamDetailResponse struct {
+	teamResponse
+	Projects []teamProjectResponse `json:"projects"`
+}
+
+type teamProjectResponse struct {
+	ID   uint   `json:"id"`
+	Name string `json:"name"`
+}
+
+type projectResponse struct {
+	ID        uint   `json:"id"`
+	Name      string `json:"name"`
+	Desc      string `json:"desc,omitempty"`
+	Status    string `json:"status"`
+	CreatedAt int64  `json:"created_at"`
+	UpdatedAt int64  `json:"updated_at"`
+}
+
+type sessionResponse struct {
+	ID string `json:"id"`
+	// Current 标识发出本次请求的会话。
+	Current    bool   `json:"current"`
+	IP         string `json:"ip"`
+	UserAgent  string `json:"user_agent"`
+	CreatedAt  int64  `json:"created_at"`
+	LastSeenAt int64  `json:"last_seen_at"`
+	ExpiresAt  int64  `json:"expires_at"`
+}
+
+type accessTokenResponse struct {
+	ID   uint   `json:"id"`
+	Name string `json:"name"`
+	// Token 是令牌明文，仅在创建时返回。
+	Token      string `json:"token,omitempty"`
+	CreatedAt  int64  `json:"created_at"`
+	ExpiresAt  int64  `json:"expires_at"`
+	LastUsedAt *int64 `json:"last_used_at,omitempty"`
+}
+
+type auditResponse struct {
+	ID        uint   `json:"id"`
+	Content   string `json:"content"`
+	RequestID string `json:"request_id,omitempty"`
+	CreatedAt int64  `json:"created_at"`
+}
+
+type listResponse[T any] struct {
+	Total int64 `json:"total"`
+	List  []T   `json:"list"`
+}
+
+func newListResponse[M, T any](total int64, items []M, convert func(*M) T) listResponse[T] {
+	list := make([]T, 0, len(items))
+	for i := range items {
+		list = append(list, convert(&items[i]))
+	}
+	return listResponse[T]{Total: total, List: list}
+}
+
+func toUserResponse(u *models.User) userResponse {
+	nickname := u.Nickname
+	if nickname == "" {
+		nickname = u.Username
+	}
+	roles := make([]roleResponse, 0, len(u.Roles))
+	for i := range u.Roles {
+		roles = append(roles, toRoleResponse(&u.Roles[i]))
+	}
+	return userResponse{
+		ID:        u.ID,
+		Username:  u.Username,
+		Email:     u.Email,
+		Nickname:  nickname,
+		Logo:      u.Logo,
+		Roles:     roles,
+		CreatedAt: u.CreatedAt.Unix(),
+		UpdatedAt: u.UpdatedAt.Unix(),
+	}
+}
+
+func toRoleResponse(r *models.Role) roleResponse {
+	resp := roleResponse{
+		ID:   r.ID,
+		Name: r.Name,
+		Type: r.Type,
+		Desc: r.Description,
+	}
+	for _, p := range r.Permissions {
+		resp.Permissions = append(resp.Permissions, p.Permission)
+	}
+	return resp
+}
+
+func toRoleBindingResponse(b *models.TeamRoleBinding) roleBindingResponse {
+	resp := roleBindingResponse{
+		User: toUserResponse(b.User),
+		Role: toRoleResponse(b.Role),
+	}
+	if b.Team != nil {
+		team := toTeamResponse(b.Team)
+		resp.Team = &team
+	}
+	return resp
+}
+
+func toVisibilityResponse(v *visibility) visibilityResponse {
+	return visibilityResponse{
+		ViewerID:      v.viewerID,
+		UserID:        v.userID,
+		Visible:       v.visible(),
+		Reason:        v.reason,
+		SharedTeamIDs: v.sharedTeamIDs,
+	}
+}
+
+func toPermissionResponse(p *policy.PermissionInfo) permissionResponse {
+	return permissionResponse{Name: string(p.Name), Desc: p.Description, TeamScoped: p.TeamScoped}
+}
+
+func toTeamResponse(t *models.Team) teamResponse {
+	resp := teamResponse{
+		ID:        t.ID,
+		Name:      t.Name,
+		Desc:      t.Description,
+		CreatedAt: t.CreatedAt.Unix(),
+		UpdatedAt: t.UpdatedAt.Unix(),
+	}
+	if t.Leader != nil {
+		leader := toUserResponse(t.Leader)
+		resp.Leader = &leader
+	}
+	return resp
+}
+
+func toProjectResponse(p *models.Project) projectResponse {
+	return projectResponse{
+		ID:        p.ID,
+		Name:      p.Name,
+		Desc:      p.Description,
+		Status:    p.Status,
+		CreatedAt: p.CreatedAt.Unix(),
+		UpdatedAt: p.UpdatedAt.Unix(),
+	}
+}
+
+func toAuditResponse(a *models.Audit) auditResponse {
+	return auditResponse{
+		ID:        a.ID,
+		Content:   a.Content,
+		RequestID: a.RequestID,
+		CreatedAt: a.CreatedAt.Unix(),
+	}
+}
+
+func toSessionResponse(sess *session.Session, currentID string) sessionResponse {
+	return sessionResponse{
+		ID:         sess.ID,
+		Current:    sess.ID == currentID,
+		IP:         sess.IP,
+		UserAgent:  sess.UserAgent,
+		CreatedAt:  sess.CreatedAt.Unix(),
+		LastSeenAt: sess.LastSeenAt.Unix(),
+		ExpiresAt:  sess.ExpiresAt.Unix(),
+	}
+}
+
+func toAccessTokenResponse(t *models.PersonalAccessToken) accessTokenResponse {
+	resp := accessTokenResponse{
+		ID:        t.ID,
+		Name:      t.Name,
+		CreatedAt: t.CreatedAt.Unix(),
+		ExpiresAt: t.ExpiresAt.Unix(),
+	}
+	if t.LastUsedAt != nil {
+		lastUsedAt := t.LastUsedAt.Unix()
+		resp.LastUsedAt = &lastUsedAt
+	}
+	return resp
+}
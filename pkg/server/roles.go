@@ -0,0 +1,284 @@
+package server
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/dspo/go-homework/pkg/models"
+	"github.com/dspo/go-homework/pkg/policy"
+)
+
+// listRoles 返回全部 Roles 及其权限，该资源列表不分页。
+func (s *Server) listRoles(c *gin.Context) error {
+	var roles []models.Role
+	if err := preloadPermissions(s.db.WithContext(c)).Order("id").Find(&roles).Error; err != nil {
+		return err
+	}
+	c.JSON(http.StatusOK, newListResponse(int64(len(roles)), roles, toRoleResponse))
+	return nil
+}
+
+// listPermissions 返回可以授予 Custom Role 的全部权限。
+func (s *Server) listPermissions(c *gin.Context) error {
+	c.JSON(http.StatusOK, newListResponse(int64(len(policy.Permissions)), policy.Permissions, toPermissionResponse))
+	return nil
+}
+
+type createRoleRequest struct {
+	Name        string   `json:"name"`
+	Desc        string   `json:"desc"`
+	Permissions []string `json:"permissions"`
+}
+
+func (s *Server) createRole(c *gin.Context) error {
+	me := currentUser(c)
+	var req createRoleRequest
+	if err := bindJSON(c, &req); err != nil {
+		return err
+	}
+	if err := validateName("role", req.Name); err != nil {
+		return err
+	}
+	permissions, err := normalizePermissions(req.Permissions)
+	if err != nil {
+		return err
+	}
+
+	role := &models.Role{Name: req.Name, Type: models.RoleTypeCustom, Description: req.Desc}
+	err = s.db.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		taken, err := exists(tx, &models.Role{}, "name = ?", req.Name)
+		if err != nil {
+			return err
+		}
+		if taken {
+			return conflict("role name %q is already in use", req.Name)
+		}
+		if err := tx.Create(role).Error; err != nil {
+			return err
+		}
+		if err := setRolePermissions(tx, role.ID, permissions); err != nil {
+			return err
+		}
+		role, err = loadRole(tx, role.ID)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	s.audit(c, me, true, "创建了%s，权限: %s", describeRole(role), describePermissions(role))
+	c.JSON(http.StatusOK, toRoleResponse(role))
+	return nil
+}
+
+type updateRoleRequest struct {
+	Name        *string   `json:"name"`
+	Desc        *string   `json:"desc"`
+	Permissions *[]string `json:"permissions"`
+}
+
+// updateRole 修改 Custom Role 的名称、描述或权限，未提供的字段保持不变。System Role 不可修改。
+func (s *Server) updateRole(c *gin.Context) error {
+	me := currentUser(c)
+	roleID, err := pathID(c, "role_id")
+	if err != nil {
+		return err
+	}
+	var req updateRoleRequest
+	if err := bindJSON(c, &req); err != nil {
+		return err
+	}
+	if req.Name != nil {
+		if err := validateName("role", *req.Name); err != nil {
+			return err
+		}
+	}
+	var permissions []string
+	if req.Permissions != nil {
+		if permissions, err = normalizePermissions(*req.Permissions); err != nil {
+			return err
+		}
+	}
+
+	var role *models.Role
+	err = s.db.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		if role, err = loadRole(tx, roleID); err != nil {
+			return err
+		}
+		if role.Type == models.RoleTypeSystem {
+			return badRequest("system role %q can not be modified", role.Name)
+		}
+		updates := map[string]any{}
+		if req.Name != nil {
+			taken, err := exists(tx, &models.Role{}, "name = ? AND id <> ?", *req.Name, role.ID)
+			if err != nil {
+				return err
+			}
+			if taken {
+				return conflict("role name %q is already in use", *req.Name)
+			}
+			updates["name"] = *req.Name
+		}
+		if req.Desc != nil {
+			updates["description"] = *req.Desc
+		}
+		if len(updates) > 0 {
+			if err := tx.Model(&models.Role{ID: role.ID}).Updates(updates).Error; err != nil {
+				return err
+			}
+		}
+		if req.Permissions != nil {
+			if err := checkScopedRolePermissions(tx, role, permissions); err != nil {
+				return err
+			}
+			if err := setRolePermissions(tx, role.ID, permissions); err != nil {
+				return err
+			}
+		}
+		role, err = loadRole(tx, role.ID)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	s.audit(c, me, true, "修改了%s，权限: %s", describeRole(role), describePermissions(role))
+	c.JSON(http.StatusOK, toRoleResponse(role))
+	return nil
+}
+
+// deleteRole 删除自定义 Role 并解除其与 User 的绑定，System Role 不可删除。
+func (s *Server) deleteRole(c *gin.Context) error {
+	me := currentUser(c)
+	roleID, err := pathID(c, "role_id")
+	if err != nil {
+		return err
+	}
+
+	var role *models.Role
+	err = s.db.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		if role, err = loadRole(tx, roleID); err != nil {
+			return err
+		}
+		if role.Type == models.RoleTypeSystem {
+			return badRequest("system role %q can not be deleted", role.Name)
+		}
+		if err := tx.Where("role_id = ?", role.ID).Delete(&models.UserRole{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("role_id = ?", role.ID).Delete(&models.RolePermission{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("role_id = ?", role.ID).Delete(&models.TeamRoleBinding{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(role).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	s.audit(c, me, true, "删除了%s", describeRole(role))
+	c.Status(http.StatusOK)
+	return nil
+}
+
+// bindTeamRole 幂等地在 team 内为 user 绑定 role。
+func bindTeamRole(tx *gorm.DB, teamID, userID, roleID uint) error {
+	bound, err := exists(tx, &models.TeamRoleBinding{}, "team_id = ? AND user_id = ? AND role_id = ?", teamID, userID, roleID)
+	if err != nil || bound {
+		return err
+	}
+	return tx.Create(&models.TeamRoleBinding{TeamID: teamID, UserID: userID, RoleID: roleID}).Error
+}
+
+// globalPermission 返回 permissions 中第一个不是 Team 内权限的，没有时返回空字符串。
+func globalPermission(permissions []string) string {
+	for _, name := range permissions {
+		if info, ok := policy.LookupPermission(name); !ok || !info.TeamScoped {
+			return name
+		}
+	}
+	return ""
+}
+
+// rolePermissions 返回 Role 拥有的权限名称。
+func rolePermissions(r *models.Role) []string {
+	names := make([]string, 0, len(r.Permissions))
+	for _, p := range r.Permissions {
+		names = append(names, p.Permission)
+	}
+	return names
+}
+
+// checkScopedRolePermissions 要求限定在 Team 内绑定的 Role 只拥有 Team 内的权限。
+// 限定在 Team 内的绑定不授予其他权限，允许这样的绑定会让人误以为用户在该 Team 内拥有这些权限。
+func checkScopedRolePermissions(tx *gorm.DB, role *models.Role, permissions []string) error {
+	p := globalPermission(permissions)
+	if p == "" {
+		return nil
+	}
+	scoped, err := exists(tx, &models.TeamRoleBinding{}, "role_id = ?", role.ID)
+	if err != nil || !scoped {
+		return err
+	}
+	return badRequest("role %q is bound within teams and can only have team scoped permissions, %s applies globally", role.Name, p)
+}
+
+// filterByRoles 按 role_name 参数筛选用户，多个 role_name 为“或”的关系。全局绑定总是满足筛选，
+// 限定在 teamIDs 中某个 Team 内的绑定同样满足。
+func filterByRoles(c *gin.Context, tx *gorm.DB, query *gorm.DB, teamIDs []uint) *gorm.DB {
+	roleNames := c.QueryArray("role_name")
+	if len(roleNames) == 0 {
+		return query
+	}
+	roleIDs := tx.Model(&models.Role{}).Select("id").Where("name IN ?", roleNames)
+	global := tx.Model(&models.UserRole{}).Select("user_id").Where("role_id IN (?)", roleIDs)
+	if len(teamIDs) == 0 {
+		return query.Where("users.id IN (?)", global)
+	}
+	scoped := tx.Model(&models.TeamRoleBinding{}).Select("user_id").Where("role_id IN (?) AND team_id IN ?", roleIDs, teamIDs)
+	return query.Where("(users.id IN (?) OR users.id IN (?))", global, scoped)
+}
+
+// normalizePermissions 校验 names 均为可授予的权限，返回去重并排序后的结果。
+func normalizePermissions(names []string) ([]string, error) {
+	for _, name := range names {
+		if !policy.ValidPermission(name) {
+			return nil, badRequest("unknown permission %q", name)
+		}
+	}
+	names = slices.Clone(names)
+	slices.Sort(names)
+	return slices.Compact(names), nil
+}
+
+// setRolePermissions 将 Role 的权限替换为 permissions。
+func setRolePermissions(tx *gorm.DB, roleID uint, permissions []string) error {
+	if err := tx.Where("role_id = ?", roleID).Delete(&models.RolePermission{}).Error; err != nil {
+		return err
+	}
+	for _, permission := range permissions {
+		if err := tx.Create(&models.RolePermission{RoleID: roleID, Permission: permission}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// preloadPermissions 按名称顺序预加载 Roles 的权限。
+func preloadPermissions(tx *gorm.DB) *gorm.DB {
+	return tx.Preload("Permissions", func(tx *gorm.DB) *gorm.DB { return tx.Order("permission") })
+}
+
+// describePermissions 返回 Role 的权限列表，用于审计日志。
+func describePermissions(r *models.Role) string {
+	if len(r.Permissions) == 0 {
+		return "无"
+	}
+	return strings.Join(rolePermissions(r), ", ")
+}
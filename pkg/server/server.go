@@ -0,0 +1,170 @@
+// Package server 实现 openapi.yaml 约定的 REST API。
+package server
+
+import (
+	"net/http"
+	"path"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"github.com/dspo/go-homework/pkg/config"
+	"github.com/dspo/go-homework/pkg/metrics"
+	"github.com/dspo/go-homework/pkg/password"
+	"github.com/dspo/go-homework/pkg/session"
+)
+
+// Server 持有 API 处理所需的依赖。
+type Server struct {
+	db        *gorm.DB
+	sessions  session.Store
+	metrics   *metrics.Metrics
+	readiness []readinessCheck
+	// loginThrottle 为 nil 时登录不限流。
+	loginThrottle  *loginThrottle
+	trustedProxies []string
+	passwordPolicy *password.Policy
+	appName        string
+	cookie         config.SessionCookie
+	// sso 为 nil 时未启用单点登录。
+	sso *singleSignOn
+	// serving 返回 false 时 /api 下的接口返回 503，为 nil 时总是提供服务。
+	serving func() bool
+	// operations 将 "METHOD /path" 形式的路由映射到 openapi.yaml 中的 operationId。
+	operations map[string]string
+}
+
+// New 创建一个使用 db 作为存储、sessions 保存登录会话的 Server，/readyz 默认检查数据库连通性。
+func New(db *gorm.DB, sessions session.Store, opts ...Option) *Server {
+	s := &Server{
+		db:         db,
+		sessions:   sessions,
+		metrics:    metrics.New(),
+		operations: make(map[string]string),
+		// 未通过 WithPasswordPolicy 设置时使用默认策略。
+		passwordPolicy: password.NewPolicy(config.DefaultPasswordPolicy),
+		appName:        config.DefaultAppName,
+		cookie:         config.SessionCookie{SameSite: config.DefaultSessionCookieSameSite},
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.AddReadinessCheck("database", s.pingDatabase)
+	s.registerMetrics()
+	return s
+}
+
+// Handler 返回注册了全部路由的 http.Handler。
+func (s *Server) Handler() http.Handler {
+	gin.SetMode(gin.ReleaseMode)
+	r := gin.New()
+	if err := r.SetTrustedProxies(s.trustedProxies); err != nil {
+		zap.L().Error("invalid trusted proxies", zap.Strings("trusted_proxies", s.trustedProxies), zap.Error(err))
+	}
+	r.Use(assignRequestID, s.accessLog, gin.Recovery(), s.observe)
+
+	root := &r.RouterGroup
+	s.handle(root, http.MethodGet, "/livez", "livez", s.livez)
+	s.handle(root, http.MethodGet, "/readyz", "readyz", s.readyz)
+	// 按 openapi.yaml 的约定，/healthz 返回 200 表示服务已就绪，与 /readyz 一致。
+	s.handle(root, http.MethodGet, "/healthz", "healthz", s.readyz)
+	s.handle(root, http.MethodGet, "/metrics", "metrics", gin.WrapH(s.metrics.Handler()))
+	// 健康检查与指标之外的接口在服务就绪前返回 503，见 WithServing。
+	public := r.Group("/api", s.requireServing)
+	s.handle(public, http.MethodPost, "/login", "login", wrap(s.login))
+	s.handle(public, http.MethodPost, "/login/2fa", "completeLogin", wrap(s.completeLogin))
+	s.handle(public, http.MethodGet, "/login/oidc", "startOIDCLogin", wrap(s.startOIDCLogin))
+	s.handle(public, http.MethodGet, "/login/oidc/callback", "completeOIDCLogin", wrap(s.completeOIDCLogin))
+
+	api := public.Group("", s.authenticate, s.verifyCSRF, s.authorize)
+	s.handle(api, http.MethodPost, "/logout", "logout", wrap(s.logout))
+	s.handle(api, http.MethodPut, "/me/password", "updateMyPassword", wrap(s.updateMyPassword))
+
+	// 以下接口要求用户已完成首次登录的密码修改。
+	api.Use(s.requirePasswordChanged)
+
+	s.handle(api, http.MethodGet, "/me/2fa", "getMyTwoFactor", wrap(s.getMyTwoFactor))
+	s.handle(api, http.MethodPost, "/me/2fa", "enrollMyTwoFactor", wrap(s.enrollMyTwoFactor))
+	s.handle(api, http.MethodDelete, "/me/2fa", "disableMyTwoFactor", wrap(s.disableMyTwoFactor))
+	s.handle(api, http.MethodPost, "/me/2fa/activate", "activateMyTwoFactor", wrap(s.activateMyTwoFactor))
+	s.handle(api, http.MethodPost, "/me/2fa/recovery-codes", "regenerateMyRecoveryCodes", wrap(s.regenerateMyRecoveryCodes))
+
+	// 以下接口要求 admin 已启用两步验证。
+	api.Use(s.requireTwoFactor)
+
+	s.handle(api, http.MethodGet, "/me", "me", wrap(s.me))
+	s.handle(api, http.MethodPut, "/me", "updateMe", wrap(s.updateMe))
+	s.handle(api, http.MethodGet, "/me/teams", "getMyTeams", wrap(s.getMyTeams))
+	s.handle(api, http.MethodDelete, "/me/teams/:team_id", "exitTeam", wrap(s.exitTeam))
+	s.handle(api, http.MethodGet, "/me/projects", "getMyProjects", wrap(s.getMyProjects))
+	s.handle(api, http.MethodDelete, "/me/projects/:project_id", "exitProject", wrap(s.exitProject))
+	s.handle(api, http.MethodGet, "/me/sessions", "listMySessions", wrap(s.listMySessions))
+	s.handle(api, http.MethodDelete, "/me/sessions", "deleteMySessions", wrap(s.deleteMySessions))
+	s.handle(api, http.MethodGet, "/me/sessions/:session_id", "getMySession", wrap(s.getMySession))
+	s.handle(api, http.MethodDelete, "/me/sessions/:session_id", "deleteMySession", wrap(s.deleteMySession))
+	s.handle(api, http.MethodGet, "/me/tokens", "listMyAccessTokens", wrap(s.listMyAccessTokens))
+	s.handle(api, http.MethodPost, "/me/tokens", "createMyAccessToken", wrap(s.createMyAccessToken))
+	s.handle(api, http.MethodDelete, "/me/tokens/:token_id", "deleteMyAccessToken", wrap(s.deleteMyAccessToken))
+	s.handle(api, http.MethodGet, "/me/permissions", "getMyPermissions", wrap(s.getMyPermissions))
+	s.handle(api, http.MethodPost, "/me/identities/oidc", "linkMyOIDCIdentity", wrap(s.linkMyOIDCIdentity))
+
+	s.handle(api, http.MethodPost, "/users", "createUser", wrap(s.createUser))
+	s.handle(api, http.MethodGet, "/users", "listUsers", wrap(s.listUsers))
+	s.handle(api, http.MethodGet, "/users/:user_id", "getUser", wrap(s.getUser))
+	s.handle(api, http.MethodDelete, "/users/:user_id", "deleteUser", wrap(s.deleteUser))
+	s.handle(api, http.MethodGet, "/users/:user_id/teams", "getUserTeams", wrap(s.getUserTeams))
+	s.handle(api, http.MethodGet, "/users/:user_id/projects", "getUserProjects", wrap(s.getUserProjects))
+	s.handle(api, http.MethodGet, "/users/:user_id/roles", "listUserRoles", wrap(s.listUserRoles))
+	s.handle(api, http.MethodPost, "/users/:user_id/roles", "addUserRole", wrap(s.addUserRole))
+	s.handle(api, http.MethodDelete, "/users/:user_id/roles/:role_id", "removeUserRole", wrap(s.removeUserRole))
+	s.handle(api, http.MethodDelete, "/users/:user_id/sessions", "deleteUserSessions", wrap(s.deleteUserSessions))
+	s.handle(api, http.MethodDelete, "/users/:user_id/lockout", "unlockUser", wrap(s.unlockUser))
+	s.handle(api, http.MethodDelete, "/ips/:ip/lockout", "unlockIP", wrap(s.unlockIP))
+	s.handle(api, http.MethodPost, "/users/:user_id/password-reset", "resetUserPassword", wrap(s.resetUserPassword))
+	s.handle(api, http.MethodDelete, "/users/:user_id/2fa", "resetUserTwoFactor", wrap(s.resetUserTwoFactor))
+	s.handle(api, http.MethodGet, "/users/:user_id/visibility", "getUserVisibility", wrap(s.getUserVisibility))
+	s.handle(api, http.MethodGet, "/visibility", "getVisibilityReport", wrap(s.getVisibilityReport))
+
+	s.handle(api, http.MethodGet, "/teams", "listTeams", wrap(s.listTeams))
+	s.handle(api, http.MethodPost, "/teams", "createTeam", wrap(s.createTeam))
+	s.handle(api, http.MethodGet, "/teams/:team_id", "getTeam", wrap(s.getTeam))
+	s.handle(api, http.MethodPut, "/teams/:team_id", "updateTeam", wrap(s.updateTeam))
+	s.handle(api, http.MethodPatch, "/teams/:team_id", "updateTeamLeader", wrap(s.updateTeamLeader))
+	s.handle(api, http.MethodDelete, "/teams/:team_id", "deleteTeam", wrap(s.deleteTeam))
+	s.handle(api, http.MethodGet, "/teams/:team_id/users", "getTeamUsers", wrap(s.getTeamUsers))
+	s.handle(api, http.MethodPost, "/teams/:team_id/users", "addTeamUser", wrap(s.addTeamUser))
+	s.handle(api, http.MethodDelete, "/teams/:team_id/users/:user_id", "removeTeamUser", wrap(s.removeTeamUser))
+	s.handle(api, http.MethodGet, "/teams/:team_id/roles", "listTeamRoles", wrap(s.listTeamRoles))
+	s.handle(api, http.MethodGet, "/teams/:team_id/projects", "getTeamProjects", wrap(s.getTeamProjects))
+	s.handle(api, http.MethodPost, "/teams/:team_id/projects", "createTeamProject", wrap(s.createTeamProject))
+
+	s.handle(api, http.MethodGet, "/projects/:project_id", "getProject", wrap(s.getProject))
+	s.handle(api, http.MethodPut, "/projects/:project_id", "updateProject", wrap(s.updateProject))
+	s.handle(api, http.MethodPatch, "/projects/:project_id", "patchProject", wrap(s.patchProject))
+	s.handle(api, http.MethodDelete, "/projects/:project_id", "deleteProject", wrap(s.deleteProject))
+	s.handle(api, http.MethodGet, "/projects/:project_id/users", "getProjectUsers", wrap(s.getProjectUsers))
+	s.handle(api, http.MethodPost, "/projects/:project_id/users", "addProjectUser", wrap(s.addProjectUser))
+	s.handle(api, http.MethodDelete, "/projects/:project_id/users/:user_id", "removeProjectUser", wrap(s.removeProjectUser))
+
+	s.handle(api, http.MethodGet, "/roles", "listRoles", wrap(s.listRoles))
+	s.handle(api, http.MethodPost, "/roles", "createRole", wrap(s.createRole))
+	s.handle(api, http.MethodPut, "/roles/:role_id", "updateRole", wrap(s.updateRole))
+	s.handle(api, http.MethodDelete, "/roles/:role_id", "deleteRole", wrap(s.deleteRole))
+	s.handle(api, http.MethodGet, "/permissions", "listPermissions", wrap(s.listPermissions))
+
+	s.handle(api, http.MethodGet, "/audits", "audits", wrap(s.audits))
+
+	return r
+}
+
+// handle 在 g 上注册路由，并记录其对应的 operationId。
+func (s *Server) handle(g *gin.RouterGroup, method, relativePath, operationID string, handlers ...gin.HandlerFunc) {
+	g.Handle(method, relativePath, handlers...)
+	s.operations[method+" "+path.Join(g.BasePath(), relativePath)] = operationID
+}
+
+// operationID 返回请求所匹配路由的 operationId，未匹配任何路由时返回空字符串。
+func (s *Server) operationID(c *gin.Context) string {
+	return s.operations[c.Request.Method+" "+c.FullPath()]
+}
@@ -0,0 +1,91 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"gorm.io/gorm"
+
+	"github.com/dspo/go-homework/pkg/bootstrap"
+	"github.com/dspo/go-homework/pkg/config"
+	"github.com/dspo/go-homework/pkg/database"
+	"github.com/dspo/go-homework/pkg/migrate"
+	"github.com/dspo/go-homework/pkg/session"
+)
+
+// newTestServer 以临时的 SQLite 数据库启动完成迁移与初始化的服务，opts 在默认选项之后生效。
+func newTestServer(t *testing.T, opts ...Option) (*httptest.Server, *gorm.DB) {
+	t.Helper()
+	db, err := database.Open(config.Database{Name: config.DatabaseSQLite, Path: filepath.Join(t.TempDir(), "app.db")}, nil)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	m, err := migrate.New(db)
+	if err != nil {
+		t.Fatalf("failed to load migrations: %v", err)
+	}
+	if _, err := m.Up(context.Background()); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+	if _, err := bootstrap.Run(context.Background(), db); err != nil {
+		t.Fatalf("failed to bootstrap: %v", err)
+	}
+	sessions := session.NewDBStore(db, session.Options{
+		AbsoluteTimeout: config.DefaultSessionAbsoluteTimeout,
+		IdleTimeout:     config.DefaultSessionIdleTimeout,
+	})
+	opts = append([]Option{WithLoginThrottle(config.DefaultLoginThrottle)}, opts...)
+	ts := httptest.NewServer(New(db, sessions, opts...).Handler())
+	t.Cleanup(ts.Close)
+	return ts, db
+}
+
+// withCSRFToken 像页面脚本一样，将 jar 中当前会话的 CSRF token 放入请求头。
+func withCSRFToken(jar http.CookieJar, req *http.Request) {
+	for _, cookie := range jar.Cookies(req.URL) {
+		if cookie.Name == csrfCookieName {
+			req.Header.Set(csrfHeader, cookie.Value)
+		}
+	}
+}
+
+// 迁移与初始化完成前只提供健康检查，/api 下的接口返回 503。
+func TestServingGate(t *testing.T) {
+	var serving atomic.Bool
+	ts, _ := newTestServer(t, WithServing(serving.Load))
+	status := func(method, path string) int {
+		req, err := http.NewRequest(method, ts.URL+path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	for _, tt := range []struct {
+		method, path  string
+		before, after int
+	}{
+		{http.MethodGet, "/livez", http.StatusOK, http.StatusOK},
+		{http.MethodGet, "/metrics", http.StatusOK, http.StatusOK},
+		{http.MethodPost, "/api/login", http.StatusServiceUnavailable, http.StatusBadRequest},
+		{http.MethodGet, "/api/me", http.StatusServiceUnavailable, http.StatusUnauthorized},
+	} {
+		if got := status(tt.method, tt.path); got != tt.before {
+			t.Errorf("%s %s before serving = %d, want %d", tt.method, tt.path, got, tt.before)
+		}
+		serving.Store(true)
+		if got := status(tt.method, tt.path); got != tt.after {
+			t.Errorf("%s %s after serving = %d, want %d", tt.method, tt.path, got, tt.after)
+		}
+		serving.Store(false)
+	}
+}
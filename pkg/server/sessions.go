@@ -0,0 +1,107 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/dspo/go-homework/pkg/models"
+	"github.com/dspo/go-homework/pkg/session"
+)
+
+func (s *Server) listMySessions(c *gin.Context) error {
+	list, err := s.sessions.List(c, currentUser(c).ID)
+	if err != nil {
+		return err
+	}
+	current := currentSessionID(c)
+	items := make([]sessionResponse, 0, len(list))
+	for _, sess := range list {
+		items = append(items, toSessionResponse(sess, current))
+	}
+	c.JSON(http.StatusOK, listResponse[sessionResponse]{Total: int64(len(items)), List: items})
+	return nil
+}
+
+func (s *Server) getMySession(c *gin.Context) error {
+	id := c.Param("session_id")
+	list, err := s.sessions.List(c, currentUser(c).ID)
+	if err != nil {
+		return err
+	}
+	for _, sess := range list {
+		if sess.ID == id {
+			c.JSON(http.StatusOK, toSessionResponse(sess, currentSessionID(c)))
+			return nil
+		}
+	}
+	return notFound("session %s not found", id)
+}
+
+// deleteMySession 撤销当前用户的一个会话，撤销的是当前会话时同时清除 Cookie。
+func (s *Server) deleteMySession(c *gin.Context) error {
+	me := currentUser(c)
+	id := c.Param("session_id")
+	if err := s.sessions.DeleteByID(c, me.ID, id); err != nil {
+		if errors.Is(err, session.ErrNotFound) {
+			return notFound("session %s not found", id)
+		}
+		return err
+	}
+	if id == currentSessionID(c) {
+		s.clearSessionCookie(c)
+	}
+	s.audit(c, me, true, "撤销了%s", describeSession(id))
+	c.Status(http.StatusOK)
+	return nil
+}
+
+// deleteMySessions 撤销当前用户的全部会话（含当前会话），即在所有设备上登出。
+func (s *Server) deleteMySessions(c *gin.Context) error {
+	me := currentUser(c)
+	if err := s.sessions.DeleteByUser(c, me.ID); err != nil {
+		return err
+	}
+	s.clearSessionCookie(c)
+	s.audit(c, me, true, "登出了全部会话")
+	c.Status(http.StatusOK)
+	return nil
+}
+
+func (s *Server) deleteUserSessions(c *gin.Context) error {
+	me := currentUser(c)
+	userID, err := pathID(c, "user_id")
+	if err != nil {
+		return err
+	}
+	target, err := loadUser(s.db.WithContext(c), userID)
+	if err != nil {
+		return err
+	}
+	if err := s.revokeCredentials(c, target.ID); err != nil {
+		return err
+	}
+	if target.ID == me.ID {
+		s.clearSessionCookie(c)
+	}
+	s.audit(c, me, true, "撤销了用户 %s 的全部会话与个人访问令牌", describeUser(target))
+	c.Status(http.StatusOK)
+	return nil
+}
+
+// revokeCredentials 撤销用户的全部会话与个人访问令牌，用于修改或重置密码、admin 撤销用户会话等凭据可能已泄露的场景。
+func (s *Server) revokeCredentials(c *gin.Context, userID uint) error {
+	if err := s.db.WithContext(c).Where("user_id = ?", userID).Delete(&models.PersonalAccessToken{}).Error; err != nil {
+		return err
+	}
+	return s.sessions.DeleteByUser(c, userID)
+}
+
+// currentSessionID 返回当前会话的 ID，使用个人访问令牌认证时返回空字符串。
+func currentSessionID(c *gin.Context) string {
+	if sess := currentSession(c); sess != nil {
+		return sess.ID
+	}
+	return ""
+}
@@ -0,0 +1,296 @@
+package server
+
+import (
+	"context"
+	"crypto/subtle"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"github.com/dspo/go-homework/pkg/models"
+	"github.com/dspo/go-homework/pkg/sso"
+)
+
+const (
+	// oidcLoginTTL 是跳转到身份提供方后完成登录的时限。
+	oidcLoginTTL = 10 * time.Minute
+	// oidcStateCookieName 保存 state，回调时核对，确保回调来自发起登录的同一浏览器。
+	oidcStateCookieName = "oidc_state"
+	oidcStateCookiePath = "/api/login/oidc"
+)
+
+// singleSignOn 是 OIDC 单点登录的设置。
+type singleSignOn struct {
+	provider      *sso.Provider
+	autoProvision bool
+}
+
+// startOIDCLogin 将用户重定向到身份提供方登录。
+func (s *Server) startOIDCLogin(c *gin.Context) error {
+	return s.redirectToIdP(c, nil)
+}
+
+// linkMyOIDCIdentity 将当前用户重定向到身份提供方，回调时把登录的身份关联到当前用户。
+// 只能在登录会话中发起，以免泄露的个人访问令牌被用来关联他人的身份、长期控制账号。
+func (s *Server) linkMyOIDCIdentity(c *gin.Context) error {
+	if currentSession(c) == nil {
+		return forbidden("single sign-on identities can only be linked in a login session")
+	}
+	me := currentUser(c)
+	return s.redirectToIdP(c, &me.ID)
+}
+
+// redirectToIdP 发起一次单点登录并重定向到身份提供方。userID 非空时，回调将身份关联到该用户。
+func (s *Server) redirectToIdP(c *gin.Context, userID *uint) error {
+	if s.sso == nil {
+		return notFound("single sign-on is not enabled")
+	}
+	login, err := sso.NewLogin()
+	if err != nil {
+		return err
+	}
+	authURL, err := s.sso.provider.AuthCodeURL(c, login)
+	if err != nil {
+		zap.L().Error("failed to start single sign-on", zap.String("request_id", requestID(c)), zap.Error(err))
+		return newError(http.StatusBadGateway, "身份提供方暂时不可用")
+	}
+	if err := s.db.WithContext(c).Create(&models.OIDCLogin{
+		ID:        hashAccessToken(login.State),
+		Nonce:     login.Nonce,
+		Verifier:  login.Verifier,
+		UserID:    userID,
+		ExpiresAt: time.Now().UTC().Truncate(time.Millisecond).Add(oidcLoginTTL),
+	}).Error; err != nil {
+		return err
+	}
+	c.SetSameSite(http.SameSiteLaxMode)
+	// IdP 的回调是跨站的顶级导航，state Cookie 须为 Lax 才会随之发送，不受 session.cookie.same_site 影响。
+	c.SetCookie(oidcStateCookieName, login.State, int(oidcLoginTTL.Seconds()), oidcStateCookiePath, "", s.cookie.Secure, true)
+	c.Redirect(http.StatusFound, authURL)
+	return nil
+}
+
+// completeOIDCLogin 处理身份提供方的回调：核对 state，以授权码换取并校验 ID Token，将身份映射到用户后登录。
+func (s *Server) completeOIDCLogin(c *gin.Context) error {
+	if s.sso == nil {
+		return notFound("single sign-on is not enabled")
+	}
+	state := c.Query("state")
+	cookie, _ := c.Cookie(oidcStateCookieName)
+	c.SetCookie(oidcStateCookieName, "", -1, oidcStateCookiePath, "", s.cookie.Secure, true)
+	if state == "" || subtle.ConstantTimeCompare([]byte(state), []byte(cookie)) != 1 {
+		return badRequest("state does not match the login started by this browser")
+	}
+
+	// 单点登录与密码登录共用客户端 IP 的失败计数，身份确定后再检查账号的锁定。
+	lockout, err := s.loginLockout(c, "")
+	if err != nil {
+		return err
+	}
+	if lockout > 0 {
+		return tooManyLoginAttempts(c, lockout)
+	}
+
+	// 无论成功与否，一次登录只能回调一次。
+	db := s.db.WithContext(c)
+	var pending models.OIDCLogin
+	result := db.Where("id = ?", hashAccessToken(state)).Limit(1).Find(&pending)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return newError(http.StatusUnauthorized, "登录已过期，请重新登录")
+	}
+	if err := db.Delete(&pending).Error; err != nil {
+		return err
+	}
+	if !time.Now().Before(pending.ExpiresAt) {
+		return newError(http.StatusUnauthorized, "登录已过期，请重新登录")
+	}
+	if reason := c.Query("error"); reason != "" {
+		return newError(http.StatusUnauthorized, "身份提供方拒绝了登录: %s", reason)
+	}
+
+	identity, err := s.sso.provider.Exchange(c, &sso.Login{State: state, Nonce: pending.Nonce, Verifier: pending.Verifier}, c.Query("code"))
+	if err != nil {
+		zap.L().Warn("single sign-on failed", zap.String("request_id", requestID(c)), zap.Error(err))
+		s.metrics.ObserveLogin(false)
+		if err := s.recordLoginFailure(c, "", nil); err != nil {
+			return err
+		}
+		return newError(http.StatusUnauthorized, "单点登录失败")
+	}
+	if pending.UserID != nil {
+		return s.linkIdentity(c, *pending.UserID, identity)
+	}
+
+	var user *models.User
+	var provisioned bool
+	err = db.Transaction(func(tx *gorm.DB) (err error) {
+		user, provisioned, err = s.resolveIdentity(tx, identity)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		s.metrics.ObserveLogin(false)
+		if err := s.recordLoginFailure(c, "", nil); err != nil {
+			return err
+		}
+		return forbidden("该身份尚未开通账号，请联系 admin")
+	}
+	if provisioned {
+		s.audit(c, user, true, "通过单点登录自动创建了用户 %s", describeUser(user))
+	}
+	accountKey := loginAccountKey(nil, user)
+	if lockout, err := s.loginLockout(c, accountKey); err != nil {
+		return err
+	} else if lockout > 0 {
+		return tooManyLoginAttempts(c, lockout)
+	}
+
+	cred, err := twoFactorCredential(db, user.ID)
+	if err != nil {
+		return err
+	}
+	if cred.Enabled() {
+		return s.issueLoginChallenge(c, user)
+	}
+	return s.finishLogin(c, user, accountKey, "使用单点登录登录")
+}
+
+// resolveIdentity 返回身份对应的用户，依次尝试：已关联该身份的用户；邮箱相同的用户（要求 IdP 已验证邮箱），并关联该身份；
+// 启用了自动创建时，创建新用户并关联该身份。均不满足时返回 nil。
+//
+// 邮箱可以由用户自行修改且不经验证，按邮箱关联只适用于 linkableByEmail 的用户，其他用户须登录后通过
+// linkMyOIDCIdentity 关联，否则在 IdP 中使用相同邮箱即可登录他人的账号。
+func (s *Server) resolveIdentity(tx *gorm.DB, identity *sso.Identity) (user *models.User, provisioned bool, err error) {
+	var link models.UserIdentity
+	result := tx.Where("issuer = ? AND subject = ?", identity.Issuer, identity.Subject).Limit(1).Find(&link)
+	if result.Error != nil {
+		return nil, false, result.Error
+	}
+	if result.RowsAffected > 0 {
+		user, err = loadUser(tx, link.UserID)
+		return user, false, err
+	}
+
+	if identity.Email != "" && identity.EmailVerified {
+		var found models.User
+		result := tx.Where("email = ?", identity.Email).Limit(1).Find(&found)
+		if result.Error != nil {
+			return nil, false, result.Error
+		}
+		if result.RowsAffected > 0 {
+			if user, err = loadUser(tx, found.ID); err != nil {
+				return nil, false, err
+			}
+			linkable, err := linkableByEmail(tx, user, identity.Issuer)
+			if err != nil {
+				return nil, false, err
+			}
+			if !linkable {
+				return nil, false, forbidden("该邮箱已属于本地账号，请使用该账号登录后关联单点登录身份")
+			}
+		}
+	}
+	if user == nil {
+		if !s.sso.autoProvision {
+			return nil, false, nil
+		}
+		if user, err = provisionUser(tx, identity); err != nil {
+			return nil, false, err
+		}
+		provisioned = true
+	}
+
+	link = models.UserIdentity{UserID: user.ID, Issuer: identity.Issuer, Subject: identity.Subject}
+	if err := tx.Create(&link).Error; err != nil {
+		return nil, false, err
+	}
+	user, err = loadUser(tx, user.ID)
+	return user, provisioned, err
+}
+
+// linkableByEmail 判断单点登录的身份能否仅凭相同的邮箱关联到 user：admin、有本地密码的用户，
+// 以及已关联了该 IdP 其他身份的用户都不能，以免邮箱被他人抢先占用或冒用。
+func linkableByEmail(tx *gorm.DB, user *models.User, issuer string) (bool, error) {
+	if user.IsAdmin() || user.HasPassword() {
+		return false, nil
+	}
+	linked, err := exists(tx, &models.UserIdentity{}, "user_id = ? AND issuer = ?", user.ID, issuer)
+	return !linked, err
+}
+
+// linkIdentity 完成 linkMyOIDCIdentity 发起的关联：将身份关联到发起关联的用户，不创建新的会话。
+// 身份已关联到其他用户，或用户已关联了该 IdP 的其他身份时返回 409。
+func (s *Server) linkIdentity(c *gin.Context, userID uint, identity *sso.Identity) error {
+	var user *models.User
+	err := s.db.WithContext(c).Transaction(func(tx *gorm.DB) (err error) {
+		if user, err = loadUser(tx, userID); err != nil {
+			return err
+		}
+		var link models.UserIdentity
+		result := tx.Where("issuer = ? AND subject = ?", identity.Issuer, identity.Subject).Limit(1).Find(&link)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected > 0 {
+			if link.UserID != userID {
+				return conflict("the identity is already linked to another user")
+			}
+			return nil
+		}
+		linked, err := exists(tx, &models.UserIdentity{}, "user_id = ? AND issuer = ?", userID, identity.Issuer)
+		if err != nil {
+			return err
+		}
+		if linked {
+			return conflict("another identity of the provider is already linked to the user")
+		}
+		return tx.Create(&models.UserIdentity{UserID: userID, Issuer: identity.Issuer, Subject: identity.Subject}).Error
+	})
+	if err != nil {
+		return err
+	}
+	s.audit(c, user, true, "关联了单点登录身份 %s", identity.Subject)
+	c.JSON(http.StatusOK, toUserResponse(user))
+	return nil
+}
+
+// provisionUser 为单点登录的身份创建用户并绑定 normal user Role。用户没有本地密码，不受首次登录须修改密码的限制。
+func provisionUser(tx *gorm.DB, identity *sso.Identity) (*models.User, error) {
+	if !usernamePattern.MatchString(identity.Username) {
+		return nil, forbidden("身份提供方未提供合法的用户名，无法自动创建用户")
+	}
+	taken, err := exists(tx, &models.User{}, "username = ?", identity.Username)
+	if err != nil {
+		return nil, err
+	}
+	if taken {
+		return nil, conflict("username %q is already in use", identity.Username)
+	}
+	user := &models.User{Username: identity.Username, Nickname: identity.Name}
+	// 未经 IdP 验证的邮箱不予采用，避免占用他人的邮箱。
+	if identity.Email != "" && identity.EmailVerified {
+		user.Email = &identity.Email
+	}
+	if err := tx.Create(user).Error; err != nil {
+		return nil, err
+	}
+	var role models.Role
+	if err := tx.Where("name = ?", models.RoleNormalUser).First(&role).Error; err != nil {
+		return nil, err
+	}
+	return user, bindRole(tx, user.ID, role.ID)
+}
+
+// deleteExpiredOIDCLogins 清理过期未回调的单点登录，返回清理的数量。
+func (s *Server) deleteExpiredOIDCLogins(ctx context.Context) (int64, error) {
+	result := s.db.WithContext(ctx).Where("expires_at < ?", time.Now().UTC().Truncate(time.Millisecond)).Delete(&models.OIDCLogin{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,464 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/dspo/go-homework/pkg/models"
+)
+
+func (s *Server) listTeams(c *gin.Context) error {
+	p, err := parsePagination(c)
+	if err != nil {
+		return err
+	}
+
+	me := currentUser(c)
+	query := s.db.WithContext(c).Model(&models.Team{})
+	if !me.IsAdmin() {
+		query = query.Where("teams.id IN (?)", myTeamIDs(s.db, me.ID))
+	}
+	return s.renderTeams(c, query, p)
+}
+
+type createTeamRequest struct {
+	Name string `json:"name"`
+	Desc string `json:"desc"`
+}
+
+func (s *Server) createTeam(c *gin.Context) error {
+	me := currentUser(c)
+	var req createTeamRequest
+	if err := bindJSON(c, &req); err != nil {
+		return err
+	}
+	if err := validateName("team", req.Name); err != nil {
+		return err
+	}
+
+	db := s.db.WithContext(c)
+	taken, err := exists(db, &models.Team{}, "name = ?", req.Name)
+	if err != nil {
+		return err
+	}
+	if taken {
+		return conflict("team name %q is already in use", req.Name)
+	}
+	team := &models.Team{Name: req.Name, Description: req.Desc}
+	if err := db.Create(team).Error; err != nil {
+		return err
+	}
+
+	s.audit(c, me, true, "创建了%s", describeTeam(team))
+	c.JSON(http.StatusOK, toTeamResponse(team))
+	return nil
+}
+
+func (s *Server) getTeam(c *gin.Context) error {
+	db := s.db.WithContext(c)
+	team, err := loadPathTeam(c, db)
+	if err != nil {
+		return err
+	}
+	var projects []models.Project
+	if err := db.Where("team_id = ?", team.ID).Order("id").Find(&projects).Error; err != nil {
+		return err
+	}
+
+	resp := teamDetailResponse{
+		teamResponse: toTeamResponse(team),
+		Projects:     make([]teamProjectResponse, 0, len(projects)),
+	}
+	for _, p := range projects {
+		resp.Projects = append(resp.Projects, teamProjectResponse{ID: p.ID, Name: p.Name})
+	}
+	c.JSON(http.StatusOK, resp)
+	return nil
+}
+
+type updateTeamRequest struct {
+	Name *string `json:"name"`
+	Desc *string `json:"desc"`
+}
+
+func (s *Server) updateTeam(c *gin.Context) error {
+	var req updateTeamRequest
+	if err := bindJSON(c, &req); err != nil {
+		return err
+	}
+
+	db := s.db.WithContext(c)
+	team, err := loadPathTeam(c, db)
+	if err != nil {
+		return err
+	}
+	updates := map[string]any{}
+	if req.Name != nil {
+		if err := validateName("team", *req.Name); err != nil {
+			return err
+		}
+		taken, err := exists(db, &models.Team{}, "name = ? AND id <> ?", *req.Name, team.ID)
+		if err != nil {
+			return err
+		}
+		if taken {
+			return conflict("team name %q is already in use", *req.Name)
+		}
+		updates["name"] = *req.Name
+	}
+	if req.Desc != nil {
+		updates["description"] = *req.Desc
+	}
+	if len(updates) > 0 {
+		if err := db.Model(&models.Team{ID: team.ID}).Updates(updates).Error; err != nil {
+			return err
+		}
+		s.audit(c, currentUser(c), true, "修改了%s", describeTeam(team))
+	}
+
+	if team, err = loadTeam(db, team.ID); err != nil {
+		return err
+	}
+	c.JSON(http.StatusOK, toTeamResponse(team))
+	return nil
+}
+
+// leaderPatch 是 updateTeamLeader 接受的 JSON Patch 操作。
+type leaderPatch struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	Value json.RawMessage `json:"value"`
+}
+
+type leaderValue struct {
+	ID uint `json:"id"`
+}
+
+// parseLeaderPatch 解析 updateTeamLeader 的请求体，返回新 Leader 的 ID，nil 表示清空 Leader。
+func parseLeaderPatch(c *gin.Context) (*uint, error) {
+	var patches []leaderPatch
+	if err := bindJSON(c, &patches); err != nil {
+		return nil, err
+	}
+	if len(patches) != 1 {
+		return nil, badRequest("exactly one patch operation is required")
+	}
+	patch := patches[0]
+	if patch.Op != "replace" || patch.Path != "/leader" {
+		return nil, badRequest("only {\"op\": \"replace\", \"path\": \"/leader\"} is supported")
+	}
+	if len(patch.Value) == 0 || string(patch.Value) == "null" {
+		return nil, nil
+	}
+	var value leaderValue
+	dec := json.NewDecoder(strings.NewReader(string(patch.Value)))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&value); err != nil || value.ID == 0 {
+		return nil, badRequest("invalid leader value: %s", patch.Value)
+	}
+	return &value.ID, nil
+}
+
+func (s *Server) updateTeamLeader(c *gin.Context) error {
+	leaderID, err := parseLeaderPatch(c)
+	if err != nil {
+		return err
+	}
+
+	var team *models.Team
+	err = s.db.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		if team, err = loadPathTeam(c, tx); err != nil {
+			return err
+		}
+		if leaderID != nil {
+			if _, err := loadUser(tx, *leaderID); err != nil {
+				return err
+			}
+			member, err := isTeamMember(tx, team.ID, *leaderID)
+			if err != nil {
+				return err
+			}
+			if !member {
+				return badRequest("user %d is not a member of team %d", *leaderID, team.ID)
+			}
+		}
+		previous := team.LeaderID
+		if err := tx.Model(&models.Team{ID: team.ID}).Update("leader_id", leaderID).Error; err != nil {
+			return err
+		}
+		for _, userID := range []*uint{previous, leaderID} {
+			if userID == nil {
+				continue
+			}
+			if err := syncLeaderRole(tx, *userID); err != nil {
+				return err
+			}
+		}
+		team, err = loadTeam(tx, team.ID)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	if team.Leader != nil {
+		s.audit(c, currentUser(c), true, "将%s的 Leader 设为 %s", describeTeam(team), describeUser(team.Leader))
+	} else {
+		s.audit(c, currentUser(c), true, "清空了%s的 Leader", describeTeam(team))
+	}
+	c.JSON(http.StatusOK, toTeamResponse(team))
+	return nil
+}
+
+// deleteTeam 级联删除 Team 下的 Projects，并解除所有成员关联与限定在该 Team 内的 Role 绑定。
+func (s *Server) deleteTeam(c *gin.Context) error {
+	var team *models.Team
+	err := s.db.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		var err error
+		if team, err = loadPathTeam(c, tx); err != nil {
+			return err
+		}
+		projectIDs := tx.Model(&models.Project{}).Select("id").Where("team_id = ?", team.ID)
+		if err := tx.Where("project_id IN (?)", projectIDs).Delete(&models.ProjectMember{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("team_id = ?", team.ID).Delete(&models.Project{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("team_id = ?", team.ID).Delete(&models.TeamMember{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("team_id = ?", team.ID).Delete(&models.TeamRoleBinding{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(team).Error; err != nil {
+			return err
+		}
+		if team.LeaderID != nil {
+			return syncLeaderRole(tx, *team.LeaderID)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	s.audit(c, currentUser(c), true, "删除了%s", describeTeam(team))
+	c.Status(http.StatusOK)
+	return nil
+}
+
+func (s *Server) getTeamUsers(c *gin.Context) error {
+	p, err := parsePagination(c)
+	if err != nil {
+		return err
+	}
+	db := s.db.WithContext(c)
+	team, err := loadPathTeam(c, db)
+	if err != nil {
+		return err
+	}
+
+	query := db.Model(&models.User{}).Where("users.id IN (?)",
+		s.db.Model(&models.TeamMember{}).Select("user_id").Where("team_id = ?", team.ID))
+	query = filterByRoles(c, s.db, query, []uint{team.ID})
+	return s.renderUsers(c, searchUsers(c, query), p)
+}
+
+// listTeamRoles 返回限定在 Team 内的全部 Role 绑定，该资源列表不分页。
+func (s *Server) listTeamRoles(c *gin.Context) error {
+	db := s.db.WithContext(c)
+	team, err := loadPathTeam(c, db)
+	if err != nil {
+		return err
+	}
+	var bindings []models.TeamRoleBinding
+	if err := db.Preload("User.Roles").Preload("Role").Where("team_id = ?", team.ID).
+		Order("user_id").Order("role_id").Find(&bindings).Error; err != nil {
+		return err
+	}
+	for i := range bindings {
+		bindings[i].Team = team
+	}
+	c.JSON(http.StatusOK, newListResponse(int64(len(bindings)), bindings, toRoleBindingResponse))
+	return nil
+}
+
+type addMemberRequest struct {
+	UserID uint `json:"user_id"`
+}
+
+func (s *Server) addTeamUser(c *gin.Context) error {
+	var req addMemberRequest
+	if err := bindJSON(c, &req); err != nil {
+		return err
+	}
+
+	me := currentUser(c)
+	db := s.db.WithContext(c)
+	team, err := loadPathTeam(c, db)
+	if err != nil {
+		return err
+	}
+	target, err := s.loadAddableUser(db, me, req.UserID)
+	if err != nil {
+		return err
+	}
+	if err := addTeamMember(db, team.ID, target.ID); err != nil {
+		return err
+	}
+
+	s.audit(c, me, true, "将用户 %s 加入了%s", describeUser(target), describeTeam(team))
+	c.Status(http.StatusOK)
+	return nil
+}
+
+func (s *Server) removeTeamUser(c *gin.Context) error {
+	userID, err := pathID(c, "user_id")
+	if err != nil {
+		return err
+	}
+
+	var team *models.Team
+	var target *models.User
+	err = s.db.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		if team, err = loadPathTeam(c, tx); err != nil {
+			return err
+		}
+		if target, err = loadUser(tx, userID); err != nil {
+			return err
+		}
+		member, err := isTeamMember(tx, team.ID, target.ID)
+		if err != nil {
+			return err
+		}
+		if !member {
+			return notFound("user %d is not a member of team %d", target.ID, team.ID)
+		}
+		return removeTeamMember(tx, team, target.ID)
+	})
+	if err != nil {
+		return err
+	}
+
+	s.audit(c, currentUser(c), true, "将用户 %s 移出了%s", describeUser(target), describeTeam(team))
+	c.Status(http.StatusOK)
+	return nil
+}
+
+func (s *Server) getTeamProjects(c *gin.Context) error {
+	p, err := parsePagination(c)
+	if err != nil {
+		return err
+	}
+	partIn, err := queryBool(c, "part_in")
+	if err != nil {
+		return err
+	}
+	db := s.db.WithContext(c)
+	team, err := loadPathTeam(c, db)
+	if err != nil {
+		return err
+	}
+
+	me := currentUser(c)
+	query := db.Model(&models.Project{}).Where("projects.team_id = ?", team.ID)
+	if name := c.Query("name"); name != "" {
+		query = query.Where("projects.name LIKE ?", likePattern(name))
+	}
+	if partIn != nil {
+		if *partIn {
+			query = query.Where("projects.id IN (?)", myProjectIDs(s.db, me.ID))
+		} else {
+			query = query.Where("projects.id NOT IN (?)", myProjectIDs(s.db, me.ID))
+		}
+	}
+	return s.renderProjects(c, query, p)
+}
+
+type createProjectRequest struct {
+	Name string `json:"name"`
+	Desc string `json:"desc"`
+}
+
+func (s *Server) createTeamProject(c *gin.Context) error {
+	var req createProjectRequest
+	if err := bindJSON(c, &req); err != nil {
+		return err
+	}
+	if err := validateName("project", req.Name); err != nil {
+		return err
+	}
+
+	db := s.db.WithContext(c)
+	team, err := loadPathTeam(c, db)
+	if err != nil {
+		return err
+	}
+	taken, err := exists(db, &models.Project{}, "team_id = ? AND name = ?", team.ID, req.Name)
+	if err != nil {
+		return err
+	}
+	if taken {
+		return conflict("project name %q is already in use in team %d", req.Name, team.ID)
+	}
+	project := &models.Project{
+		TeamID:      team.ID,
+		Name:        req.Name,
+		Description: req.Desc,
+		Status:      models.ProjectStatusWaitForSchedule,
+	}
+	if err := db.Create(project).Error; err != nil {
+		return err
+	}
+
+	s.audit(c, currentUser(c), true, "在%s下创建了%s", describeTeam(team), describeProject(project))
+	c.JSON(http.StatusOK, toProjectResponse(project))
+	return nil
+}
+
+// loadPathTeam 加载路径参数 team_id 指定的 Team，Me 对它的权限已由 authorize 校验。
+func loadPathTeam(c *gin.Context, tx *gorm.DB) (*models.Team, error) {
+	teamID, err := pathID(c, "team_id")
+	if err != nil {
+		return nil, err
+	}
+	return loadTeam(tx, teamID)
+}
+
+// loadAddableUser 加载将被加入 Team 或 Project 的用户：admin 可添加任何用户，
+// Team Leader 只能添加对其可见的用户。
+func (s *Server) loadAddableUser(tx *gorm.DB, me *models.User, userID uint) (*models.User, error) {
+	if userID == 0 {
+		return nil, badRequest("user_id is required")
+	}
+	target, err := loadUser(tx, userID)
+	if err != nil {
+		return nil, err
+	}
+	visible, err := canSee(tx, me, target.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !visible {
+		return nil, forbidden("user %d is not visible to you", userID)
+	}
+	return target, nil
+}
+
+func (s *Server) renderTeams(c *gin.Context, query *gorm.DB, p *pagination) error {
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return err
+	}
+	var teams []models.Team
+	if err := p.apply(query, "teams").Preload("Leader.Roles").Find(&teams).Error; err != nil {
+		return err
+	}
+	c.JSON(http.StatusOK, newListResponse(total, teams, toTeamResponse))
+	return nil
+}
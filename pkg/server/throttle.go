@@ -0,0 +1,133 @@
+package server
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/dspo/go-homework/pkg/models"
+	"github.com/dspo/go-homework/pkg/throttle"
+)
+
+// loginThrottle 按账号与客户端 IP 分别统计登录失败，任一方被锁定时拒绝登录。
+type loginThrottle struct {
+	account *throttle.Limiter
+	ip      *throttle.Limiter
+}
+
+// loginAccountKey 返回登录账号的限流键。账号存在时按用户 ID 计数，使用户名与邮箱登录共享计数；
+// 不存在时按提交的用户名或邮箱计数，使不存在的账号与存在的账号同样会被锁定，不暴露账号是否存在。
+func loginAccountKey(req *loginRequest, user *models.User) string {
+	if user != nil {
+		return "user:" + strconv.FormatUint(uint64(user.ID), 10)
+	}
+	if req.Email != nil {
+		return "email:" + strings.ToLower(*req.Email)
+	}
+	return "username:" + strings.ToLower(*req.Username)
+}
+
+// loginLockout 返回账号与客户端 IP 中较长的剩余锁定时长，未被锁定时返回 0。accountKey 为空时只检查客户端 IP。
+func (s *Server) loginLockout(c *gin.Context, accountKey string) (time.Duration, error) {
+	if s.loginThrottle == nil {
+		return 0, nil
+	}
+	var account time.Duration
+	if accountKey != "" {
+		var err error
+		if account, err = s.loginThrottle.account.Check(c, accountKey); err != nil {
+			return 0, err
+		}
+	}
+	ip, err := s.loginThrottle.ip.Check(c, c.ClientIP())
+	if err != nil {
+		return 0, err
+	}
+	return max(account, ip), nil
+}
+
+// recordLoginFailure 为账号与客户端 IP 各记录一次失败，导致锁定时记录审计日志。user 为 nil 表示账号不存在，
+// accountKey 为空表示无法确定账号（如单点登录失败），只记录客户端 IP。
+func (s *Server) recordLoginFailure(c *gin.Context, accountKey string, user *models.User) error {
+	if s.loginThrottle == nil {
+		return nil
+	}
+	if accountKey != "" {
+		failures, lockout, err := s.loginThrottle.account.Fail(c, accountKey)
+		if err != nil {
+			return err
+		}
+		if lockout > 0 {
+			account := strings.SplitN(accountKey, ":", 2)[1]
+			if user != nil {
+				account = describeUser(user)
+			}
+			s.audit(c, user, false, "登录连续失败 %d 次，账号 %s 被锁定 %s", failures, account, lockout)
+		}
+	}
+	ip := c.ClientIP()
+	failures, lockout, err := s.loginThrottle.ip.Fail(c, ip)
+	if err != nil {
+		return err
+	}
+	if lockout > 0 {
+		s.audit(c, nil, false, "登录连续失败 %d 次，IP %s 被锁定 %s", failures, ip, lockout)
+	}
+	return nil
+}
+
+// resetLoginFailures 在登录成功后清除账号的失败计数。IP 的计数不清除，以免攻击者用自己的账号登录来重置。
+func (s *Server) resetLoginFailures(c *gin.Context, accountKey string) error {
+	if s.loginThrottle == nil {
+		return nil
+	}
+	return s.loginThrottle.account.Reset(c, accountKey)
+}
+
+// tooManyLoginAttempts 返回 429，并通过 Retry-After 告知客户端需等待的秒数。
+func tooManyLoginAttempts(c *gin.Context, lockout time.Duration) error {
+	seconds := int(math.Ceil(lockout.Seconds()))
+	c.Header("Retry-After", strconv.Itoa(seconds))
+	return newError(http.StatusTooManyRequests, "登录失败次数过多，请 %d 秒后重试", seconds)
+}
+
+// unlockUser 解除用户因登录失败导致的锁定。
+func (s *Server) unlockUser(c *gin.Context) error {
+	me := currentUser(c)
+	userID, err := pathID(c, "user_id")
+	if err != nil {
+		return err
+	}
+	target, err := loadUser(s.db.WithContext(c), userID)
+	if err != nil {
+		return err
+	}
+	if err := s.resetLoginFailures(c, loginAccountKey(nil, target)); err != nil {
+		return err
+	}
+	s.audit(c, me, true, "解除了用户 %s 的登录锁定", describeUser(target))
+	c.Status(http.StatusOK)
+	return nil
+}
+
+// unlockIP 解除客户端 IP 因登录失败导致的锁定。同一出口 IP 之后的全部用户都会因 IP 被锁定而无法登录，
+// 而用户登录成功不会清除 IP 的计数，须由 admin 解除。
+func (s *Server) unlockIP(c *gin.Context) error {
+	ip := net.ParseIP(c.Param("ip"))
+	if ip == nil {
+		return badRequest("invalid ip: %q", c.Param("ip"))
+	}
+	if s.loginThrottle != nil {
+		if err := s.loginThrottle.ip.Reset(c, ip.String()); err != nil {
+			return err
+		}
+	}
+	s.audit(c, currentUser(c), true, "解除了 IP %s 的登录锁定", ip)
+	c.Status(http.StatusOK)
+	return nil
+}
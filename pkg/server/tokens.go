@@ -0,0 +1,170 @@
+package server
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/dspo/go-homework/pkg/models"
+)
+
+// accessTokenPrefix 便于在日志、代码仓库中识别泄露的令牌。
+const accessTokenPrefix = "hwpat_"
+
+// 个人访问令牌的有效期（天），创建时未指定则使用默认值。
+const (
+	defaultAccessTokenDays = 30
+	maxAccessTokenDays     = 365
+)
+
+// accessTokenTouchInterval 是更新 last_used_at 的最小间隔，避免每个请求都写数据库。
+const accessTokenTouchInterval = time.Minute
+
+func hashAccessToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func newAccessToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return accessTokenPrefix + hex.EncodeToString(buf), nil
+}
+
+// bearerToken 返回 Authorization 头中的 Bearer 令牌，第二个返回值表示请求是否携带了 Authorization 头。
+func bearerToken(c *gin.Context) (string, bool) {
+	header := c.GetHeader("Authorization")
+	if header == "" {
+		return "", false
+	}
+	scheme, token, _ := strings.Cut(header, " ")
+	if !strings.EqualFold(scheme, "Bearer") {
+		return "", true
+	}
+	return strings.TrimSpace(token), true
+}
+
+// lookupAccessToken 返回 token 对应的未过期的个人访问令牌，并按需更新其最近使用时间。
+func (s *Server) lookupAccessToken(c *gin.Context, token string) (*models.PersonalAccessToken, error) {
+	if token == "" {
+		return nil, errUnauthorized
+	}
+	db := s.db.WithContext(c)
+	var pat models.PersonalAccessToken
+	if err := db.Where("token_hash = ?", hashAccessToken(token)).Take(&pat).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errUnauthorized
+		}
+		return nil, err
+	}
+	now := time.Now()
+	if !now.Before(pat.ExpiresAt) {
+		return nil, errUnauthorized
+	}
+	if pat.LastUsedAt == nil || now.Sub(*pat.LastUsedAt) >= accessTokenTouchInterval {
+		if err := db.Model(&models.PersonalAccessToken{ID: pat.ID}).Update("last_used_at", now).Error; err != nil {
+			return nil, err
+		}
+		pat.LastUsedAt = &now
+	}
+	return &pat, nil
+}
+
+func (s *Server) listMyAccessTokens(c *gin.Context) error {
+	var tokens []models.PersonalAccessToken
+	if err := s.db.WithContext(c).Where("user_id = ?", currentUser(c).ID).Order("id").Find(&tokens).Error; err != nil {
+		return err
+	}
+	c.JSON(http.StatusOK, newListResponse(int64(len(tokens)), tokens, toAccessTokenResponse))
+	return nil
+}
+
+type createAccessTokenRequest struct {
+	Name          string `json:"name"`
+	ExpiresInDays *int   `json:"expires_in_days"`
+}
+
+// createMyAccessToken 为当前用户创建个人访问令牌，令牌明文只在响应中出现这一次。
+// 只能在登录会话中创建，以免泄露的令牌借以生成更多令牌。
+func (s *Server) createMyAccessToken(c *gin.Context) error {
+	if currentSession(c) == nil {
+		return forbidden("personal access tokens can only be created in a login session")
+	}
+	var req createAccessTokenRequest
+	if err := bindJSON(c, &req); err != nil {
+		return err
+	}
+	if err := validateName("token", req.Name); err != nil {
+		return err
+	}
+	days := defaultAccessTokenDays
+	if req.ExpiresInDays != nil {
+		days = *req.ExpiresInDays
+	}
+	if days < 1 || days > maxAccessTokenDays {
+		return badRequest("expires_in_days must be between 1 and %d", maxAccessTokenDays)
+	}
+
+	me := currentUser(c)
+	db := s.db.WithContext(c)
+	taken, err := exists(db, &models.PersonalAccessToken{}, "user_id = ? AND name = ?", me.ID, req.Name)
+	if err != nil {
+		return err
+	}
+	if taken {
+		return conflict("token name %q is already in use", req.Name)
+	}
+	token, err := newAccessToken()
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	pat := &models.PersonalAccessToken{
+		UserID:    me.ID,
+		Name:      req.Name,
+		TokenHash: hashAccessToken(token),
+		ExpiresAt: now.AddDate(0, 0, days),
+		CreatedAt: now,
+	}
+	if err := db.Create(pat).Error; err != nil {
+		return err
+	}
+
+	s.audit(c, me, true, "创建了%s", describeAccessToken(pat))
+	resp := toAccessTokenResponse(pat)
+	resp.Token = token
+	c.JSON(http.StatusOK, resp)
+	return nil
+}
+
+func (s *Server) deleteMyAccessToken(c *gin.Context) error {
+	tokenID, err := pathID(c, "token_id")
+	if err != nil {
+		return err
+	}
+	me := currentUser(c)
+	db := s.db.WithContext(c)
+	var pat models.PersonalAccessToken
+	if err := db.Where("id = ? AND user_id = ?", tokenID, me.ID).Take(&pat).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return notFound("token %d not found", tokenID)
+		}
+		return err
+	}
+	if err := db.Delete(&pat).Error; err != nil {
+		return err
+	}
+
+	s.audit(c, me, true, "撤销了%s", describeAccessToken(&pat))
+	c.Status(http.StatusOK)
+	return nil
+}
@@ -0,0 +1,416 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/dspo/go-homework/pkg/models"
+	"github.com/dspo/go-homework/pkg/twofactor"
+)
+
+const (
+	// loginChallengeTTL 是密码校验通过后完成两步验证的时限。
+	loginChallengeTTL = 5 * time.Minute
+	// maxChallengeAttempts 是同一登录挑战允许输错验证码的次数，用尽后须重新输入密码。
+	maxChallengeAttempts = 5
+)
+
+type loginChallengeResponse struct {
+	// Challenge 是登录挑战令牌，须与验证码一起提交到 /api/login/2fa。
+	Challenge string `json:"challenge"`
+	ExpiresAt int64  `json:"expires_at"`
+}
+
+type twoFactorResponse struct {
+	Enabled bool `json:"enabled"`
+	// Required 表示用户必须启用两步验证，目前只有 admin 是必须的。
+	Required               bool `json:"required"`
+	RecoveryCodesRemaining int  `json:"recovery_codes_remaining"`
+}
+
+type twoFactorEnrollmentResponse struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+	// QRCode 是编码了 ProvisioningURI 的 PNG 图片的 data URI。
+	QRCode string `json:"qr_code"`
+}
+
+type recoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// twoFactorCredential 返回用户的 TOTP 密钥，用户从未开始启用两步验证时返回 nil。
+func twoFactorCredential(tx *gorm.DB, userID uint) (*models.TwoFactorCredential, error) {
+	var cred models.TwoFactorCredential
+	result := tx.Where("user_id = ?", userID).Limit(1).Find(&cred)
+	if result.Error != nil || result.RowsAffected == 0 {
+		return nil, result.Error
+	}
+	return &cred, nil
+}
+
+// requireTwoFactor 拦截尚未启用两步验证的 admin，admin 必须启用两步验证后才能访问其他接口。
+func (s *Server) requireTwoFactor(c *gin.Context) {
+	me := currentUser(c)
+	if me.IsAdmin() {
+		cred, err := twoFactorCredential(s.db.WithContext(c), me.ID)
+		if err != nil {
+			abortWithError(c, err)
+			return
+		}
+		if !cred.Enabled() {
+			abortWithError(c, forbidden("admin 须先启用两步验证"))
+			return
+		}
+	}
+	c.Next()
+}
+
+// issueLoginChallenge 在密码校验通过后返回登录挑战，客户端须通过 /api/login/2fa 提交验证码完成登录。
+func (s *Server) issueLoginChallenge(c *gin.Context, user *models.User) error {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return err
+	}
+	token := hex.EncodeToString(buf)
+	challenge := models.LoginChallenge{
+		ID:        hashAccessToken(token),
+		UserID:    user.ID,
+		ExpiresAt: time.Now().UTC().Truncate(time.Millisecond).Add(loginChallengeTTL),
+	}
+	if err := s.db.WithContext(c).Create(&challenge).Error; err != nil {
+		return err
+	}
+	c.JSON(http.StatusAccepted, loginChallengeResponse{Challenge: token, ExpiresAt: challenge.ExpiresAt.Unix()})
+	return nil
+}
+
+type completeLoginRequest struct {
+	Challenge string `json:"challenge"`
+	Code      string `json:"code"`
+}
+
+// completeLogin 以 TOTP 验证码或恢复码完成两步验证登录。
+func (s *Server) completeLogin(c *gin.Context) error {
+	var req completeLoginRequest
+	if err := bindJSON(c, &req); err != nil {
+		return err
+	}
+	if req.Challenge == "" || req.Code == "" {
+		return badRequest("challenge and code are required")
+	}
+
+	db := s.db.WithContext(c)
+	var challenge models.LoginChallenge
+	result := db.Where("id = ?", hashAccessToken(req.Challenge)).Limit(1).Find(&challenge)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 || !time.Now().Before(challenge.ExpiresAt) {
+		return newError(http.StatusUnauthorized, "登录已过期，请重新输入密码")
+	}
+	user, err := loadUser(db, challenge.UserID)
+	if err != nil {
+		return err
+	}
+	accountKey := loginAccountKey(nil, user)
+	lockout, err := s.loginLockout(c, accountKey)
+	if err != nil {
+		return err
+	}
+	if lockout > 0 {
+		s.metrics.ObserveLoginThrottled()
+		return tooManyLoginAttempts(c, lockout)
+	}
+
+	usedRecoveryCode, ok, err := s.verifySecondFactor(db, user.ID, req.Code)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		s.metrics.ObserveLogin(false)
+		s.audit(c, user, false, "两步验证")
+		if challenge.Attempts+1 >= maxChallengeAttempts {
+			err = db.Delete(&challenge).Error
+		} else {
+			err = db.Model(&challenge).UpdateColumn("attempts", gorm.Expr("attempts + 1")).Error
+		}
+		if err != nil {
+			return err
+		}
+		if err := s.recordLoginFailure(c, accountKey, user); err != nil {
+			return err
+		}
+		return newError(http.StatusUnauthorized, "验证码错误")
+	}
+
+	// 以删除是否成功判断挑战是否仍未被使用，并发提交同一挑战时只有一个请求能完成登录。
+	result = db.Where("id = ?", challenge.ID).Delete(&models.LoginChallenge{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return newError(http.StatusUnauthorized, "登录已过期，请重新输入密码")
+	}
+	action := "使用两步验证登录"
+	if usedRecoveryCode {
+		action = "使用恢复码完成两步验证登录"
+	}
+	return s.finishLogin(c, user, accountKey, action)
+}
+
+// verifySecondFactor 校验 TOTP 验证码或恢复码，每个 TOTP 验证码只接受一次，恢复码校验通过后即作废。
+func (s *Server) verifySecondFactor(tx *gorm.DB, userID uint, code string) (usedRecoveryCode, ok bool, err error) {
+	cred, err := twoFactorCredential(tx, userID)
+	if err != nil || !cred.Enabled() {
+		return false, false, err
+	}
+	if twofactor.IsTOTPCode(code) {
+		step, ok := twofactor.Match(cred.Secret, code, time.Now())
+		if !ok {
+			return false, false, nil
+		}
+		// 以条件更新记录验证码所在的时间步，同一验证码或更早的验证码不能再次使用，并发提交时也只有一个请求成功。
+		result := tx.Model(&models.TwoFactorCredential{}).
+			Where("user_id = ? AND last_step < ?", userID, step).
+			UpdateColumn("last_step", step)
+		return false, result.RowsAffected > 0, result.Error
+	}
+	// 以条件更新作废恢复码，并发使用同一恢复码时只有一个请求成功。
+	result := tx.Model(&models.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, twofactor.HashRecoveryCode(code)).
+		Limit(1).UpdateColumn("used_at", time.Now())
+	return true, result.RowsAffected > 0, result.Error
+}
+
+// replaceRecoveryCodes 生成新的恢复码并作废原有的全部恢复码。
+func replaceRecoveryCodes(tx *gorm.DB, userID uint) ([]string, error) {
+	codes, hashes, err := twofactor.NewRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error; err != nil {
+		return nil, err
+	}
+	rows := make([]models.RecoveryCode, 0, len(hashes))
+	for _, hash := range hashes {
+		rows = append(rows, models.RecoveryCode{UserID: userID, CodeHash: hash})
+	}
+	return codes, tx.Create(&rows).Error
+}
+
+// deleteTwoFactor 删除用户的 TOTP 密钥、恢复码与未完成的登录挑战。
+func deleteTwoFactor(tx *gorm.DB, userID uint) error {
+	for _, model := range []any{&models.TwoFactorCredential{}, &models.RecoveryCode{}, &models.LoginChallenge{}} {
+		if err := tx.Where("user_id = ?", userID).Delete(model).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Server) getMyTwoFactor(c *gin.Context) error {
+	me := currentUser(c)
+	db := s.db.WithContext(c)
+	cred, err := twoFactorCredential(db, me.ID)
+	if err != nil {
+		return err
+	}
+	resp := twoFactorResponse{Enabled: cred.Enabled(), Required: me.IsAdmin()}
+	if resp.Enabled {
+		var remaining int64
+		if err := db.Model(&models.RecoveryCode{}).Where("user_id = ? AND used_at IS NULL", me.ID).Count(&remaining).Error; err != nil {
+			return err
+		}
+		resp.RecoveryCodesRemaining = int(remaining)
+	}
+	c.JSON(http.StatusOK, resp)
+	return nil
+}
+
+// enrollMyTwoFactor 生成新的 TOTP 密钥，用户须通过 activateMyTwoFactor 提交验证码确认后才生效。
+// 重复调用会替换尚未确认的密钥。
+func (s *Server) enrollMyTwoFactor(c *gin.Context) error {
+	me := currentUser(c)
+	db := s.db.WithContext(c)
+	cred, err := twoFactorCredential(db, me.ID)
+	if err != nil {
+		return err
+	}
+	if cred.Enabled() {
+		return conflict("two-factor authentication is already enabled")
+	}
+	enrollment, err := twofactor.NewEnrollment(s.appName, me.Username)
+	if err != nil {
+		return err
+	}
+	if err := db.Clauses(clause.OnConflict{UpdateAll: true}).
+		Create(&models.TwoFactorCredential{UserID: me.ID, Secret: enrollment.Secret}).Error; err != nil {
+		return err
+	}
+	c.JSON(http.StatusOK, twoFactorEnrollmentResponse{
+		Secret:          enrollment.Secret,
+		ProvisioningURI: enrollment.URI,
+		QRCode:          enrollment.QRCode,
+	})
+	return nil
+}
+
+type twoFactorCodeRequest struct {
+	Code string `json:"code"`
+}
+
+// activateMyTwoFactor 以验证码确认 TOTP 密钥，两步验证随即生效，并返回恢复码。
+func (s *Server) activateMyTwoFactor(c *gin.Context) error {
+	var req twoFactorCodeRequest
+	if err := bindJSON(c, &req); err != nil {
+		return err
+	}
+	me := currentUser(c)
+	var codes []string
+	err := s.db.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		cred, err := twoFactorCredential(tx, me.ID)
+		if err != nil {
+			return err
+		}
+		switch {
+		case cred == nil:
+			return badRequest("call POST /api/me/2fa to enroll first")
+		case cred.Enabled():
+			return conflict("two-factor authentication is already enabled")
+		}
+		step, ok := twofactor.Match(cred.Secret, req.Code, time.Now())
+		if !ok {
+			return badRequest("invalid verification code")
+		}
+		// 确认用的验证码同样不能再用于登录。
+		if err := tx.Model(cred).Updates(map[string]any{"enabled_at": time.Now(), "last_step": step}).Error; err != nil {
+			return err
+		}
+		codes, err = replaceRecoveryCodes(tx, me.ID)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	s.audit(c, me, true, "启用了两步验证")
+	c.JSON(http.StatusOK, recoveryCodesResponse{RecoveryCodes: codes})
+	return nil
+}
+
+// disableMyTwoFactor 以验证码或恢复码确认后停用两步验证，admin 不能停用。
+func (s *Server) disableMyTwoFactor(c *gin.Context) error {
+	var req twoFactorCodeRequest
+	if err := bindJSON(c, &req); err != nil {
+		return err
+	}
+	me := currentUser(c)
+	db := s.db.WithContext(c)
+	if err := checkConstraint(db, "disableMyTwoFactor", me, nil); err != nil {
+		return err
+	}
+	err := s.confirmSecondFactor(c, me, req.Code, func(tx *gorm.DB) error {
+		return deleteTwoFactor(tx, me.ID)
+	})
+	if err != nil {
+		return err
+	}
+	s.audit(c, me, true, "停用了两步验证")
+	c.Status(http.StatusOK)
+	return nil
+}
+
+// regenerateMyRecoveryCodes 以验证码或恢复码确认后重新生成恢复码，原有的恢复码全部作废。
+func (s *Server) regenerateMyRecoveryCodes(c *gin.Context) error {
+	var req twoFactorCodeRequest
+	if err := bindJSON(c, &req); err != nil {
+		return err
+	}
+	me := currentUser(c)
+	var codes []string
+	err := s.confirmSecondFactor(c, me, req.Code, func(tx *gorm.DB) (err error) {
+		codes, err = replaceRecoveryCodes(tx, me.ID)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	s.audit(c, me, true, "重新生成了两步验证恢复码")
+	c.JSON(http.StatusOK, recoveryCodesResponse{RecoveryCodes: codes})
+	return nil
+}
+
+// confirmSecondFactor 要求用户已启用两步验证且 code 有效，并在同一事务中执行 fn。
+// 与 /api/login/2fa 一样，验证码错误计入登录限流，锁定期间返回 429，以免借已登录的会话猜测验证码。
+func (s *Server) confirmSecondFactor(c *gin.Context, me *models.User, code string, fn func(tx *gorm.DB) error) error {
+	accountKey := loginAccountKey(nil, me)
+	lockout, err := s.loginLockout(c, accountKey)
+	if err != nil {
+		return err
+	}
+	if lockout > 0 {
+		return tooManyLoginAttempts(c, lockout)
+	}
+	var invalid bool
+	err = s.db.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		cred, err := twoFactorCredential(tx, me.ID)
+		if err != nil {
+			return err
+		}
+		if !cred.Enabled() {
+			return badRequest("two-factor authentication is not enabled")
+		}
+		_, ok, err := s.verifySecondFactor(tx, me.ID, code)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			invalid = true
+			return badRequest("invalid verification code")
+		}
+		return fn(tx)
+	})
+	if invalid {
+		// 事务结束后再记录失败，失败次数不随事务回滚。
+		if err := s.recordLoginFailure(c, accountKey, me); err != nil {
+			return err
+		}
+	}
+	return err
+}
+
+// resetUserTwoFactor 由 admin 为丢失身份验证器与恢复码的用户停用两步验证。
+// 被重置的用户若是 admin，须重新启用两步验证后才能访问其他接口。
+func (s *Server) resetUserTwoFactor(c *gin.Context) error {
+	me := currentUser(c)
+	userID, err := pathID(c, "user_id")
+	if err != nil {
+		return err
+	}
+	var target *models.User
+	err = s.db.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		if target, err = loadUser(tx, userID); err != nil {
+			return err
+		}
+		return deleteTwoFactor(tx, target.ID)
+	})
+	if err != nil {
+		return err
+	}
+	s.audit(c, me, true, "重置了用户 %s 的两步验证", describeUser(target))
+	c.Status(http.StatusOK)
+	return nil
+}
+
+// deleteExpiredLoginChallenges 清理过期的登录挑战，返回清理的数量。
+func (s *Server) deleteExpiredLoginChallenges(ctx context.Context) (int64, error) {
+	result := s.db.WithContext(ctx).Where("expires_at < ?", time.Now().UTC().Truncate(time.Millisecond)).Delete(&models.LoginChallenge{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,502 @@
+package server
+
+import (
+	"net/http"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/dspo/go-homework/pkg/models"
+	"github.com/dspo/go-homework/pkg/password"
+)
+
+type createUserRequest struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+func (s *Server) createUser(c *gin.Context) error {
+	me := currentUser(c)
+	var req createUserRequest
+	if err := bindJSON(c, &req); err != nil {
+		return err
+	}
+	if !usernamePattern.MatchString(req.Username) {
+		return badRequest("username must be 4-30 characters of letters, digits, underscores or hyphens")
+	}
+	if err := s.validatePassword(req.Password); err != nil {
+		return err
+	}
+	hash, err := password.Hash(req.Password)
+	if err != nil {
+		return err
+	}
+
+	user := &models.User{
+		Username:           req.Username,
+		PasswordHash:       hash,
+		MustChangePassword: true,
+	}
+	err = s.db.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		taken, err := exists(tx, &models.User{}, "username = ?", req.Username)
+		if err != nil {
+			return err
+		}
+		if taken {
+			return conflict("username %q is already in use", req.Username)
+		}
+		if err := tx.Create(user).Error; err != nil {
+			return err
+		}
+		var role models.Role
+		if err := tx.Where("name = ?", models.RoleNormalUser).First(&role).Error; err != nil {
+			return err
+		}
+		if err := bindRole(tx, user.ID, role.ID); err != nil {
+			return err
+		}
+		user, err = loadUser(tx, user.ID)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	s.audit(c, me, true, "创建了用户 %s", describeUser(user))
+	c.JSON(http.StatusOK, toUserResponse(user))
+	return nil
+}
+
+func (s *Server) listUsers(c *gin.Context) error {
+	p, err := parsePagination(c)
+	if err != nil {
+		return err
+	}
+	teamIDs, err := queryIDs(c, "team_id")
+	if err != nil {
+		return err
+	}
+
+	me := currentUser(c)
+	query := filterVisibleUsers(s.db, s.db.WithContext(c).Model(&models.User{}), me)
+	if len(teamIDs) > 0 {
+		if !me.IsAdmin() {
+			// 非 admin 只能按自己所在的 Teams 筛选，否则可以通过 role_name 探知其他 Teams 内的 Role 绑定。
+			var mine []uint
+			if err := s.db.WithContext(c).Model(&models.TeamMember{}).Where("user_id = ? AND team_id IN ?", me.ID, teamIDs).
+				Pluck("team_id", &mine).Error; err != nil {
+				return err
+			}
+			teamIDs = mine
+		}
+		query = query.Where("users.id IN (?)", s.db.Model(&models.TeamMember{}).Select("user_id").Where("team_id IN ?", teamIDs))
+	}
+	// 指定了 team_id 时，限定在这些 Teams 内的 Role 绑定同样满足 role_name 的筛选。
+	query = filterByRoles(c, s.db, query, teamIDs)
+	return s.renderUsers(c, searchUsers(c, query), p)
+}
+
+func (s *Server) getUser(c *gin.Context) error {
+	target, err := s.loadPathUser(c)
+	if err != nil {
+		return err
+	}
+	c.JSON(http.StatusOK, toUserResponse(target))
+	return nil
+}
+
+// getUserVisibility 说明 viewer_id 指定的用户（默认为 Me）是否可见路径参数指定的用户，以便排查因不可见而被拒绝的操作。
+// 只有 admin 可以指定其他用户为 viewer。
+func (s *Server) getUserVisibility(c *gin.Context) error {
+	me := currentUser(c)
+	db := s.db.WithContext(c)
+	target, err := s.loadPathUser(c)
+	if err != nil {
+		return err
+	}
+	viewerIDs, err := queryIDs(c, "viewer_id")
+	if err != nil {
+		return err
+	}
+	viewer := me
+	switch {
+	case len(viewerIDs) > 1:
+		return badRequest("viewer_id can only be specified once")
+	case len(viewerIDs) == 1 && viewerIDs[0] != me.ID:
+		if !me.IsAdmin() {
+			return forbidden("only admin can explain the visibility of other users")
+		}
+		if viewer, err = loadUser(db, viewerIDs[0]); err != nil {
+			return err
+		}
+	}
+
+	v, err := explainVisibility(db, viewer, target.ID)
+	if err != nil {
+		return err
+	}
+	c.JSON(http.StatusOK, toVisibilityResponse(v))
+	return nil
+}
+
+// maxVisibilityReportUsers 是可见性报告一次最多包含的用户数。
+const maxVisibilityReportUsers = 50
+
+// getVisibilityReport 两两列出 user_id 指定的用户之间的可见性。
+func (s *Server) getVisibilityReport(c *gin.Context) error {
+	userIDs, err := queryIDs(c, "user_id")
+	if err != nil {
+		return err
+	}
+	slices.Sort(userIDs)
+	userIDs = slices.Compact(userIDs)
+	if len(userIDs) < 2 || len(userIDs) > maxVisibilityReportUsers {
+		return badRequest("between 2 and %d distinct user_id are required", maxVisibilityReportUsers)
+	}
+
+	db := s.db.WithContext(c)
+	var users []models.User
+	if err := db.Preload("Roles").Where("id IN ?", userIDs).Order("id").Find(&users).Error; err != nil {
+		return err
+	}
+	for i, userID := range userIDs {
+		if i >= len(users) || users[i].ID != userID {
+			return notFound("user %d not found", userID)
+		}
+	}
+	list, err := explainVisibilities(db, users)
+	if err != nil {
+		return err
+	}
+	c.JSON(http.StatusOK, newListResponse(int64(len(list)), list, toVisibilityResponse))
+	return nil
+}
+
+func (s *Server) deleteUser(c *gin.Context) error {
+	me := currentUser(c)
+	userID, err := pathID(c, "user_id")
+	if err != nil {
+		return err
+	}
+
+	var target *models.User
+	err = s.db.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		if target, err = loadUser(tx, userID); err != nil {
+			return err
+		}
+		if err := checkConstraint(tx, "deleteUser", me, target); err != nil {
+			return err
+		}
+		// 用户被删除时解除其全部关联，但不级联删除 Teams、Projects 与 Roles。
+		if err := tx.Model(&models.Team{}).Where("leader_id = ?", target.ID).Update("leader_id", nil).Error; err != nil {
+			return err
+		}
+		for _, model := range []any{&models.ProjectMember{}, &models.TeamMember{}, &models.UserRole{}, &models.TeamRoleBinding{}, &models.PersonalAccessToken{}, &models.PasswordHistory{}, &models.UserIdentity{}} {
+			if err := tx.Where("user_id = ?", target.ID).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+		if err := deleteTwoFactor(tx, target.ID); err != nil {
+			return err
+		}
+		return tx.Delete(target).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := s.sessions.DeleteByUser(c, target.ID); err != nil {
+		return err
+	}
+	s.audit(c, me, true, "删除了用户 %s", describeUser(target))
+	if target.IsAdmin() {
+		s.audit(c, me, true, "随用户 %s 的删除撤销了其 admin 权限", describeUser(target))
+	}
+	c.Status(http.StatusOK)
+	return nil
+}
+
+func (s *Server) getUserTeams(c *gin.Context) error {
+	p, err := parsePagination(c)
+	if err != nil {
+		return err
+	}
+	target, err := s.loadPathUser(c)
+	if err != nil {
+		return err
+	}
+
+	me := currentUser(c)
+	query := s.db.WithContext(c).Model(&models.Team{}).Where("teams.id IN (?)", myTeamIDs(s.db, target.ID))
+	if !me.IsAdmin() {
+		// 普通用户看到的范围不超过 Me 自身所在的 Teams。
+		query = query.Where("teams.id IN (?)", myTeamIDs(s.db, me.ID))
+	}
+	return s.renderTeams(c, query, p)
+}
+
+func (s *Server) getUserProjects(c *gin.Context) error {
+	p, err := parsePagination(c)
+	if err != nil {
+		return err
+	}
+	target, err := s.loadPathUser(c)
+	if err != nil {
+		return err
+	}
+
+	me := currentUser(c)
+	query := s.db.WithContext(c).Model(&models.Project{}).Where("projects.id IN (?)", myProjectIDs(s.db, target.ID))
+	if !me.IsAdmin() {
+		// 普通用户看到的范围不超过 Me 自身参与的 Projects。
+		query = query.Where("projects.id IN (?)", myProjectIDs(s.db, me.ID))
+	}
+	return s.renderProjects(c, query, p)
+}
+
+// listUserRoles 返回用户的全局 Role 绑定与限定在 Team 内的 Role 绑定，该资源列表不分页。
+func (s *Server) listUserRoles(c *gin.Context) error {
+	target, err := s.loadPathUser(c)
+	if err != nil {
+		return err
+	}
+
+	me := currentUser(c)
+	user := toUserResponse(target)
+	bindings := make([]roleBindingResponse, 0, len(target.Roles))
+	for i := range target.Roles {
+		bindings = append(bindings, roleBindingResponse{User: user, Role: toRoleResponse(&target.Roles[i])})
+	}
+	query := s.db.WithContext(c).Preload("Team.Leader.Roles").Preload("Role").Where("user_id = ?", target.ID)
+	if !me.IsAdmin() {
+		// 普通用户看到的范围不超过 Me 自身所在的 Teams。
+		query = query.Where("team_id IN (?)", myTeamIDs(s.db, me.ID))
+	}
+	var scoped []models.TeamRoleBinding
+	if err := query.Order("team_id").Order("role_id").Find(&scoped).Error; err != nil {
+		return err
+	}
+	for i := range scoped {
+		scoped[i].User = target
+		bindings = append(bindings, toRoleBindingResponse(&scoped[i]))
+	}
+	c.JSON(http.StatusOK, listResponse[roleBindingResponse]{Total: int64(len(bindings)), List: bindings})
+	return nil
+}
+
+type addUserRoleRequest struct {
+	RoleID uint `json:"role_id"`
+	// TeamID 不为空时，绑定仅在该 Team 及其 Projects 内生效。
+	TeamID *uint `json:"team_id"`
+}
+
+func (s *Server) addUserRole(c *gin.Context) error {
+	me := currentUser(c)
+	userID, err := pathID(c, "user_id")
+	if err != nil {
+		return err
+	}
+	var req addUserRoleRequest
+	if err := bindJSON(c, &req); err != nil {
+		return err
+	}
+
+	db := s.db.WithContext(c)
+	target, err := loadUser(db, userID)
+	if err != nil {
+		return err
+	}
+	role, err := loadRole(db, req.RoleID)
+	if err != nil {
+		return err
+	}
+	// admin Role 可以授予其他用户，其余 System Roles 由系统自动管理。
+	if role.Type == models.RoleTypeSystem && role.Name != models.RoleAdmin {
+		return badRequest("system role %q is managed by the system and can not be bound manually", role.Name)
+	}
+	if req.TeamID == nil {
+		// 已绑定时什么也不做，也不记录审计日志。
+		var bound bool
+		err := db.Transaction(func(tx *gorm.DB) (err error) {
+			bound, err = exists(tx, &models.UserRole{}, "user_id = ? AND role_id = ?", target.ID, role.ID)
+			if err != nil || bound {
+				return err
+			}
+			if err := tx.Create(&models.UserRole{UserID: target.ID, RoleID: role.ID}).Error; err != nil {
+				return err
+			}
+			if role.Name != models.RoleAdmin {
+				return nil
+			}
+			// 授予 admin 权限的审计日志与绑定一同提交，审计日志写入失败时不授予。
+			return tx.Create(newAudit(c, me, true, "授予了用户 %s admin 权限", describeUser(target))).Error
+		})
+		if err != nil {
+			return err
+		}
+		if !bound && role.Name != models.RoleAdmin {
+			s.audit(c, me, true, "为用户 %s 绑定了%s", describeUser(target), describeRole(role))
+		}
+		c.Status(http.StatusOK)
+		return nil
+	}
+	if role.Name == models.RoleAdmin {
+		return badRequest("the admin role can only be bound globally")
+	}
+	// 限定在 Team 内的绑定只授予 Team 内的权限，见 relations.Granted。
+	if p := globalPermission(rolePermissions(role)); p != "" {
+		return badRequest("role %q has permission %s which only applies globally, bind it without team_id", role.Name, p)
+	}
+
+	var team *models.Team
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if team, err = loadTeam(tx, *req.TeamID); err != nil {
+			return err
+		}
+		member, err := isTeamMember(tx, team.ID, target.ID)
+		if err != nil {
+			return err
+		}
+		if !member {
+			return badRequest("user %d is not a member of team %d", target.ID, team.ID)
+		}
+		return bindTeamRole(tx, team.ID, target.ID, role.ID)
+	})
+	if err != nil {
+		return err
+	}
+
+	s.audit(c, me, true, "在%s内为用户 %s 绑定了%s", describeTeam(team), describeUser(target), describeRole(role))
+	c.Status(http.StatusOK)
+	return nil
+}
+
+func (s *Server) removeUserRole(c *gin.Context) error {
+	me := currentUser(c)
+	userID, err := pathID(c, "user_id")
+	if err != nil {
+		return err
+	}
+	roleID, err := pathID(c, "role_id")
+	if err != nil {
+		return err
+	}
+	teamID, err := queryInt64(c, "team_id")
+	if err != nil {
+		return err
+	}
+
+	db := s.db.WithContext(c)
+	target, err := loadUser(db, userID)
+	if err != nil {
+		return err
+	}
+	role, err := loadRole(db, roleID)
+	if err != nil {
+		return err
+	}
+	if role.Type == models.RoleTypeSystem && role.Name != models.RoleAdmin {
+		return badRequest("system role %q is managed by the system and can not be unbound manually", role.Name)
+	}
+	if role.Name == models.RoleAdmin && teamID == nil {
+		return s.revokeAdmin(c, target)
+	}
+	if teamID != nil {
+		team, err := loadTeam(db, uint(*teamID))
+		if err != nil {
+			return err
+		}
+		result := db.Where("team_id = ? AND user_id = ? AND role_id = ?", team.ID, target.ID, role.ID).Delete(&models.TeamRoleBinding{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return notFound("user %d is not bound to role %d in team %d", target.ID, role.ID, team.ID)
+		}
+		s.audit(c, me, true, "在%s内为用户 %s 解绑了%s", describeTeam(team), describeUser(target), describeRole(role))
+		c.Status(http.StatusOK)
+		return nil
+	}
+
+	result := db.Where("user_id = ? AND role_id = ?", target.ID, role.ID).Delete(&models.UserRole{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return notFound("user %d is not bound to role %d", target.ID, role.ID)
+	}
+
+	s.audit(c, me, true, "为用户 %s 解绑了%s", describeUser(target), describeRole(role))
+	c.Status(http.StatusOK)
+	return nil
+}
+
+// revokeAdmin 解除 target 的 admin Role。初始化时创建的 admin 用户始终保有 admin Role，admin 也不能解除自身的 admin Role。
+// 事务中锁定全部 admin 的绑定，使并发的撤销依次执行：执行者须仍是 admin，解除后系统中仍至少有一名 admin。
+func (s *Server) revokeAdmin(c *gin.Context, target *models.User) error {
+	me := currentUser(c)
+	if err := checkRevokeAdmin(me, target); err != nil {
+		return err
+	}
+	if !target.IsAdmin() {
+		return notFound("user %d is not bound to role %s", target.ID, models.RoleAdmin)
+	}
+	err := s.db.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		roleIDs := tx.Model(&models.Role{}).Select("id").Where("name = ?", models.RoleAdmin)
+		var adminIDs []uint
+		if err := tx.Model(&models.UserRole{}).Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("role_id IN (?)", roleIDs).Pluck("user_id", &adminIDs).Error; err != nil {
+			return err
+		}
+		switch {
+		case !slices.Contains(adminIDs, me.ID):
+			return forbidden("you are no longer an admin")
+		case !slices.Contains(adminIDs, target.ID):
+			return notFound("user %d is not bound to role %s", target.ID, models.RoleAdmin)
+		case len(adminIDs) < 2:
+			return forbidden("at least one admin must remain")
+		}
+		if err := tx.Where("user_id = ? AND role_id IN (?)", target.ID, roleIDs).Delete(&models.UserRole{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(newAudit(c, me, true, "撤销了用户 %s 的 admin 权限", describeUser(target))).Error
+	})
+	if err != nil {
+		return err
+	}
+	c.Status(http.StatusOK)
+	return nil
+}
+
+// loadPathUser 加载路径参数 user_id 指定的用户，Me 对它的权限已由 authorize 校验。
+func (s *Server) loadPathUser(c *gin.Context) (*models.User, error) {
+	userID, err := pathID(c, "user_id")
+	if err != nil {
+		return nil, err
+	}
+	return loadUser(s.db.WithContext(c), userID)
+}
+
+// searchUsers 按 name 参数对 username 与 nickname 同时模糊搜索。
+func searchUsers(c *gin.Context, query *gorm.DB) *gorm.DB {
+	if name := c.Query("name"); name != "" {
+		pattern := likePattern(name)
+		query = query.Where("(users.username LIKE ? OR users.nickname LIKE ?)", pattern, pattern)
+	}
+	return query
+}
+
+func (s *Server) renderUsers(c *gin.Context, query *gorm.DB, p *pagination) error {
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return err
+	}
+	var users []models.User
+	if err := p.apply(query, "users").Preload("Roles").Find(&users).Error; err != nil {
+		return err
+	}
+	c.JSON(http.StatusOK, newListResponse(total, users, toUserResponse))
+	return nil
+}
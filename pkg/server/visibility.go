@@ -0,0 +1,118 @@
+package server
+
+import (
+	"slices"
+
+	"gorm.io/gorm"
+
+	"github.com/dspo/go-homework/pkg/models"
+)
+
+// 用户之间的可见性规则：admin 可见所有人，用户总是可见自己，其余情况要求两者同处于至少一个 Team。
+// 用户列表的过滤（filterVisibleUsers）、visibleUser 授权规则（canSee）与可见性说明接口（judgeVisibility）均由本文件实现，
+// 前两者以 SQL 判断，后者在内存中判断并给出原因，三者的一致性由测试保证。
+
+// 可见或不可见的原因。
+const (
+	visibleAsAdmin   = "admin"
+	visibleAsSelf    = "self"
+	visibleInTeam    = "shared_team"
+	invisibleNoTeams = "no_shared_team"
+)
+
+// visibility 说明 viewer 是否可见 user。
+type visibility struct {
+	viewerID      uint
+	userID        uint
+	reason        string
+	sharedTeamIDs []uint
+}
+
+func (v *visibility) visible() bool {
+	return v.reason != invisibleNoTeams
+}
+
+// visibleUserIDs 返回与 user 同处于至少一个 Team 的用户 ID 子查询。
+func visibleUserIDs(tx *gorm.DB, userID uint) *gorm.DB {
+	return tx.Model(&models.TeamMember{}).Select("user_id").Where("team_id IN (?)", myTeamIDs(tx, userID))
+}
+
+// filterVisibleUsers 将用户查询限定为 me 可见的用户。
+func filterVisibleUsers(tx, query *gorm.DB, me *models.User) *gorm.DB {
+	if me.IsAdmin() {
+		return query
+	}
+	return query.Where("(users.id = ? OR users.id IN (?))", me.ID, visibleUserIDs(tx, me.ID))
+}
+
+// canSee 判断 me 是否可见 target，每次授权都会调用，因此以一条查询判断，不计算原因。
+func canSee(tx *gorm.DB, me *models.User, targetID uint) (bool, error) {
+	if me.IsAdmin() || me.ID == targetID {
+		return true, nil
+	}
+	return exists(tx, &models.TeamMember{}, "user_id = ? AND team_id IN (?)", targetID, myTeamIDs(tx, me.ID))
+}
+
+// explainVisibility 说明 viewer 是否可见 userID 指定的用户及其原因。
+func explainVisibility(tx *gorm.DB, viewer *models.User, userID uint) (*visibility, error) {
+	teams, err := teamsOf(tx, []uint{viewer.ID, userID})
+	if err != nil {
+		return nil, err
+	}
+	return judgeVisibility(viewer, userID, teams), nil
+}
+
+// explainVisibilities 说明 users 中每个用户是否可见其余每个用户，按 users 的顺序两两列出。
+func explainVisibilities(tx *gorm.DB, users []models.User) ([]visibility, error) {
+	userIDs := make([]uint, 0, len(users))
+	for _, user := range users {
+		userIDs = append(userIDs, user.ID)
+	}
+	teams, err := teamsOf(tx, userIDs)
+	if err != nil {
+		return nil, err
+	}
+	list := make([]visibility, 0, len(users)*(len(users)-1))
+	for i := range users {
+		for _, userID := range userIDs {
+			if userID != users[i].ID {
+				list = append(list, *judgeVisibility(&users[i], userID, teams))
+			}
+		}
+	}
+	return list, nil
+}
+
+// teamsOf 返回 userIDs 中每个用户所在 Teams 的 ID，按 ID 升序。
+func teamsOf(tx *gorm.DB, userIDs []uint) (map[uint][]uint, error) {
+	var members []models.TeamMember
+	if err := tx.Where("user_id IN ?", userIDs).Order("team_id").Find(&members).Error; err != nil {
+		return nil, err
+	}
+	teams := make(map[uint][]uint, len(userIDs))
+	for _, m := range members {
+		teams[m.UserID] = append(teams[m.UserID], m.TeamID)
+	}
+	return teams, nil
+}
+
+// judgeVisibility 按可见性规则判断 viewer 是否可见 userID 指定的用户，teams 须包含二者所在的 Teams。
+func judgeVisibility(viewer *models.User, userID uint, teams map[uint][]uint) *visibility {
+	v := &visibility{viewerID: viewer.ID, userID: userID, sharedTeamIDs: []uint{}}
+	for _, teamID := range teams[viewer.ID] {
+		if slices.Contains(teams[userID], teamID) {
+			v.sharedTeamIDs = append(v.sharedTeamIDs, teamID)
+		}
+	}
+	switch {
+	case viewer.IsAdmin():
+		v.reason = visibleAsAdmin
+	case viewer.ID == userID:
+		v.reason = visibleAsSelf
+	case len(v.sharedTeamIDs) > 0:
+		v.reason = visibleInTeam
+	default:
+		v.reason = invisibleNoTeams
+	}
+	return v
+}
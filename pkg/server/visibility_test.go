@@ -0,0 +1,94 @@
+package server
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/dspo/go-homework/pkg/models"
+)
+
+func TestJudgeVisibility(t *testing.T) {
+	admin := &models.User{ID: 1, Roles: []models.Role{{Name: models.RoleAdmin}}}
+	alice := &models.User{ID: 2}
+	teams := map[uint][]uint{
+		alice.ID: {10, 11, 12},
+		3:        {11, 12},
+		4:        {13},
+	}
+	for _, tt := range []struct {
+		viewer *models.User
+		userID uint
+		reason string
+		shared []uint
+	}{
+		{viewer: admin, userID: 4, reason: visibleAsAdmin, shared: []uint{}},
+		{viewer: alice, userID: alice.ID, reason: visibleAsSelf, shared: []uint{10, 11, 12}},
+		{viewer: alice, userID: 3, reason: visibleInTeam, shared: []uint{11, 12}},
+		{viewer: alice, userID: 4, reason: invisibleNoTeams, shared: []uint{}},
+		{viewer: alice, userID: admin.ID, reason: invisibleNoTeams, shared: []uint{}},
+	} {
+		v := judgeVisibility(tt.viewer, tt.userID, teams)
+		if v.reason != tt.reason || !slices.Equal(v.sharedTeamIDs, tt.shared) {
+			t.Errorf("judgeVisibility(%d, %d) = %s %v, want %s %v", tt.viewer.ID, tt.userID, v.reason, v.sharedTeamIDs, tt.reason, tt.shared)
+		}
+		if v.visible() != (tt.reason != invisibleNoTeams) {
+			t.Errorf("judgeVisibility(%d, %d).visible() = %v", tt.viewer.ID, tt.userID, v.visible())
+		}
+	}
+}
+
+// filterVisibleUsers、canSee 与 judgeVisibility 分别实现同一套可见性规则，在同一份数据上的结论须一致。
+func TestVisibilityImplementationsAgree(t *testing.T) {
+	_, db := newTestServer(t)
+	var admin models.User
+	if err := db.Preload("Roles").Where("username = ?", models.AdminUsername).First(&admin).Error; err != nil {
+		t.Fatal(err)
+	}
+	users := []models.User{admin}
+	for _, name := range []string{"alice", "bob", "carol", "dave"} {
+		user := models.User{Username: name, PasswordHash: "x"}
+		if err := db.Create(&user).Error; err != nil {
+			t.Fatal(err)
+		}
+		users = append(users, user)
+	}
+	// alice 与 bob 同在 x，bob 与 carol 同在 y，dave 不在任何 Team。
+	for name, members := range map[string][]int{"x": {1, 2}, "y": {2, 3}, "z": {0}} {
+		team := models.Team{Name: name}
+		if err := db.Create(&team).Error; err != nil {
+			t.Fatal(err)
+		}
+		for _, i := range members {
+			if err := addTeamMember(db, team.ID, users[i].ID); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	userIDs := make([]uint, 0, len(users))
+	for _, user := range users {
+		userIDs = append(userIDs, user.ID)
+	}
+	teams, err := teamsOf(db, userIDs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range users {
+		viewer := &users[i]
+		var listed []uint
+		if err := filterVisibleUsers(db, db.Model(&models.User{}), viewer).Pluck("users.id", &listed).Error; err != nil {
+			t.Fatal(err)
+		}
+		for _, userID := range userIDs {
+			judged := judgeVisibility(viewer, userID, teams).visible()
+			seen, err := canSee(db, viewer, userID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if filtered := slices.Contains(listed, userID); filtered != judged || seen != judged {
+				t.Errorf("visibility of %d to %s: filterVisibleUsers = %v, canSee = %v, judgeVisibility = %v",
+					userID, viewer.Username, filtered, seen, judged)
+			}
+		}
+	}
+}
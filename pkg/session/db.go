@@ -0,0 +1,149 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/dspo/go-homework/pkg/models"
+)
+
+// DBStore 将会话保存在 sessions 表中，多个副本连接同一个数据库即可共享会话。
+//
+// 为减少写入，Get 只在距上次续期超过 renewInterval 时才更新 last_seen_at，
+// 因此空闲超时的实际生效时间最多推迟 renewInterval。
+type DBStore struct {
+	db   *gorm.DB
+	opts Options
+	now  func() time.Time
+}
+
+// NewDBStore 创建使用 db 的 DBStore，要求 sessions 表已由迁移创建。
+func NewDBStore(db *gorm.DB, opts Options) *DBStore {
+	return &DBStore{db: db, opts: opts, now: time.Now}
+}
+
+// timestamp 返回写入数据库的当前时间。统一使用 UTC 并截断到数据库列的毫秒精度，
+// 使 SQLite 中按字符串保存的时间也能正确比较。
+func (s *DBStore) timestamp() time.Time {
+	return s.now().UTC().Truncate(time.Millisecond)
+}
+
+func (s *DBStore) Create(ctx context.Context, userID uint, client Client) (*Session, error) {
+	sess, err := newSession(userID, client, s.timestamp(), s.opts)
+	if err != nil {
+		return nil, err
+	}
+	row := models.Session{
+		ID:         sess.ID,
+		UserID:     sess.UserID,
+		IP:         sess.IP,
+		UserAgent:  sess.UserAgent,
+		CreatedAt:  sess.CreatedAt,
+		LastSeenAt: sess.LastSeenAt,
+		ExpiresAt:  sess.ExpiresAt,
+	}
+	if err := s.db.WithContext(ctx).Create(&row).Error; err != nil {
+		return nil, err
+	}
+	return sess, nil
+}
+
+func (s *DBStore) Get(ctx context.Context, token string) (*Session, error) {
+	db := s.db.WithContext(ctx)
+	id := hashToken(token)
+	now := s.timestamp()
+
+	var row models.Session
+	if err := db.Where("id = ?", id).Take(&row).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	sess := fromModel(&row)
+	sess.Token = token
+	if s.opts.expired(sess, now) {
+		if err := db.Where("id = ?", id).Delete(&models.Session{}).Error; err != nil {
+			return nil, err
+		}
+		return nil, ErrNotFound
+	}
+	if now.Sub(sess.LastSeenAt) >= s.opts.renewInterval() {
+		if err := db.Model(&models.Session{ID: id}).Update("last_seen_at", now).Error; err != nil {
+			return nil, err
+		}
+		sess.LastSeenAt = now
+	}
+	return sess, nil
+}
+
+func (s *DBStore) Delete(ctx context.Context, token string) error {
+	return s.db.WithContext(ctx).Where("id = ?", hashToken(token)).Delete(&models.Session{}).Error
+}
+
+func (s *DBStore) List(ctx context.Context, userID uint) ([]*Session, error) {
+	var rows []models.Session
+	if err := s.valid(ctx).Where("user_id = ?", userID).Order("last_seen_at DESC").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	list := make([]*Session, 0, len(rows))
+	for i := range rows {
+		list = append(list, fromModel(&rows[i]))
+	}
+	return list, nil
+}
+
+func (s *DBStore) DeleteByID(ctx context.Context, userID uint, id string) error {
+	result := s.db.WithContext(ctx).Where("id = ? AND user_id = ?", id, userID).Delete(&models.Session{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *DBStore) DeleteByUser(ctx context.Context, userID uint) error {
+	return s.db.WithContext(ctx).Where("user_id = ?", userID).Delete(&models.Session{}).Error
+}
+
+func (s *DBStore) Count(ctx context.Context) (int64, error) {
+	var n int64
+	err := s.valid(ctx).Count(&n).Error
+	return n, err
+}
+
+// valid 返回只包含未过期会话的查询。
+func (s *DBStore) valid(ctx context.Context) *gorm.DB {
+	now := s.timestamp()
+	query := s.db.WithContext(ctx).Model(&models.Session{}).Where("expires_at > ?", now)
+	if s.opts.IdleTimeout > 0 {
+		query = query.Where("last_seen_at > ?", now.Add(-s.opts.IdleTimeout))
+	}
+	return query
+}
+
+func (s *DBStore) DeleteExpired(ctx context.Context) (int64, error) {
+	now := s.timestamp()
+	query := s.db.WithContext(ctx).Where("expires_at <= ?", now)
+	if s.opts.IdleTimeout > 0 {
+		query = query.Or("last_seen_at <= ?", now.Add(-s.opts.IdleTimeout))
+	}
+	result := query.Delete(&models.Session{})
+	return result.RowsAffected, result.Error
+}
+
+func fromModel(row *models.Session) *Session {
+	return &Session{
+		ID:         row.ID,
+		UserID:     row.UserID,
+		Client:     Client{IP: row.IP, UserAgent: row.UserAgent},
+		CreatedAt:  row.CreatedAt,
+		LastSeenAt: row.LastSeenAt,
+		ExpiresAt:  row.ExpiresAt,
+	}
+}
@@ -0,0 +1,128 @@
+package session
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+)
+
+// MemoryStore 将会话保存在进程内存中，进程重启后会话全部失效，也不能在多个副本间共享。
+type MemoryStore struct {
+	opts Options
+	now  func() time.Time
+
+	mu   sync.Mutex
+	byID map[string]*Session
+}
+
+// NewMemoryStore 创建 MemoryStore。
+func NewMemoryStore(opts Options) *MemoryStore {
+	return &MemoryStore{opts: opts, now: time.Now, byID: make(map[string]*Session)}
+}
+
+func (s *MemoryStore) Create(_ context.Context, userID uint, client Client) (*Session, error) {
+	sess, err := newSession(userID, client, s.now(), s.opts)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stored := *sess
+	stored.Token = ""
+	s.byID[sess.ID] = &stored
+	return sess, nil
+}
+
+func (s *MemoryStore) Get(_ context.Context, token string) (*Session, error) {
+	id := hashToken(token)
+	now := s.now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sess, ok := s.byID[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	if s.opts.expired(sess, now) {
+		delete(s.byID, id)
+		return nil, ErrNotFound
+	}
+	sess.LastSeenAt = now
+	found := *sess
+	found.Token = token
+	return &found, nil
+}
+
+func (s *MemoryStore) Delete(_ context.Context, token string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.byID, hashToken(token))
+	return nil
+}
+
+func (s *MemoryStore) List(_ context.Context, userID uint) ([]*Session, error) {
+	now := s.now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var list []*Session
+	for _, sess := range s.byID {
+		if sess.UserID == userID && !s.opts.expired(sess, now) {
+			found := *sess
+			list = append(list, &found)
+		}
+	}
+	slices.SortFunc(list, func(a, b *Session) int {
+		return b.LastSeenAt.Compare(a.LastSeenAt)
+	})
+	return list, nil
+}
+
+func (s *MemoryStore) DeleteByID(_ context.Context, userID uint, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sess, ok := s.byID[id]
+	if !ok || sess.UserID != userID {
+		return ErrNotFound
+	}
+	delete(s.byID, id)
+	return nil
+}
+
+func (s *MemoryStore) DeleteByUser(_ context.Context, userID uint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, sess := range s.byID {
+		if sess.UserID == userID {
+			delete(s.byID, id)
+		}
+	}
+	return nil
+}
+
+func (s *MemoryStore) Count(context.Context) (int64, error) {
+	now := s.now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var n int64
+	for _, sess := range s.byID {
+		if !s.opts.expired(sess, now) {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (s *MemoryStore) DeleteExpired(context.Context) (int64, error) {
+	now := s.now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var n int64
+	for id, sess := range s.byID {
+		if s.opts.expired(sess, now) {
+			delete(s.byID, id)
+			n++
+		}
+	}
+	return n, nil
+}
@@ -0,0 +1,145 @@
+// Package session 管理登录会话。
+//
+// 会话有两个超时：自创建起计算的绝对超时，以及自最近一次访问起计算的空闲超时。
+// 每次通过 Store.Get 访问会话都会续期空闲超时（滑动过期），但不会超过绝对超时。
+// MemoryStore 只在单个进程内有效；DBStore 将会话保存在数据库中，供多个副本共享。
+package session
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+	"unicode/utf8"
+
+	"gorm.io/gorm"
+
+	"github.com/dspo/go-homework/pkg/config"
+)
+
+// ErrNotFound 表示会话不存在或已过期。
+var ErrNotFound = errors.New("session not found")
+
+// Session 是一个登录会话。
+type Session struct {
+	// Token 是写入 Cookie 的会话令牌。
+	Token string
+	// ID 是 Token 的 SHA-256 摘要，持久化时只保存 ID。
+	ID     string
+	UserID uint
+	Client
+	CreatedAt  time.Time
+	LastSeenAt time.Time
+	// ExpiresAt 是绝对过期时间。
+	ExpiresAt time.Time
+}
+
+// Client 描述创建会话的客户端，供用户辨认自己的各个登录。
+type Client struct {
+	IP        string
+	UserAgent string
+}
+
+// 与 sessions 表的列宽一致，超出部分被截断。
+const (
+	maxIPLength        = 64
+	maxUserAgentLength = 255
+)
+
+// Store 保存会话。
+type Store interface {
+	// Create 为 userID 创建来自 client 的新会话。
+	Create(ctx context.Context, userID uint, client Client) (*Session, error)
+	// Get 返回 token 对应的有效会话并续期其空闲超时，会话不存在或已过期时返回 ErrNotFound。
+	Get(ctx context.Context, token string) (*Session, error)
+	// Delete 删除 token 对应的会话，会话不存在时不返回错误。
+	Delete(ctx context.Context, token string) error
+	// List 返回 userID 的全部有效会话，最近访问的在前。返回的会话不含 Token。
+	List(ctx context.Context, userID uint) ([]*Session, error)
+	// DeleteByID 删除 userID 的 ID 为 id 的会话，会话不存在或属于其他用户时返回 ErrNotFound。
+	DeleteByID(ctx context.Context, userID uint, id string) error
+	// DeleteByUser 删除 userID 的全部会话。
+	DeleteByUser(ctx context.Context, userID uint) error
+	// Count 返回未过期的会话数量。
+	Count(ctx context.Context) (int64, error)
+	// DeleteExpired 清理已过期的会话，返回清理的数量。
+	DeleteExpired(ctx context.Context) (int64, error)
+}
+
+// Options 是会话的超时设置。
+type Options struct {
+	// AbsoluteTimeout 是会话自创建起的最长有效期。
+	AbsoluteTimeout time.Duration
+	// IdleTimeout 是会话在没有访问时的最长有效期，为 0 时不限制。
+	IdleTimeout time.Duration
+}
+
+// New 按 cfg.Store 创建 MemoryStore 或使用 db 的 DBStore。
+func New(cfg config.Session, db *gorm.DB) (Store, error) {
+	opts := Options{AbsoluteTimeout: cfg.AbsoluteTimeout}
+	if cfg.IdleTimeout != nil {
+		opts.IdleTimeout = *cfg.IdleTimeout
+	}
+	switch cfg.Store {
+	case config.SessionStoreMemory:
+		return NewMemoryStore(opts), nil
+	case config.SessionStoreDatabase:
+		return NewDBStore(db, opts), nil
+	default:
+		return nil, fmt.Errorf("unsupported session store %q", cfg.Store)
+	}
+}
+
+// newSession 生成随机令牌并按 opts 计算过期时间。
+func newSession(userID uint, client Client, now time.Time, opts Options) (*Session, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, err
+	}
+	token := hex.EncodeToString(buf)
+	return &Session{
+		Token:      token,
+		ID:         hashToken(token),
+		UserID:     userID,
+		Client:     Client{IP: truncate(client.IP, maxIPLength), UserAgent: truncate(client.UserAgent, maxUserAgentLength)},
+		CreatedAt:  now,
+		LastSeenAt: now,
+		ExpiresAt:  now.Add(opts.AbsoluteTimeout),
+	}, nil
+}
+
+// truncate 将 s 截断为至多 n 个字符。
+func truncate(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n])
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// expired 判断 sess 在 now 时是否已超过绝对超时或空闲超时。
+func (o Options) expired(sess *Session, now time.Time) bool {
+	if !now.Before(sess.ExpiresAt) {
+		return true
+	}
+	return o.IdleTimeout > 0 && !now.Before(sess.LastSeenAt.Add(o.IdleTimeout))
+}
+
+// maxRenewInterval 是 DBStore 续期的最小间隔上限，避免每个请求都写数据库。
+const maxRenewInterval = time.Minute
+
+// renewInterval 返回续期的最小间隔，取空闲超时的 1/10，最多为 maxRenewInterval。
+// 不限制空闲时间时续期只用于记录最近访问时间，同样按 maxRenewInterval 续期。
+func (o Options) renewInterval() time.Duration {
+	if o.IdleTimeout <= 0 {
+		return maxRenewInterval
+	}
+	return min(o.IdleTimeout/10, maxRenewInterval)
+}
@@ -0,0 +1,195 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dspo/go-homework/pkg/config"
+	"github.com/dspo/go-homework/pkg/database"
+	"github.com/dspo/go-homework/pkg/migrate"
+)
+
+var testOptions = Options{AbsoluteTimeout: time.Hour, IdleTimeout: 10 * time.Minute}
+
+// clock 是测试中可手动推进的时钟。
+type clock struct{ now time.Time }
+
+func (c *clock) Now() time.Time          { return c.now }
+func (c *clock) Advance(d time.Duration) { c.now = c.now.Add(d) }
+
+func newDBStore(t *testing.T) *DBStore {
+	t.Helper()
+	db, err := database.Open(config.Database{Name: config.DatabaseSQLite, Path: filepath.Join(t.TempDir(), "session.db")}, nil)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	m, err := migrate.New(db)
+	if err != nil {
+		t.Fatalf("failed to load migrations: %v", err)
+	}
+	if _, err := m.Up(context.Background()); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+	return NewDBStore(db, testOptions)
+}
+
+func TestRenewInterval(t *testing.T) {
+	for idle, want := range map[time.Duration]time.Duration{
+		0:                maxRenewInterval,
+		10 * time.Second: time.Second,
+		2 * time.Hour:    maxRenewInterval,
+	} {
+		if got := (Options{IdleTimeout: idle}).renewInterval(); got != want {
+			t.Errorf("renewInterval() with IdleTimeout %s = %s, want %s", idle, got, want)
+		}
+	}
+}
+
+func TestStores(t *testing.T) {
+	for name, newStore := range map[string]func(t *testing.T, now func() time.Time) Store{
+		"memory": func(_ *testing.T, now func() time.Time) Store {
+			s := NewMemoryStore(testOptions)
+			s.now = now
+			return s
+		},
+		"database": func(t *testing.T, now func() time.Time) Store {
+			s := newDBStore(t)
+			s.now = now
+			return s
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			testStore(t, newStore)
+		})
+	}
+}
+
+func testStore(t *testing.T, newStore func(t *testing.T, now func() time.Time) Store) {
+	ctx := context.Background()
+	clk := &clock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	store := newStore(t, clk.Now)
+
+	get := func(token string) error {
+		t.Helper()
+		_, err := store.Get(ctx, token)
+		return err
+	}
+	count := func() int64 {
+		t.Helper()
+		n, err := store.Count(ctx)
+		if err != nil {
+			t.Fatalf("Count() failed: %v", err)
+		}
+		return n
+	}
+
+	sess, err := store.Create(ctx, 1, Client{IP: "192.0.2.1", UserAgent: "test"})
+	if err != nil {
+		t.Fatalf("Create() failed: %v", err)
+	}
+	got, err := store.Get(ctx, sess.Token)
+	if err != nil || got.UserID != 1 || got.ID != sess.ID || got.IP != "192.0.2.1" || got.UserAgent != "test" {
+		t.Fatalf("Get() = %+v, %v", got, err)
+	}
+	if err := get("no such token"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get() with an unknown token = %v, want ErrNotFound", err)
+	}
+
+	// 每次访问都续期空闲超时，持续访问的会话在绝对超时前一直有效。
+	for elapsed := time.Duration(0); elapsed < 50*time.Minute; elapsed += 5 * time.Minute {
+		clk.Advance(5 * time.Minute)
+		if err := get(sess.Token); err != nil {
+			t.Fatalf("session expired after %s of activity: %v", elapsed, err)
+		}
+	}
+	clk.Advance(10 * time.Minute)
+	if err := get(sess.Token); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get() after the absolute timeout = %v, want ErrNotFound", err)
+	}
+
+	// 超过空闲超时未访问的会话失效。
+	idle, _ := store.Create(ctx, 1, Client{})
+	active, _ := store.Create(ctx, 2, Client{})
+	clk.Advance(6 * time.Minute)
+	if err := get(active.Token); err != nil {
+		t.Fatalf("Get() = %v", err)
+	}
+	clk.Advance(6 * time.Minute)
+	if n := count(); n != 1 {
+		t.Errorf("Count() = %d, want 1", n)
+	}
+	if n, err := store.DeleteExpired(ctx); err != nil || n != 1 {
+		t.Errorf("DeleteExpired() = %d, %v, want 1", n, err)
+	}
+	if err := get(idle.Token); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get() after the idle timeout = %v, want ErrNotFound", err)
+	}
+	if err := get(active.Token); err != nil {
+		t.Errorf("Get() = %v", err)
+	}
+
+	// 列出、删除单个会话与删除某用户的全部会话。
+	a1, _ := store.Create(ctx, 1, Client{})
+	clk.Advance(time.Second)
+	a2, _ := store.Create(ctx, 1, Client{})
+	a3, _ := store.Create(ctx, 1, Client{})
+	list, err := store.List(ctx, 1)
+	if err != nil || len(list) != 3 || list[2].ID != a1.ID || list[0].Token != "" {
+		t.Fatalf("List() = %v, %v, want 3 sessions without tokens, the oldest last", list, err)
+	}
+	if err := store.Delete(ctx, a1.Token); err != nil {
+		t.Fatalf("Delete() failed: %v", err)
+	}
+	if err := get(a1.Token); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get() after Delete() = %v, want ErrNotFound", err)
+	}
+	if err := store.DeleteByID(ctx, 2, a3.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("DeleteByID() with another user = %v, want ErrNotFound", err)
+	}
+	if err := store.DeleteByID(ctx, 1, a3.ID); err != nil {
+		t.Errorf("DeleteByID() failed: %v", err)
+	}
+	if err := get(a3.Token); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get() after DeleteByID() = %v, want ErrNotFound", err)
+	}
+	if err := store.DeleteByUser(ctx, 1); err != nil {
+		t.Fatalf("DeleteByUser() failed: %v", err)
+	}
+	if err := get(a2.Token); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get() after DeleteByUser() = %v, want ErrNotFound", err)
+	}
+	if err := get(active.Token); err != nil {
+		t.Errorf("DeleteByUser() deleted sessions of another user: %v", err)
+	}
+}
+
+// TestDBStoreIsShared 模拟两个副本连接同一个数据库。
+func TestDBStoreIsShared(t *testing.T) {
+	ctx := context.Background()
+	replica1 := newDBStore(t)
+	replica2 := NewDBStore(replica1.db, testOptions)
+
+	sess, err := replica1.Create(ctx, 1, Client{})
+	if err != nil {
+		t.Fatalf("Create() failed: %v", err)
+	}
+	if _, err := replica2.Get(ctx, sess.Token); err != nil {
+		t.Fatalf("session created on one replica is not visible on another: %v", err)
+	}
+	var ids []string
+	if err := replica1.db.Raw("SELECT id FROM sessions").Scan(&ids).Error; err != nil {
+		t.Fatalf("failed to query sessions: %v", err)
+	}
+	if len(ids) != 1 || ids[0] == sess.Token {
+		t.Errorf("sessions table should store the token hash only, got %v", ids)
+	}
+	if err := replica2.DeleteByUser(ctx, 1); err != nil {
+		t.Fatalf("DeleteByUser() failed: %v", err)
+	}
+	if _, err := replica1.Get(ctx, sess.Token); !errors.Is(err, ErrNotFound) {
+		t.Errorf("session deleted on one replica is still valid on another: %v", err)
+	}
+}
@@ -0,0 +1,156 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// DefaultShutdownTimeout 是关闭流程的总时长，与 docker stop 默认宽限期一致。
+const DefaultShutdownTimeout = 10 * time.Second
+
+// ShutdownManager 管理进程退出前的收尾任务。
+//
+// 收到终止信号或被主动调用 Shutdown 后，先将自身标记为关闭中（供就绪检查使用），
+// 等待 readinessDelay 让负载均衡摘除流量，再按注册的逆序（LIFO）执行全部任务。
+// 任务共享总时长 timeout，单个任务失败或超时不会阻止后续任务执行，全部错误汇总后由 Wait 返回。
+type ShutdownManager struct {
+	timeout        time.Duration
+	readinessDelay time.Duration
+
+	mu    sync.Mutex
+	hooks []shutdownHook
+
+	shuttingDown atomic.Bool
+	once         sync.Once
+	done         chan struct{}
+	err          error
+}
+
+type shutdownHook struct {
+	name    string
+	timeout time.Duration
+	fn      func(context.Context) error
+}
+
+// NewShutdownManager 创建总时长为 timeout 的 ShutdownManager，readinessDelay 为开始执行任务前的等待时长。
+func NewShutdownManager(timeout, readinessDelay time.Duration) *ShutdownManager {
+	return &ShutdownManager{
+		timeout:        timeout,
+		readinessDelay: readinessDelay,
+		done:           make(chan struct{}),
+	}
+}
+
+// Register 注册名为 name 的收尾任务，后注册的先执行。
+// timeout 为 0 时任务可使用剩余的全部时长，否则最多执行 timeout。
+func (m *ShutdownManager) Register(name string, timeout time.Duration, fn func(context.Context) error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.hooks = append(m.hooks, shutdownHook{name: name, timeout: timeout, fn: fn})
+}
+
+// Listen 在后台等待 signals 之一（默认 SIGTERM、SIGINT），收到后触发 Shutdown。
+func (m *ShutdownManager) Listen(signals ...os.Signal) {
+	if len(signals) == 0 {
+		signals = []os.Signal{syscall.SIGTERM, syscall.SIGINT}
+	}
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, signals...)
+	go func() {
+		defer signal.Stop(sigCh)
+		select {
+		case sig := <-sigCh:
+			zap.L().Info("received signal, shutting down", zap.Stringer("signal", sig))
+			_ = m.Shutdown()
+		case <-m.done:
+		}
+	}()
+}
+
+// ShuttingDown 在关闭流程开始后返回 true。
+func (m *ShutdownManager) ShuttingDown() bool {
+	return m.shuttingDown.Load()
+}
+
+// Shutdown 开始关闭流程并等待其结束，返回汇总的错误。多次调用只会执行一次。
+func (m *ShutdownManager) Shutdown() error {
+	m.once.Do(m.run)
+	return m.Wait()
+}
+
+// Done 返回在关闭流程结束后关闭的 channel。
+func (m *ShutdownManager) Done() <-chan struct{} {
+	return m.done
+}
+
+// Wait 阻塞直到关闭流程结束，返回汇总的错误。
+func (m *ShutdownManager) Wait() error {
+	<-m.done
+	return m.err
+}
+
+func (m *ShutdownManager) run() {
+	defer close(m.done)
+	m.shuttingDown.Store(true)
+
+	ctx, cancel := context.WithTimeout(context.Background(), m.timeout)
+	defer cancel()
+
+	if m.readinessDelay > 0 {
+		select {
+		case <-time.After(m.readinessDelay):
+		case <-ctx.Done():
+		}
+	}
+
+	m.mu.Lock()
+	hooks := slices.Clone(m.hooks)
+	m.mu.Unlock()
+
+	var errs []error
+	for i := len(hooks) - 1; i >= 0; i-- {
+		hook := hooks[i]
+		start := time.Now()
+		if err := hook.run(ctx); err != nil {
+			zap.L().Error("shutdown hook failed", zap.String("hook", hook.name), zap.Duration("elapsed", time.Since(start)), zap.Error(err))
+			errs = append(errs, fmt.Errorf("%s: %w", hook.name, err))
+			continue
+		}
+		zap.L().Info("shutdown hook done", zap.String("hook", hook.name), zap.Duration("elapsed", time.Since(start)))
+	}
+	m.err = errors.Join(errs...)
+}
+
+// run 执行任务并在超时后返回，不等待不响应 ctx 的任务结束。
+func (h shutdownHook) run(ctx context.Context) error {
+	if h.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, h.timeout)
+		defer cancel()
+	}
+	errCh := make(chan error, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				errCh <- fmt.Errorf("panic: %v", r)
+			}
+		}()
+		errCh <- h.fn(ctx)
+	}()
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,212 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// recorder 记录任务的执行顺序。
+type recorder struct {
+	mu    sync.Mutex
+	names []string
+}
+
+func (r *recorder) hook(name string, err error) func(context.Context) error {
+	return func(context.Context) error {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.names = append(r.names, name)
+		return err
+	}
+}
+
+func (r *recorder) order() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.Clone(r.names)
+}
+
+func waitDone(t *testing.T, m *ShutdownManager) error {
+	t.Helper()
+	select {
+	case <-m.Done():
+		return m.Wait()
+	case <-time.After(5 * time.Second):
+		t.Fatal("shutdown did not finish")
+		return nil
+	}
+}
+
+func TestShutdownOnSignalRunsHooksInReverseOrder(t *testing.T) {
+	m := NewShutdownManager(time.Second, 0)
+	var r recorder
+	m.Register("database", 0, r.hook("database", nil))
+	m.Register("cache", 0, r.hook("cache", nil))
+	m.Register("http server", 0, r.hook("http server", nil))
+	m.Listen(syscall.SIGUSR1)
+
+	if m.ShuttingDown() {
+		t.Fatal("ShuttingDown() = true before receiving a signal")
+	}
+	if err := syscall.Kill(os.Getpid(), syscall.SIGUSR1); err != nil {
+		t.Fatal(err)
+	}
+	if err := waitDone(t, m); err != nil {
+		t.Errorf("Wait() = %v", err)
+	}
+	if got, want := r.order(), []string{"http server", "cache", "database"}; !slices.Equal(got, want) {
+		t.Errorf("hooks ran in %v, want %v", got, want)
+	}
+	if !m.ShuttingDown() {
+		t.Error("ShuttingDown() = false after shutdown")
+	}
+}
+
+func TestShutdownOnSIGTERM(t *testing.T) {
+	m := NewShutdownManager(time.Second, 0)
+	var r recorder
+	m.Register("http server", 0, r.hook("http server", nil))
+	m.Listen()
+
+	if err := syscall.Kill(os.Getpid(), syscall.SIGTERM); err != nil {
+		t.Fatal(err)
+	}
+	if err := waitDone(t, m); err != nil || len(r.order()) != 1 {
+		t.Errorf("Wait() = %v, hooks ran %v", err, r.order())
+	}
+}
+
+func TestShutdownContinuesOnError(t *testing.T) {
+	m := NewShutdownManager(time.Second, 0)
+	var r recorder
+	errClose := errors.New("close failed")
+	m.Register("first", 0, r.hook("first", nil))
+	m.Register("failing", 0, r.hook("failing", errClose))
+	m.Register("panicking", 0, func(context.Context) error { panic("boom") })
+	m.Register("last", 0, r.hook("last", nil))
+
+	err := m.Shutdown()
+	if !errors.Is(err, errClose) {
+		t.Errorf("Shutdown() = %v, want it to wrap %v", err, errClose)
+	}
+	for _, name := range []string{"failing", "panicking"} {
+		if err == nil || !strings.Contains(err.Error(), name) {
+			t.Errorf("error %v does not name hook %q", err, name)
+		}
+	}
+	if got, want := r.order(), []string{"last", "failing", "first"}; !slices.Equal(got, want) {
+		t.Errorf("hooks ran in %v, want %v", got, want)
+	}
+}
+
+func TestShutdownHookTimeout(t *testing.T) {
+	m := NewShutdownManager(time.Second, 0)
+	var r recorder
+	m.Register("after", 0, r.hook("after", nil))
+	m.Register("stuck", 50*time.Millisecond, func(context.Context) error {
+		select {} // 不响应 ctx 的任务也不能拖住后续任务
+	})
+
+	start := time.Now()
+	err := m.Shutdown()
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Shutdown() = %v, want DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("per-hook timeout was not applied, took %s", elapsed)
+	}
+	if got := r.order(); !slices.Equal(got, []string{"after"}) {
+		t.Errorf("hooks after the stuck one ran %v", got)
+	}
+}
+
+func TestShutdownGlobalBudget(t *testing.T) {
+	m := NewShutdownManager(100*time.Millisecond, 0)
+	var (
+		mu        sync.Mutex
+		deadlines []time.Time
+	)
+	for _, name := range []string{"b", "a"} {
+		m.Register(name, time.Minute, func(ctx context.Context) error {
+			deadline, _ := ctx.Deadline()
+			mu.Lock()
+			deadlines = append(deadlines, deadline)
+			mu.Unlock()
+			<-ctx.Done()
+			return ctx.Err()
+		})
+	}
+
+	start := time.Now()
+	err := m.Shutdown()
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("global budget was not applied, took %s", elapsed)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	// 总时长耗尽后，剩余任务不一定有机会执行，但都会出现在汇总的错误中。
+	if !errors.Is(err, context.DeadlineExceeded) || !strings.Contains(err.Error(), "a:") || !strings.Contains(err.Error(), "b:") {
+		t.Fatalf("Shutdown() = %v", err)
+	}
+	for _, deadline := range deadlines {
+		if deadline.After(start.Add(time.Second)) {
+			t.Errorf("hook deadline %s exceeds the global budget", deadline.Sub(start))
+		}
+	}
+}
+
+func TestShutdownFlipsReadinessBeforeHooks(t *testing.T) {
+	m := NewShutdownManager(time.Second, 100*time.Millisecond)
+	ready := make(chan time.Time, 1)
+	m.Register("http server", 0, func(context.Context) error {
+		ready <- time.Now()
+		return nil
+	})
+
+	start := time.Now()
+	go func() { _ = m.Shutdown() }()
+	deadline := time.Now().Add(time.Second)
+	for !m.ShuttingDown() {
+		if time.Now().After(deadline) {
+			t.Fatal("ShuttingDown() never became true")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	select {
+	case <-ready:
+		t.Fatal("hook ran before the readiness delay elapsed")
+	default:
+	}
+	if err := waitDone(t, m); err != nil {
+		t.Fatal(err)
+	}
+	if ranAt := <-ready; ranAt.Sub(start) < 100*time.Millisecond {
+		t.Errorf("hook ran %s after shutdown started, want at least the readiness delay", ranAt.Sub(start))
+	}
+}
+
+func TestShutdownRunsOnce(t *testing.T) {
+	m := NewShutdownManager(time.Second, 0)
+	var r recorder
+	m.Register("once", 0, r.hook("once", nil))
+
+	var wg sync.WaitGroup
+	for range 3 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_ = m.Shutdown()
+		}()
+	}
+	wg.Wait()
+	if got := r.order(); len(got) != 1 {
+		t.Errorf("hook ran %d times", len(got))
+	}
+}
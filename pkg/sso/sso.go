@@ -0,0 +1,149 @@
+// Package sso 通过 OpenID Connect 身份提供方（IdP）实现单点登录。
+//
+// 登录使用授权码模式并启用 PKCE：AuthCodeURL 生成跳转到 IdP 的地址，
+// 用户在 IdP 登录后带着授权码回到本服务，Exchange 用授权码换取并校验 ID Token，返回其中的身份信息。
+// 调用方负责保存每次登录的 state、nonce 与 PKCE verifier，并在回调时核对。
+package sso
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
+
+	"github.com/dspo/go-homework/pkg/config"
+)
+
+// ErrInvalidIDToken 表示 IdP 返回的 ID Token 校验失败或缺少必需的 claim。
+var ErrInvalidIDToken = errors.New("invalid id token")
+
+// Identity 是 ID Token 中描述用户的 claims。
+type Identity struct {
+	// Issuer 与 Subject 一起唯一标识 IdP 中的用户。
+	Issuer        string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	// Username 取自配置的 username_claim，IdP 未提供时为空。
+	Username string
+	Name     string
+}
+
+// Provider 是一个 OIDC 身份提供方。IdP 的端点在首次使用时发现并缓存，
+// 服务启动时 IdP 不可达不影响启动，发现失败时下次使用会重试。
+type Provider struct {
+	cfg config.OIDC
+
+	mu       sync.Mutex
+	oauth2   *oauth2.Config
+	verifier *oidc.IDTokenVerifier
+}
+
+// New 按 cfg 创建 Provider。
+func New(cfg config.OIDC) *Provider {
+	return &Provider{cfg: cfg}
+}
+
+// Login 是一次进行中的登录须保存的参数。
+type Login struct {
+	// State 防止跨站伪造回调，须与回调中的 state 一致。
+	State string
+	// Nonce 绑定 ID Token 与本次登录，防止重放。
+	Nonce string
+	// Verifier 是 PKCE 的 code verifier。
+	Verifier string
+}
+
+// NewLogin 生成一次登录的随机参数。
+func NewLogin() (*Login, error) {
+	state, err := randomString()
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := randomString()
+	if err != nil {
+		return nil, err
+	}
+	return &Login{State: state, Nonce: nonce, Verifier: oauth2.GenerateVerifier()}, nil
+}
+
+func randomString() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// AuthCodeURL 返回将用户跳转到 IdP 登录的地址。
+func (p *Provider) AuthCodeURL(ctx context.Context, login *Login) (string, error) {
+	conf, _, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+	return conf.AuthCodeURL(login.State, oidc.Nonce(login.Nonce), oauth2.S256ChallengeOption(login.Verifier)), nil
+}
+
+// Exchange 用回调中的授权码换取 ID Token，校验其签名、issuer、audience、有效期与 nonce 后返回身份信息。
+func (p *Provider) Exchange(ctx context.Context, login *Login, code string) (*Identity, error) {
+	conf, verifier, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+	token, err := conf.Exchange(ctx, code, oauth2.VerifierOption(login.Verifier))
+	if err != nil {
+		return nil, fmt.Errorf("failed to exchange authorization code: %w", err)
+	}
+	raw, ok := token.Extra("id_token").(string)
+	if !ok {
+		return nil, fmt.Errorf("%w: token response has no id_token", ErrInvalidIDToken)
+	}
+	idToken, err := verifier.Verify(ctx, raw)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidIDToken, err)
+	}
+	if idToken.Nonce != login.Nonce {
+		return nil, fmt.Errorf("%w: nonce mismatch", ErrInvalidIDToken)
+	}
+
+	var claims map[string]any
+	if err := idToken.Claims(&claims); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidIDToken, err)
+	}
+	identity := &Identity{Issuer: idToken.Issuer, Subject: idToken.Subject}
+	identity.Email, _ = claims["email"].(string)
+	identity.EmailVerified, _ = claims["email_verified"].(bool)
+	identity.Username, _ = claims[p.cfg.UsernameClaim].(string)
+	identity.Name, _ = claims["name"].(string)
+	if identity.Subject == "" {
+		return nil, fmt.Errorf("%w: missing sub claim", ErrInvalidIDToken)
+	}
+	return identity, nil
+}
+
+// discover 返回 IdP 的 OAuth2 配置与 ID Token 校验器，首次调用时从 IdP 的 discovery 文档获取。
+func (p *Provider) discover(ctx context.Context) (*oauth2.Config, *oidc.IDTokenVerifier, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.oauth2 != nil {
+		return p.oauth2, p.verifier, nil
+	}
+	provider, err := oidc.NewProvider(ctx, p.cfg.Issuer)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to discover OIDC provider %s: %w", p.cfg.Issuer, err)
+	}
+	p.oauth2 = &oauth2.Config{
+		ClientID:     p.cfg.ClientID,
+		ClientSecret: p.cfg.ClientSecret,
+		RedirectURL:  p.cfg.RedirectURL,
+		Endpoint:     provider.Endpoint(),
+		Scopes:       p.cfg.Scopes,
+	}
+	p.verifier = provider.Verifier(&oidc.Config{ClientID: p.cfg.ClientID})
+	return p.oauth2, p.verifier, nil
+}
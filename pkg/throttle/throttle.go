@@ -0,0 +1,134 @@
+// Package throttle 按主体（账号、IP 等）统计连续的登录失败，失败次数达到阈值后锁定该主体一段时间。
+//
+// 第 MaxFailures 次失败后锁定 Lockout，此后（锁定结束后）每再失败一次锁定时长翻倍，最多为 MaxLockout。
+// 自最近一次失败或锁定结束起超过 Window 没有新的失败，计数清零。
+// 计数保存在 login_failures 表中，多个副本共享。
+package throttle
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/dspo/go-homework/pkg/models"
+)
+
+// Policy 是限流的阈值与时长。
+type Policy struct {
+	MaxFailures int
+	Window      time.Duration
+	Lockout     time.Duration
+	MaxLockout  time.Duration
+}
+
+// Limiter 对同一类主体（如账号）限流，不同 Limiter 的计数通过 name 前缀相互隔离。
+type Limiter struct {
+	db     *gorm.DB
+	name   string
+	policy Policy
+	now    func() time.Time
+}
+
+// New 创建名为 name 的 Limiter，要求 login_failures 表已由迁移创建。
+func New(db *gorm.DB, name string, policy Policy) *Limiter {
+	return &Limiter{db: db, name: name, policy: policy, now: time.Now}
+}
+
+// maxSubjectLength 与 login_failures.subject 的列宽一致。
+const maxSubjectLength = 255
+
+func (l *Limiter) subject(key string) string {
+	subject := l.name + ":" + key
+	if len(subject) > maxSubjectLength {
+		sum := sha256.Sum256([]byte(key))
+		subject = l.name + ":sha256:" + hex.EncodeToString(sum[:])
+	}
+	return subject
+}
+
+// timestamp 返回写入数据库的当前时间，理由同 session.DBStore。
+func (l *Limiter) timestamp() time.Time {
+	return l.now().UTC().Truncate(time.Millisecond)
+}
+
+// Check 返回 key 剩余的锁定时长，未被锁定时返回 0。
+// 每次登录都会调用，多数主体没有失败记录，因此使用 Find 而非 Take，避免 GORM 为 ErrRecordNotFound 打印日志。
+func (l *Limiter) Check(ctx context.Context, key string) (time.Duration, error) {
+	var row models.LoginFailure
+	result := l.db.WithContext(ctx).Where("subject = ?", l.subject(key)).Limit(1).Find(&row)
+	if result.Error != nil || result.RowsAffected == 0 {
+		return 0, result.Error
+	}
+	return remaining(&row, l.timestamp()), nil
+}
+
+// Fail 记录 key 的一次失败，返回当前的连续失败次数，以及本次失败导致的锁定时长（未锁定时为 0）。
+// 计数以一条插入或更新语句原子地递增，并发的失败（包括首次失败）不会丢失。
+func (l *Limiter) Fail(ctx context.Context, key string) (failures int, lockout time.Duration, err error) {
+	subject := l.subject(key)
+	now := l.timestamp()
+	cutoff := now.Add(-l.policy.Window)
+	// 与 DeleteExpired 相同：自最近一次失败或锁定结束起超过 Window，计数从头开始。
+	const expired = "last_failed_at < ? AND (locked_until IS NULL OR locked_until < ?)"
+	err = l.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// MySQL 按顺序执行赋值，后面的赋值读到前面赋值后的值，因此 last_failed_at 须最后更新。
+		upsert := clause.OnConflict{
+			Columns: []clause.Column{{Name: "subject"}},
+			DoUpdates: clause.Set{
+				{Column: clause.Column{Name: "failures"}, Value: gorm.Expr("CASE WHEN "+expired+" THEN 1 ELSE failures + 1 END", cutoff, cutoff)},
+				{Column: clause.Column{Name: "locked_until"}, Value: gorm.Expr("CASE WHEN "+expired+" THEN NULL ELSE locked_until END", cutoff, cutoff)},
+				{Column: clause.Column{Name: "last_failed_at"}, Value: now},
+			},
+		}
+		if err := tx.Clauses(upsert).Create(&models.LoginFailure{Subject: subject, Failures: 1, LastFailedAt: now}).Error; err != nil {
+			return err
+		}
+		// 插入或更新后该行在事务结束前保持锁定，重新读取到的即是本次失败后的计数。
+		var row models.LoginFailure
+		if err := tx.Where("subject = ?", subject).Take(&row).Error; err != nil {
+			return err
+		}
+		failures = row.Failures
+		if failures < l.policy.MaxFailures {
+			return nil
+		}
+		lockout = l.lockoutFor(failures)
+		return tx.Model(&row).Update("locked_until", now.Add(lockout)).Error
+	})
+	return failures, lockout, err
+}
+
+// Reset 清除 key 的失败计数与锁定。
+func (l *Limiter) Reset(ctx context.Context, key string) error {
+	return l.db.WithContext(ctx).Where("subject = ?", l.subject(key)).Delete(&models.LoginFailure{}).Error
+}
+
+// DeleteExpired 清理已过期的计数，返回清理的数量。
+func (l *Limiter) DeleteExpired(ctx context.Context) (int64, error) {
+	cutoff := l.timestamp().Add(-l.policy.Window)
+	result := l.db.WithContext(ctx).
+		Where("subject LIKE ?", l.name+":%").
+		Where("last_failed_at < ? AND (locked_until IS NULL OR locked_until < ?)", cutoff, cutoff).
+		Delete(&models.LoginFailure{})
+	return result.RowsAffected, result.Error
+}
+
+// lockoutFor 返回第 failures 次失败导致的锁定时长。
+func (l *Limiter) lockoutFor(failures int) time.Duration {
+	lockout := l.policy.Lockout
+	for i := l.policy.MaxFailures; i < failures && lockout > 0 && lockout < l.policy.MaxLockout; i++ {
+		lockout *= 2
+	}
+	return min(lockout, l.policy.MaxLockout)
+}
+
+func remaining(row *models.LoginFailure, now time.Time) time.Duration {
+	if row.LockedUntil == nil || !now.Before(*row.LockedUntil) {
+		return 0
+	}
+	return row.LockedUntil.Sub(now)
+}
@@ -0,0 +1,156 @@
+package throttle
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/dspo/go-homework/pkg/config"
+	"github.com/dspo/go-homework/pkg/database"
+	"github.com/dspo/go-homework/pkg/migrate"
+	"github.com/dspo/go-homework/pkg/models"
+)
+
+var testPolicy = Policy{MaxFailures: 3, Window: 15 * time.Minute, Lockout: time.Minute, MaxLockout: 5 * time.Minute}
+
+func openDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := database.Open(config.Database{Name: config.DatabaseSQLite, Path: filepath.Join(t.TempDir(), "throttle.db")}, nil)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	m, err := migrate.New(db)
+	if err != nil {
+		t.Fatalf("failed to load migrations: %v", err)
+	}
+	if _, err := m.Up(context.Background()); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+	return db
+}
+
+func TestLimiter(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	l := New(openDB(t), "account", testPolicy)
+	l.now = func() time.Time { return now }
+
+	fail := func(wantFailures int, wantLockout time.Duration) {
+		t.Helper()
+		failures, lockout, err := l.Fail(ctx, "alice")
+		if err != nil || failures != wantFailures || lockout != wantLockout {
+			t.Fatalf("Fail() = %d, %s, %v, want %d, %s", failures, lockout, err, wantFailures, wantLockout)
+		}
+	}
+	check := func(want time.Duration) {
+		t.Helper()
+		if got, err := l.Check(ctx, "alice"); err != nil || got != want {
+			t.Fatalf("Check() = %s, %v, want %s", got, err, want)
+		}
+	}
+
+	check(0)
+	fail(1, 0)
+	fail(2, 0)
+	check(0)
+	fail(3, time.Minute)
+	check(time.Minute)
+	if got, _ := l.Check(ctx, "bob"); got != 0 {
+		t.Errorf("other keys should not be locked, got %s", got)
+	}
+
+	// 锁定时长每次翻倍，但不超过 MaxLockout。
+	now = now.Add(time.Minute)
+	check(0)
+	fail(4, 2*time.Minute)
+	now = now.Add(2 * time.Minute)
+	fail(5, 4*time.Minute)
+	now = now.Add(4 * time.Minute)
+	fail(6, 5*time.Minute)
+
+	// 锁定结束后超过 Window 没有新的失败，重新计数。
+	now = now.Add(5*time.Minute + testPolicy.Window + time.Second)
+	fail(1, 0)
+
+	if err := l.Reset(ctx, "alice"); err != nil {
+		t.Fatal(err)
+	}
+	fail(1, 0)
+}
+
+// 并发的失败都计入，包括同一主体的首次失败。
+func TestLimiterConcurrentFailures(t *testing.T) {
+	ctx := context.Background()
+	l := New(openDB(t), "account", Policy{MaxFailures: 100, Window: time.Minute, Lockout: time.Minute, MaxLockout: time.Minute})
+	const n = 8
+	errs := make(chan error, n)
+	var wg sync.WaitGroup
+	for range n {
+		wg.Go(func() {
+			_, _, err := l.Fail(ctx, "alice")
+			errs <- err
+		})
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if failures, _, err := l.Fail(ctx, "alice"); err != nil || failures != n+1 {
+		t.Errorf("Fail() after %d concurrent failures = %d, %v, want %d", n, failures, err, n+1)
+	}
+}
+
+func TestLimiterDeleteExpired(t *testing.T) {
+	ctx := context.Background()
+	db := openDB(t)
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	account := New(db, "account", testPolicy)
+	ip := New(db, "ip", testPolicy)
+	for _, l := range []*Limiter{account, ip} {
+		l.now = func() time.Time { return now }
+	}
+
+	for _, key := range []string{"alice", "alice", "alice", "bob"} {
+		if _, _, err := account.Fail(ctx, key); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, _, err := ip.Fail(ctx, "127.0.0.1"); err != nil {
+		t.Fatal(err)
+	}
+
+	// bob 的计数已过期，alice 仍在锁定结束后的 Window 内，ip 的计数不受 account 影响。
+	now = now.Add(testPolicy.Window + time.Second)
+	if n, err := account.DeleteExpired(ctx); err != nil || n != 1 {
+		t.Fatalf("DeleteExpired() = %d, %v, want 1", n, err)
+	}
+	var subjects []string
+	if err := db.Model(&models.LoginFailure{}).Order("subject").Pluck("subject", &subjects).Error; err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(subjects, ","); got != "account:alice,ip:127.0.0.1" {
+		t.Errorf("remaining subjects = %s", got)
+	}
+}
+
+func TestLongKey(t *testing.T) {
+	ctx := context.Background()
+	l := New(openDB(t), "account", testPolicy)
+	key := strings.Repeat("x", 300)
+	for range testPolicy.MaxFailures {
+		if _, _, err := l.Fail(ctx, key); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got, err := l.Check(ctx, key); err != nil || got != testPolicy.Lockout {
+		t.Errorf("Check() = %s, %v, want %s", got, err, testPolicy.Lockout)
+	}
+}
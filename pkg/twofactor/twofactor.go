@@ -0,0 +1,127 @@
+// Package twofactor 实现基于 TOTP（RFC 6238）的两步验证与一次性恢复码。
+//
+// TOTP 使用 6 位数字、30 秒周期与 SHA1，与常见的身份验证器应用兼容；校验时允许前后各一个周期的时钟偏差。
+// 恢复码在用户无法使用身份验证器时代替 TOTP 验证码，每个只能使用一次，数据库中只保存其 SHA-256 摘要。
+package twofactor
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"image/png"
+	"strings"
+	"time"
+
+	"github.com/pquerna/otp"
+	"github.com/pquerna/otp/totp"
+)
+
+// RecoveryCodeCount 是每次生成的恢复码个数。
+const RecoveryCodeCount = 10
+
+// Enrollment 是开始启用两步验证时返回给用户的密钥。
+type Enrollment struct {
+	// Secret 是 base32 编码的 TOTP 密钥，供无法扫描二维码时手动输入。
+	Secret string
+	// URI 是 otpauth:// 格式的配置 URI，身份验证器应用扫描其二维码即可添加账号。
+	URI string
+	// QRCode 是 URI 的二维码，PNG 格式的 data URI。
+	QRCode string
+}
+
+// NewEnrollment 为 account 生成新的 TOTP 密钥，issuer 显示在身份验证器应用中。
+func NewEnrollment(issuer, account string) (*Enrollment, error) {
+	key, err := totp.Generate(totp.GenerateOpts{Issuer: issuer, AccountName: account})
+	if err != nil {
+		return nil, err
+	}
+	img, err := key.Image(256, 256)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return &Enrollment{
+		Secret: key.Secret(),
+		URI:    key.URL(),
+		QRCode: "data:image/png;base64," + base64.StdEncoding.EncodeToString(buf.Bytes()),
+	}, nil
+}
+
+var validateOpts = totp.ValidateOpts{Period: 30, Skew: 1, Digits: otp.DigitsSix, Algorithm: otp.AlgorithmSHA1}
+
+// Validate 判断 code 是否是 secret 在 now 时刻有效的 TOTP 验证码。
+func Validate(secret, code string, now time.Time) bool {
+	_, ok := Match(secret, code, now)
+	return ok
+}
+
+// Match 判断 code 是否是 secret 在 now 时刻有效的 TOTP 验证码，并返回其所在的时间步（Unix 时间除以周期）。
+// 同一时间步的验证码只应接受一次，调用方须记录已接受的时间步以防重放。
+func Match(secret, code string, now time.Time) (step int64, ok bool) {
+	code = strings.TrimSpace(code)
+	if !IsTOTPCode(code) {
+		return 0, false
+	}
+	period := int64(validateOpts.Period)
+	for i := -int64(validateOpts.Skew); i <= int64(validateOpts.Skew); i++ {
+		at := now.Add(time.Duration(i*period) * time.Second)
+		want, err := Code(secret, at)
+		if err == nil && subtle.ConstantTimeCompare([]byte(want), []byte(code)) == 1 {
+			return at.Unix() / period, true
+		}
+	}
+	return 0, false
+}
+
+// Code 返回 secret 在 now 时刻的 TOTP 验证码。
+func Code(secret string, now time.Time) (string, error) {
+	return totp.GenerateCodeCustom(secret, now, validateOpts)
+}
+
+// recoveryAlphabet 不含容易混淆的 0/o、1/l。
+const recoveryAlphabet = "abcdefghijkmnpqrstuvwxyz23456789"
+
+// NewRecoveryCodes 生成 RecoveryCodeCount 个形如 xxxxx-xxxxx 的恢复码，返回恢复码及其摘要。
+func NewRecoveryCodes() (codes, hashes []string, err error) {
+	for range RecoveryCodeCount {
+		buf := make([]byte, 10)
+		if _, err := rand.Read(buf); err != nil {
+			return nil, nil, err
+		}
+		for i, b := range buf {
+			// 256 是 32 的整数倍，取模不会引入偏差。
+			buf[i] = recoveryAlphabet[int(b)%len(recoveryAlphabet)]
+		}
+		code := string(buf[:5]) + "-" + string(buf[5:])
+		codes = append(codes, code)
+		hashes = append(hashes, HashRecoveryCode(code))
+	}
+	return codes, hashes, nil
+}
+
+// HashRecoveryCode 返回恢复码的摘要，忽略大小写、空白与连字符。
+func HashRecoveryCode(code string) string {
+	code = strings.ToLower(strings.Join(strings.Fields(code), ""))
+	sum := sha256.Sum256([]byte(strings.ReplaceAll(code, "-", "")))
+	return hex.EncodeToString(sum[:])
+}
+
+// IsTOTPCode 判断 code 的格式是否是 TOTP 验证码（6 位数字），否则应按恢复码处理。
+func IsTOTPCode(code string) bool {
+	code = strings.TrimSpace(code)
+	if len(code) != validateOpts.Digits.Length() {
+		return false
+	}
+	for _, r := range code {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,76 @@
+package twofactor
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEnrollmentAndValidate(t *testing.T) {
+	e, err := NewEnrollment("go-homework", "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, err := url.Parse(e.URI)
+	if err != nil || u.Scheme != "otpauth" || u.Host != "totp" || u.Query().Get("secret") != e.Secret || u.Query().Get("issuer") != "go-homework" {
+		t.Errorf("unexpected provisioning URI %q", e.URI)
+	}
+	if !strings.HasPrefix(e.QRCode, "data:image/png;base64,") {
+		t.Errorf("QRCode should be a PNG data URI")
+	}
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	code, err := Code(e.Secret, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !IsTOTPCode(code) {
+		t.Errorf("Code() = %q, want 6 digits", code)
+	}
+	for offset, want := range map[time.Duration]bool{0: true, 30 * time.Second: true, -30 * time.Second: true, 90 * time.Second: false} {
+		if got := Validate(e.Secret, code, now.Add(offset)); got != want {
+			t.Errorf("Validate() at %s = %v, want %v", offset, got, want)
+		}
+	}
+	if Validate(e.Secret, "not a code", now) {
+		t.Error("Validate() should reject malformed codes")
+	}
+
+	step := now.Unix() / 30
+	for offset, want := range map[time.Duration]int64{0: step, 30 * time.Second: step, -30 * time.Second: step} {
+		if got, ok := Match(e.Secret, code, now.Add(offset)); !ok || got != want {
+			t.Errorf("Match() at %s = %d, %v, want %d", offset, got, ok, want)
+		}
+	}
+	next, err := Code(e.Secret, now.Add(30*time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, ok := Match(e.Secret, next, now); !ok || got != step+1 {
+		t.Errorf("Match() of the next code = %d, %v, want %d", got, ok, step+1)
+	}
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	codes, hashes, err := NewRecoveryCodes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(codes) != RecoveryCodeCount || len(hashes) != RecoveryCodeCount {
+		t.Fatalf("got %d codes and %d hashes", len(codes), len(hashes))
+	}
+	seen := make(map[string]bool)
+	for i, code := range codes {
+		if len(code) != 11 || code[5] != '-' || IsTOTPCode(code) {
+			t.Errorf("unexpected recovery code %q", code)
+		}
+		if seen[code] {
+			t.Errorf("duplicated recovery code %q", code)
+		}
+		seen[code] = true
+		if HashRecoveryCode(" "+strings.ToUpper(strings.ReplaceAll(code, "-", ""))+" ") != hashes[i] {
+			t.Errorf("HashRecoveryCode should ignore case, spaces and hyphens")
+		}
+	}
+}
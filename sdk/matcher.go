@@ -18,14 +18,28 @@ var (
 type Error struct {
 	// StatusCode is the HTTP status code.
 	StatusCode int `json:"-"`
+	// RetryAfter is the number of seconds to wait before retrying, taken from the Retry-After header, 0 if absent.
+	RetryAfter int `json:"-"`
 	// Error_ is the error details, it's exclusive with Payload.
 	Error_ string `json:"error,omitempty"`
+	// Violations lists the violated password policy rules when a password is rejected.
+	Violations []PasswordViolation `json:"violations,omitempty"`
 }
 
 func (e Error) Error() string {
 	return fmt.Sprintf("status code: %d, error: %s", e.StatusCode, e.Error_)
 }
 
+// TwoFactorRequiredError is returned by login when the account has two-factor authentication enabled
+// and no TOTP secret of it is registered by RegisterTOTPSecret. Complete the login by CompleteLogin.
+type TwoFactorRequiredError struct {
+	LoginChallenge
+}
+
+func (e *TwoFactorRequiredError) Error() string {
+	return "two-factor authentication required"
+}
+
 type (
 	HaveOccurredWithStatusCode   int
 	HaveOccurredWithErrorMsg     string
@@ -2,6 +2,7 @@ package sdk
 
 import (
 	"net/url"
+	"slices"
 	"strconv"
 	"time"
 )
@@ -24,6 +25,83 @@ type Role struct {
 	Name string  `json:"name"`
 	Type string  `json:"type"` // System or Custom
 	Desc *string `json:"desc,omitempty"`
+	// Permissions is only returned by the roles API, not in the roles of a user
+	Permissions []string `json:"permissions,omitempty"`
+}
+
+// RoleBinding represents a role bound to a user, globally or within a team
+type RoleBinding struct {
+	User User `json:"user"`
+	Role Role `json:"role"`
+	// Team is nil for a global binding
+	Team *Team `json:"team,omitempty"`
+}
+
+// RoleBindingsListResponse represents a role bindings list response
+type RoleBindingsListResponse struct {
+	Total int           `json:"total"`
+	List  []RoleBinding `json:"list"`
+}
+
+// Permission represents a permission that can be granted to a custom role
+type Permission struct {
+	Name string `json:"name"`
+	Desc string `json:"desc"`
+	// TeamScoped reports whether the permission can be granted by a role binding scoped to a team,
+	// in which case it applies to that team and its projects only
+	TeamScoped bool `json:"team_scoped"`
+}
+
+// ResourcePermissions represents the actions the current user can perform on a resource,
+// the actions are the operation ids of the API, e.g. updateTeam
+type ResourcePermissions struct {
+	Resource string   `json:"resource"`
+	Actions  []string `json:"actions"`
+	// Error tells why the resource has no actions when it does not exist
+	Error string `json:"error,omitempty"`
+}
+
+// Allows reports whether the current user can perform action on the resource
+func (p *ResourcePermissions) Allows(action string) bool {
+	return slices.Contains(p.Actions, action)
+}
+
+// ResourcePermissionsListResponse represents a resource permissions list response
+type ResourcePermissionsListResponse struct {
+	Total int                   `json:"total"`
+	List  []ResourcePermissions `json:"list"`
+}
+
+// UserResource returns the resource of a user for the permissions API
+func UserResource(userID int) string {
+	return "users/" + strconv.Itoa(userID)
+}
+
+// TeamResource returns the resource of a team for the permissions API
+func TeamResource(teamID int) string {
+	return "teams/" + strconv.Itoa(teamID)
+}
+
+// ProjectResource returns the resource of a project for the permissions API
+func ProjectResource(projectID int) string {
+	return "projects/" + strconv.Itoa(projectID)
+}
+
+// Visibility explains whether the viewer can see the user: admin can see everyone, a user can always see themselves,
+// otherwise they must share at least one team
+type Visibility struct {
+	ViewerID int  `json:"viewer_id"`
+	UserID   int  `json:"user_id"`
+	Visible  bool `json:"visible"`
+	// Reason is one of admin, self, shared_team and no_shared_team
+	Reason        string `json:"reason"`
+	SharedTeamIDs []int  `json:"shared_team_ids"`
+}
+
+// VisibilityListResponse represents a visibility report response
+type VisibilityListResponse struct {
+	Total int          `json:"total"`
+	List  []Visibility `json:"list"`
 }
 
 // Team represents a team model
@@ -57,9 +135,32 @@ type Project struct {
 type AuditLog struct {
 	ID        int    `json:"id"`
 	Content   string `json:"content"`
+	RequestID string `json:"request_id,omitempty"`
 	CreatedAt int64  `json:"created_at"`
 }
 
+// Session represents a login session of the current user
+type Session struct {
+	ID         string `json:"id"`
+	Current    bool   `json:"current"`
+	IP         string `json:"ip"`
+	UserAgent  string `json:"user_agent"`
+	CreatedAt  int64  `json:"created_at"`
+	LastSeenAt int64  `json:"last_seen_at"`
+	ExpiresAt  int64  `json:"expires_at"`
+}
+
+// AccessToken represents a personal access token of the current user
+type AccessToken struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+	// Token is the secret, it is only returned once on creation
+	Token      string `json:"token,omitempty"`
+	CreatedAt  int64  `json:"created_at"`
+	ExpiresAt  int64  `json:"expires_at"`
+	LastUsedAt *int64 `json:"last_used_at,omitempty"`
+}
+
 // ListResponse represents a paginated list response
 type ListResponse struct {
 	Total int `json:"total"`
@@ -90,12 +191,30 @@ type RolesListResponse struct {
 	List  []Role `json:"list"`
 }
 
+// PermissionsListResponse represents a permissions list response
+type PermissionsListResponse struct {
+	Total int          `json:"total"`
+	List  []Permission `json:"list"`
+}
+
 // AuditsListResponse represents an audit logs list response
 type AuditsListResponse struct {
 	Total int        `json:"total"`
 	List  []AuditLog `json:"list"`
 }
 
+// SessionsListResponse represents a sessions list response
+type SessionsListResponse struct {
+	Total int       `json:"total"`
+	List  []Session `json:"list"`
+}
+
+// AccessTokensListResponse represents a personal access tokens list response
+type AccessTokensListResponse struct {
+	Total int           `json:"total"`
+	List  []AccessToken `json:"list"`
+}
+
 // LoginWithUsername represents a login request with username
 type LoginWithUsername struct {
 	Username string `json:"username"`
@@ -121,6 +240,73 @@ type UpdatePasswordRequest struct {
 	NewPassword string `json:"new_password"`
 }
 
+// CreateAccessTokenRequest represents a create personal access token request
+type CreateAccessTokenRequest struct {
+	Name string `json:"name"`
+	// ExpiresInDays defaults to 30 days when nil
+	ExpiresInDays *int `json:"expires_in_days,omitempty"`
+}
+
+// PasswordViolation is a password policy rule violated by a password
+type PasswordViolation struct {
+	// Rule is the name of the rule in the password_policy config, e.g. min_length, required_classes, banned, history
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// LoginChallenge is returned by login when the account has two-factor authentication enabled
+type LoginChallenge struct {
+	// Challenge is submitted with a TOTP code or a recovery code to complete the login
+	Challenge string `json:"challenge"`
+	ExpiresAt int64  `json:"expires_at"`
+}
+
+// CompleteLoginRequest represents a request to complete a two-step login
+type CompleteLoginRequest struct {
+	Challenge string `json:"challenge"`
+	// Code is a TOTP code or a recovery code
+	Code string `json:"code"`
+}
+
+// TwoFactorCodeRequest carries a TOTP code or a recovery code to confirm a two-factor operation
+type TwoFactorCodeRequest struct {
+	Code string `json:"code"`
+}
+
+// TwoFactorStatus represents the two-factor authentication status of a user
+type TwoFactorStatus struct {
+	Enabled bool `json:"enabled"`
+	// Required is true if the user must enable two-factor authentication, i.e. the user is admin
+	Required               bool `json:"required"`
+	RecoveryCodesRemaining int  `json:"recovery_codes_remaining"`
+}
+
+// TwoFactorEnrollment is a newly generated TOTP secret
+type TwoFactorEnrollment struct {
+	Secret string `json:"secret"`
+	// ProvisioningURI is the otpauth:// URI to be added to an authenticator app
+	ProvisioningURI string `json:"provisioning_uri"`
+	// QRCode is a PNG data URI of the QR code of ProvisioningURI
+	QRCode string `json:"qr_code"`
+}
+
+// RecoveryCodes are one-time codes to complete a two-step login without the authenticator
+type RecoveryCodes struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// ResetPasswordRequest represents an admin password reset request
+type ResetPasswordRequest struct {
+	// Password is generated by the server when empty
+	Password string `json:"password,omitempty"`
+}
+
+// PasswordReset is the result of an admin password reset
+type PasswordReset struct {
+	// Password is the temporary password, it is only returned once
+	Password string `json:"password"`
+}
+
 // CreateUserRequest represents a request to create a user
 type CreateUserRequest struct {
 	Username string `json:"username"`
@@ -173,8 +359,16 @@ type PatchProjectRequest struct {
 
 // CreateRoleRequest represents a request to create a role
 type CreateRoleRequest struct {
-	Name string  `json:"name"`
-	Desc *string `json:"desc,omitempty"`
+	Name        string   `json:"name"`
+	Desc        *string  `json:"desc,omitempty"`
+	Permissions []string `json:"permissions,omitempty"`
+}
+
+// UpdateRoleRequest represents a request to update a custom role; nil fields are left unchanged
+type UpdateRoleRequest struct {
+	Name        *string   `json:"name,omitempty"`
+	Desc        *string   `json:"desc,omitempty"`
+	Permissions *[]string `json:"permissions,omitempty"`
 }
 
 // AddUserToTeamRequest represents a request to add a user to a team
@@ -190,6 +384,8 @@ type AddUserToProjectRequest struct {
 // AddRoleToUserRequest represents a request to add a role to a user
 type AddRoleToUserRequest struct {
 	RoleID int `json:"role_id"`
+	// TeamID limits the binding to a team and its projects
+	TeamID *int `json:"team_id,omitempty"`
 }
 
 // ListParams represents common list query parameters
@@ -12,8 +12,10 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/pkg/errors"
+	"github.com/pquerna/otp/totp"
 )
 
 // SDK is the client interface
@@ -22,9 +24,25 @@ type SDK interface {
 	LoginWithUsername(username, password string) (UserClient, error)
 	// LoginWithEmail logs in with email and returns a user-scoped client
 	LoginWithEmail(email, password string) (UserClient, error)
+	// CompleteLogin completes a two-step login with a TOTP code or a recovery code.
+	// The challenge is taken from the *TwoFactorRequiredError returned by LoginWithUsername or LoginWithEmail.
+	CompleteLogin(challenge, code string) (UserClient, error)
+	// LoginWithOIDC logs in through the OpenID Connect identity provider configured on the server,
+	// following the redirects of the authorization code flow. The identity provider must sign the user in
+	// without interaction, e.g. a stand-in provider in tests.
+	LoginWithOIDC() (UserClient, error)
+	// RegisterTOTPSecret registers the TOTP secret of an account, i.e. a username or an email.
+	// Logins of the account then complete the two-step login with a generated code automatically.
+	// The server accepts each TOTP code only once, so such logins reuse the session of the previous
+	// automatic login of the account with the same password while it is still valid.
+	RegisterTOTPSecret(account, secret string)
 	Guest() UserClient
+	// WithAccessToken returns a client authenticated with a personal access token
+	WithAccessToken(token string) UserClient
 	// Healthz performs health check
 	Healthz() error
+	// Metrics scrapes /metrics and returns the Prometheus text exposition
+	Metrics() (string, error)
 }
 
 // UserClient 表示携带指定用户登录态的客户端。
@@ -63,6 +81,39 @@ type MeAPI interface {
 	ListProjects(params *ListParams) (*ProjectsListResponse, error)
 	// ExitProject exits from a project
 	ExitProject(projectID int) error
+	// ListSessions lists current user's active sessions
+	ListSessions() (*SessionsListResponse, error)
+	// GetSession gets one of current user's sessions
+	GetSession(sessionID string) (*Session, error)
+	// RevokeSession revokes one of current user's sessions
+	RevokeSession(sessionID string) error
+	// RevokeAllSessions revokes all sessions of current user, i.e. logs out everywhere
+	RevokeAllSessions() error
+	// ListAccessTokens lists current user's personal access tokens
+	ListAccessTokens() (*AccessTokensListResponse, error)
+	// CreateAccessToken creates a personal access token, the secret is only returned once
+	CreateAccessToken(req *CreateAccessTokenRequest) (*AccessToken, error)
+	// RevokeAccessToken revokes a personal access token
+	RevokeAccessToken(tokenID int) error
+	// GetTwoFactor gets the two-factor authentication status of current user
+	GetTwoFactor() (*TwoFactorStatus, error)
+	// EnrollTwoFactor generates a new TOTP secret, it takes effect after ActivateTwoFactor
+	EnrollTwoFactor() (*TwoFactorEnrollment, error)
+	// ActivateTwoFactor enables two-factor authentication with a code from the enrolled secret
+	// and returns the recovery codes, they are only returned once
+	ActivateTwoFactor(code string) (*RecoveryCodes, error)
+	// DisableTwoFactor disables two-factor authentication with a TOTP code or a recovery code, admin can not disable it
+	DisableTwoFactor(code string) error
+	// RegenerateRecoveryCodes replaces all recovery codes with new ones
+	RegenerateRecoveryCodes(code string) (*RecoveryCodes, error)
+	// Permissions gets the actions current user can perform on a resource, e.g. TeamResource(12),
+	// an empty resource means the actions not on a specific resource, e.g. createTeam
+	Permissions(resource string) (*ResourcePermissions, error)
+	// BatchPermissions gets the actions current user can perform on each of the resources
+	BatchPermissions(resources ...string) (*ResourcePermissionsListResponse, error)
+	// LinkOIDC signs in to the OpenID Connect identity provider configured on the server
+	// and links the identity to current user, so that current user can log in with it
+	LinkOIDC() (*User, error)
 }
 
 // UsersAPI provides user management operations
@@ -79,10 +130,32 @@ type UsersAPI interface {
 	ListTeams(userID int, params *ListParams) (*TeamsListResponse, error)
 	// ListProjects gets user's projects list
 	ListProjects(userID int) (*ProjectsListResponse, error)
+	// ListRoles lists the global and team-scoped role bindings of a user
+	ListRoles(userID int) (*RoleBindingsListResponse, error)
 	// AddRole adds a role to user
 	AddRole(userID, roleID int) error
 	// RemoveRole removes a role from user
 	RemoveRole(userID, roleID int) error
+	// AddTeamRole binds a role to user within a team and its projects (admin only)
+	AddTeamRole(userID, roleID, teamID int) error
+	// RemoveTeamRole removes a role bound to user within a team (admin only)
+	RemoveTeamRole(userID, roleID, teamID int) error
+	// RevokeSessions revokes all sessions and personal access tokens of a user (admin only)
+	RevokeSessions(userID int) error
+	// Unlock clears the login lockout of a user caused by failed login attempts (admin only)
+	Unlock(userID int) error
+	// UnlockIP clears the login lockout of a client IP caused by failed login attempts (admin only)
+	UnlockIP(ip string) error
+	// ResetPassword sets a temporary password for a user who must change it on next login (admin only).
+	// The server generates one if password is empty.
+	ResetPassword(userID int, password string) (*PasswordReset, error)
+	// ResetTwoFactor disables two-factor authentication of a user who lost the authenticator (admin only)
+	ResetTwoFactor(userID int) error
+	// GetVisibility explains whether the viewer can see the user and why, the viewer is current user if viewerID is nil,
+	// only admin can specify another viewer
+	GetVisibility(userID int, viewerID *int) (*Visibility, error)
+	// VisibilityReport lists the visibility between each pair of the users (admin only)
+	VisibilityReport(userIDs ...int) (*VisibilityListResponse, error)
 }
 
 // TeamsAPI provides team management operations
@@ -101,6 +174,8 @@ type TeamsAPI interface {
 	Delete(teamID int) error
 	// ListUsers gets team members list
 	ListUsers(teamID int, params *ListParams) (*UsersListResponse, error)
+	// ListRoles lists the role bindings scoped to a team
+	ListRoles(teamID int) (*RoleBindingsListResponse, error)
 	// AddUser adds a user to team
 	AddUser(teamID, userID int) error
 	// RemoveUser removes a user from team
@@ -135,8 +210,12 @@ type RolesAPI interface {
 	List() (*RolesListResponse, error)
 	// Create creates a role
 	Create(req *CreateRoleRequest) (*Role, error)
+	// Update updates a custom role
+	Update(roleID int, req *UpdateRoleRequest) (*Role, error)
 	// Delete deletes a role
 	Delete(roleID int) error
+	// Permissions lists the permissions that can be granted to custom roles
+	Permissions() (*PermissionsListResponse, error)
 }
 
 // AuditsAPI provides audit log operations
@@ -158,16 +237,35 @@ func NewSDK(addr string) SDK {
 }
 
 func initSDK(addr string) {
+	globalSDK = newSDK(addr)
+}
+
+// NewClient creates an SDK instance for addr. Unlike NewSDK it is not a singleton,
+// e.g. to talk to another server started in the same process.
+func NewClient(addr string) SDK {
+	return newSDK(addr)
+}
+
+func newSDK(addr string) *sdk {
 	jar, _ := cookiejar.New(nil)
 	baseURL, _ := url.Parse(strings.TrimRight(addr, "/"))
-	globalSDK = &sdk{
+	return &sdk{
 		baseURL: baseURL,
 		client: &http.Client{
 			Jar: jar,
 		},
+		totpSecrets:  new(sync.Map),
+		totpSessions: new(sync.Map),
 	}
 }
 
+// NewSDKWithToken creates a client authenticated with a personal access token
+// instead of a login session. Unlike NewSDK it is not a singleton.
+func NewSDKWithToken(addr, token string) UserClient {
+	baseURL, _ := url.Parse(strings.TrimRight(addr, "/"))
+	return (&sdk{baseURL: baseURL}).WithAccessToken(token)
+}
+
 // GetSDK returns the global SDK singleton
 func GetSDK() SDK {
 	if globalSDK == nil {
@@ -181,6 +279,12 @@ type sdk struct {
 	client  *http.Client
 	// cookieScope 记录最近一次更新 Cookie 时使用的 URL，用于复制登录态。
 	cookieScope *url.URL
+	// token 不为空时，请求通过 Authorization: Bearer 携带个人访问令牌。
+	token string
+	// totpSecrets 保存 RegisterTOTPSecret 注册的账号到 TOTP 密钥的映射，由派生的客户端共享。
+	totpSecrets *sync.Map
+	// totpSessions 保存自动完成两步验证的登录，键为账号与密码，由派生的客户端共享。
+	totpSessions *sync.Map
 }
 
 func (s *sdk) Me() MeAPI {
@@ -214,9 +318,9 @@ func (s *sdk) cookieURLForJar(base *url.URL) *url.URL {
 		return nil
 	}
 	u := *base
-	if u.Path == "" {
-		u.Path = "/"
-	}
+	// 复制到新 Cookie jar 的 Cookie 不保留 Path 属性，其作用范围取决于这里的 URL，
+	// 因此总是使用根路径，避免登录接口的路径（如 /api/login/2fa）限制了 Cookie 的作用范围。
+	u.Path = "/"
 	u.RawQuery = ""
 	u.Fragment = ""
 	return &u
@@ -245,8 +349,10 @@ func (s *sdk) cloneWithCookies() UserClient {
 	newClient.Jar = newJar
 
 	return &sdk{
-		baseURL: baseCopy,
-		client:  &newClient,
+		baseURL:      baseCopy,
+		client:       &newClient,
+		totpSecrets:  s.totpSecrets,
+		totpSessions: s.totpSessions,
 	}
 }
 
@@ -295,6 +401,11 @@ func doRequest[T any](s *sdk, method, pathStr string, body any) (*T, error) {
 	if body != nil {
 		req.Header.Set("Content-Type", "application/json")
 	}
+	if s.token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.token)
+	} else {
+		s.setCSRFToken(req)
+	}
 
 	resp, err := s.client.Do(req)
 	if err != nil {
@@ -311,6 +422,7 @@ func doRequest[T any](s *sdk, method, pathStr string, body any) (*T, error) {
 
 	if resp.StatusCode >= 400 {
 		var e = &Error{StatusCode: resp.StatusCode}
+		e.RetryAfter, _ = strconv.Atoi(resp.Header.Get("Retry-After"))
 		if err = json.Unmarshal(respBody, e); err != nil {
 			e.Error_ = errors.Wrapf(err, "response body: %s", string(respBody)).Error()
 		}
@@ -327,6 +439,25 @@ func doRequest[T any](s *sdk, method, pathStr string, body any) (*T, error) {
 	return &out, nil
 }
 
+const (
+	csrfCookieName = "csrf_token"
+	csrfHeader     = "X-CSRF-Token"
+)
+
+// setCSRFToken 为使用 Cookie 认证的写请求附上登录时服务端下发的 CSRF token，
+// 与浏览器中页面脚本的做法相同。
+func (s *sdk) setCSRFToken(req *http.Request) {
+	if req.Method == http.MethodGet || req.Method == http.MethodHead || s.client == nil || s.client.Jar == nil {
+		return
+	}
+	for _, cookie := range s.client.Jar.Cookies(req.URL) {
+		if cookie.Name == csrfCookieName {
+			req.Header.Set(csrfHeader, cookie.Value)
+			return
+		}
+	}
+}
+
 // =============== Authentication implementations ===============
 
 func (s *sdk) LoginWithUsername(username, password string) (UserClient, error) {
@@ -334,10 +465,7 @@ func (s *sdk) LoginWithUsername(username, password string) (UserClient, error) {
 		Username: username,
 		Password: password,
 	}
-	if _, err := doRequest[struct{}](s, http.MethodPost, "/api/login", req); err != nil {
-		return nil, err
-	}
-	return s.cloneWithCookies(), nil
+	return s.login(username, password, req)
 }
 
 func (s *sdk) LoginWithEmail(email, password string) (UserClient, error) {
@@ -345,17 +473,126 @@ func (s *sdk) LoginWithEmail(email, password string) (UserClient, error) {
 		Email:    email,
 		Password: password,
 	}
-	if _, err := doRequest[struct{}](s, http.MethodPost, "/api/login", req); err != nil {
+	return s.login(email, password, req)
+}
+
+// login 在服务端要求两步验证时，使用 account 注册的 TOTP 密钥自动完成登录，未注册时返回 *TwoFactorRequiredError。
+// 服务端只接受每个 TOTP 验证码一次，因此自动完成两步验证的登录仍然有效时直接复用，而不是等待下一个验证码。
+func (s *sdk) login(account, password string, req any) (UserClient, error) {
+	key := account + "\x00" + password
+	if s.totpSessions != nil {
+		if cached, ok := s.totpSessions.Load(key); ok {
+			client := cached.(*sdk)
+			if _, err := client.Me().Get(); err == nil {
+				return client.cloneWithCookies(), nil
+			}
+			s.totpSessions.Delete(key)
+		}
+	}
+
+	challenge, err := doRequest[LoginChallenge](s, http.MethodPost, "/api/login", req)
+	if err != nil {
+		return nil, err
+	}
+	if challenge.Challenge == "" {
+		return s.cloneWithCookies(), nil
+	}
+	var secret any
+	var ok bool
+	if s.totpSecrets != nil {
+		secret, ok = s.totpSecrets.Load(account)
+	}
+	if !ok {
+		return nil, &TwoFactorRequiredError{LoginChallenge: *challenge}
+	}
+	code, err := TOTPCode(secret.(string))
+	if err != nil {
+		return nil, err
+	}
+	client, err := s.CompleteLogin(challenge.Challenge, code)
+	if err == nil && s.totpSessions != nil {
+		s.totpSessions.Store(key, client)
+	}
+	return client, err
+}
+
+func (s *sdk) CompleteLogin(challenge, code string) (UserClient, error) {
+	req := CompleteLoginRequest{
+		Challenge: challenge,
+		Code:      code,
+	}
+	if _, err := doRequest[struct{}](s, http.MethodPost, "/api/login/2fa", req); err != nil {
+		return nil, err
+	}
+	return s.cloneWithCookies(), nil
+}
+
+func (s *sdk) LoginWithOIDC() (UserClient, error) {
+	challenge, err := doRequest[LoginChallenge](s, http.MethodGet, "/api/login/oidc", nil)
+	if err != nil {
 		return nil, err
 	}
+	if challenge.Challenge != "" {
+		return nil, &TwoFactorRequiredError{LoginChallenge: *challenge}
+	}
 	return s.cloneWithCookies(), nil
 }
 
+func (s *sdk) RegisterTOTPSecret(account, secret string) {
+	if s.totpSecrets == nil {
+		s.totpSecrets = new(sync.Map)
+	}
+	s.totpSecrets.Store(account, secret)
+}
+
+// totpPeriod 是 TOTP 验证码的周期，与服务端一致。
+const totpPeriod = 30
+
+var (
+	totpMu sync.Mutex
+	// totpSteps 记录每个 TOTP 密钥最近一次生成的验证码所在的时间步。
+	totpSteps = make(map[string]int64)
+)
+
+// TOTPCode generates a TOTP code of secret that has not been generated before, as an authenticator app would.
+// The server accepts each code only once: when the code of the current time step was already generated,
+// TOTPCode returns the code of the next step, which the server accepts within its clock skew, and waits
+// for the next step if that one was generated as well.
+func TOTPCode(secret string) (string, error) {
+	totpMu.Lock()
+	defer totpMu.Unlock()
+	last := totpSteps[secret]
+	step := time.Now().Unix() / totpPeriod
+	if last > step {
+		time.Sleep(time.Until(time.Unix(last*totpPeriod, 0)))
+		step = last
+	}
+	if last >= step {
+		step = last + 1
+	}
+	code, err := totp.GenerateCode(secret, time.Unix(step*totpPeriod, 0))
+	if err != nil {
+		return "", err
+	}
+	totpSteps[secret] = step
+	return code, nil
+}
+
 func (s *sdk) Guest() UserClient {
 	return &sdk{
-		baseURL:     s.baseURL,
-		client:      new(http.Client),
-		cookieScope: s.cookieScope,
+		baseURL:      s.baseURL,
+		client:       new(http.Client),
+		cookieScope:  s.cookieScope,
+		totpSecrets:  s.totpSecrets,
+		totpSessions: s.totpSessions,
+	}
+}
+
+func (s *sdk) WithAccessToken(token string) UserClient {
+	return &sdk{
+		baseURL: s.baseURL,
+		client:  new(http.Client),
+		token:   token,
 	}
 }
 
@@ -369,6 +606,23 @@ func (s *sdk) Healthz() error {
 	return err
 }
 
+func (s *sdk) Metrics() (string, error) {
+	resp, err := s.client.Get(s.baseURL.JoinPath("/metrics").String())
+	if err != nil {
+		return "", fmt.Errorf("do request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("read response: %w", err)
+	}
+	if resp.StatusCode >= 400 {
+		return "", &Error{StatusCode: resp.StatusCode, Error_: string(body)}
+	}
+	return string(body), nil
+}
+
 // =============== Me implementations ===============
 
 type meAPI struct {
@@ -425,6 +679,95 @@ func (m *meAPI) ExitProject(projectID int) error {
 	return err
 }
 
+func (m *meAPI) ListSessions() (*SessionsListResponse, error) {
+	resp, err := doRequest[SessionsListResponse](m.sdk, http.MethodGet, "/api/me/sessions", nil)
+	return resp, err
+}
+
+func (m *meAPI) GetSession(sessionID string) (*Session, error) {
+	pathStr := path.Join("/api/me/sessions", url.PathEscape(sessionID))
+	resp, err := doRequest[Session](m.sdk, http.MethodGet, pathStr, nil)
+	return resp, err
+}
+
+func (m *meAPI) RevokeSession(sessionID string) error {
+	pathStr := path.Join("/api/me/sessions", url.PathEscape(sessionID))
+	_, err := doRequest[struct{}](m.sdk, http.MethodDelete, pathStr, nil)
+	return err
+}
+
+func (m *meAPI) RevokeAllSessions() error {
+	_, err := doRequest[struct{}](m.sdk, http.MethodDelete, "/api/me/sessions", nil)
+	return err
+}
+
+func (m *meAPI) ListAccessTokens() (*AccessTokensListResponse, error) {
+	resp, err := doRequest[AccessTokensListResponse](m.sdk, http.MethodGet, "/api/me/tokens", nil)
+	return resp, err
+}
+
+func (m *meAPI) CreateAccessToken(req *CreateAccessTokenRequest) (*AccessToken, error) {
+	resp, err := doRequest[AccessToken](m.sdk, http.MethodPost, "/api/me/tokens", req)
+	return resp, err
+}
+
+func (m *meAPI) RevokeAccessToken(tokenID int) error {
+	pathStr := path.Join("/api/me/tokens", strconv.Itoa(tokenID))
+	_, err := doRequest[struct{}](m.sdk, http.MethodDelete, pathStr, nil)
+	return err
+}
+
+func (m *meAPI) GetTwoFactor() (*TwoFactorStatus, error) {
+	return doRequest[TwoFactorStatus](m.sdk, http.MethodGet, "/api/me/2fa", nil)
+}
+
+func (m *meAPI) EnrollTwoFactor() (*TwoFactorEnrollment, error) {
+	return doRequest[TwoFactorEnrollment](m.sdk, http.MethodPost, "/api/me/2fa", nil)
+}
+
+func (m *meAPI) ActivateTwoFactor(code string) (*RecoveryCodes, error) {
+	return doRequest[RecoveryCodes](m.sdk, http.MethodPost, "/api/me/2fa/activate", TwoFactorCodeRequest{Code: code})
+}
+
+func (m *meAPI) DisableTwoFactor(code string) error {
+	_, err := doRequest[struct{}](m.sdk, http.MethodDelete, "/api/me/2fa", TwoFactorCodeRequest{Code: code})
+	return err
+}
+
+func (m *meAPI) RegenerateRecoveryCodes(code string) (*RecoveryCodes, error) {
+	return doRequest[RecoveryCodes](m.sdk, http.MethodPost, "/api/me/2fa/recovery-codes", TwoFactorCodeRequest{Code: code})
+}
+
+func (m *meAPI) Permissions(resource string) (*ResourcePermissions, error) {
+	resp, err := m.BatchPermissions(resource)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.List) != 1 {
+		return nil, fmt.Errorf("expected permissions of 1 resource, got %d", len(resp.List))
+	}
+	return &resp.List[0], nil
+}
+
+func (m *meAPI) BatchPermissions(resources ...string) (*ResourcePermissionsListResponse, error) {
+	query := make(url.Values)
+	for _, resource := range resources {
+		query.Add("resource", resource)
+	}
+	pathURL := &url.URL{
+		Path:     "/api/me/permissions",
+		RawQuery: query.Encode(),
+	}
+	return doRequest[ResourcePermissionsListResponse](m.sdk, http.MethodGet, pathURL.String(), nil)
+}
+
+func (m *meAPI) LinkOIDC() (*User, error) {
+	// 回调会清除 state Cookie，在副本上完成跳转，避免以回调的 Cookie 替换当前的登录态。
+	clone := m.sdk.cloneWithCookies().(*sdk)
+	clone.token = m.sdk.token
+	return doRequest[User](clone, http.MethodPost, "/api/me/identities/oidc", nil)
+}
+
 // =============== Users implementations ===============
 
 type usersAPI struct {
@@ -478,6 +821,12 @@ func (u *usersAPI) ListProjects(userID int) (*ProjectsListResponse, error) {
 	return resp, err
 }
 
+func (u *usersAPI) ListRoles(userID int) (*RoleBindingsListResponse, error) {
+	pathStr := path.Join("/api/users", strconv.Itoa(userID), "roles")
+	resp, err := doRequest[RoleBindingsListResponse](u.sdk, http.MethodGet, pathStr, nil)
+	return resp, err
+}
+
 func (u *usersAPI) AddRole(userID, roleID int) error {
 	pathStr := path.Join("/api/users", strconv.Itoa(userID), "roles")
 	req := AddRoleToUserRequest{RoleID: roleID}
@@ -491,6 +840,75 @@ func (u *usersAPI) RemoveRole(userID, roleID int) error {
 	return err
 }
 
+func (u *usersAPI) AddTeamRole(userID, roleID, teamID int) error {
+	pathStr := path.Join("/api/users", strconv.Itoa(userID), "roles")
+	req := AddRoleToUserRequest{RoleID: roleID, TeamID: &teamID}
+	_, err := doRequest[struct{}](u.sdk, http.MethodPost, pathStr, req)
+	return err
+}
+
+func (u *usersAPI) RemoveTeamRole(userID, roleID, teamID int) error {
+	pathURL := &url.URL{
+		Path:     path.Join("/api/users", strconv.Itoa(userID), "roles", strconv.Itoa(roleID)),
+		RawQuery: url.Values{"team_id": {strconv.Itoa(teamID)}}.Encode(),
+	}
+	_, err := doRequest[struct{}](u.sdk, http.MethodDelete, pathURL.String(), nil)
+	return err
+}
+
+func (u *usersAPI) RevokeSessions(userID int) error {
+	pathStr := path.Join("/api/users", strconv.Itoa(userID), "sessions")
+	_, err := doRequest[struct{}](u.sdk, http.MethodDelete, pathStr, nil)
+	return err
+}
+
+func (u *usersAPI) Unlock(userID int) error {
+	pathStr := path.Join("/api/users", strconv.Itoa(userID), "lockout")
+	_, err := doRequest[struct{}](u.sdk, http.MethodDelete, pathStr, nil)
+	return err
+}
+
+func (u *usersAPI) UnlockIP(ip string) error {
+	pathStr := path.Join("/api/ips", url.PathEscape(ip), "lockout")
+	_, err := doRequest[struct{}](u.sdk, http.MethodDelete, pathStr, nil)
+	return err
+}
+
+func (u *usersAPI) ResetPassword(userID int, password string) (*PasswordReset, error) {
+	pathStr := path.Join("/api/users", strconv.Itoa(userID), "password-reset")
+	return doRequest[PasswordReset](u.sdk, http.MethodPost, pathStr, ResetPasswordRequest{Password: password})
+}
+
+func (u *usersAPI) ResetTwoFactor(userID int) error {
+	pathStr := path.Join("/api/users", strconv.Itoa(userID), "2fa")
+	_, err := doRequest[struct{}](u.sdk, http.MethodDelete, pathStr, nil)
+	return err
+}
+
+func (u *usersAPI) GetVisibility(userID int, viewerID *int) (*Visibility, error) {
+	query := make(url.Values)
+	if viewerID != nil {
+		query.Set("viewer_id", strconv.Itoa(*viewerID))
+	}
+	pathURL := &url.URL{
+		Path:     path.Join("/api/users", strconv.Itoa(userID), "visibility"),
+		RawQuery: query.Encode(),
+	}
+	return doRequest[Visibility](u.sdk, http.MethodGet, pathURL.String(), nil)
+}
+
+func (u *usersAPI) VisibilityReport(userIDs ...int) (*VisibilityListResponse, error) {
+	query := make(url.Values)
+	for _, userID := range userIDs {
+		query.Add("user_id", strconv.Itoa(userID))
+	}
+	pathURL := &url.URL{
+		Path:     "/api/visibility",
+		RawQuery: query.Encode(),
+	}
+	return doRequest[VisibilityListResponse](u.sdk, http.MethodGet, pathURL.String(), nil)
+}
+
 // =============== Teams implementations ===============
 
 type teamsAPI struct {
@@ -560,6 +978,12 @@ func (t *teamsAPI) ListUsers(teamID int, params *ListParams) (*UsersListResponse
 	return resp, err
 }
 
+func (t *teamsAPI) ListRoles(teamID int) (*RoleBindingsListResponse, error) {
+	pathStr := path.Join("/api/teams", strconv.Itoa(teamID), "roles")
+	resp, err := doRequest[RoleBindingsListResponse](t.sdk, http.MethodGet, pathStr, nil)
+	return resp, err
+}
+
 func (t *teamsAPI) AddUser(teamID, userID int) error {
 	pathStr := path.Join("/api/teams", strconv.Itoa(teamID), "users")
 	req := AddUserToTeamRequest{UserID: userID}
@@ -658,12 +1082,23 @@ func (r *rolesAPI) Create(req *CreateRoleRequest) (*Role, error) {
 	return role, err
 }
 
+func (r *rolesAPI) Update(roleID int, req *UpdateRoleRequest) (*Role, error) {
+	pathStr := path.Join("/api/roles", strconv.Itoa(roleID))
+	role, err := doRequest[Role](r.sdk, http.MethodPut, pathStr, req)
+	return role, err
+}
+
 func (r *rolesAPI) Delete(roleID int) error {
 	pathStr := path.Join("/api/roles", strconv.Itoa(roleID))
 	_, err := doRequest[struct{}](r.sdk, http.MethodDelete, pathStr, nil)
 	return err
 }
 
+func (r *rolesAPI) Permissions() (*PermissionsListResponse, error) {
+	resp, err := doRequest[PermissionsListResponse](r.sdk, http.MethodGet, "/api/permissions", nil)
+	return resp, err
+}
+
 // =============== Audits implementations ===============
 
 type auditsAPI struct {
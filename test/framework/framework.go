@@ -13,8 +13,13 @@ import (
 	"github.com/onsi/gomega"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/yaml"
+
+	"github.com/dspo/go-homework/pkg/config"
+	"github.com/dspo/go-homework/pkg/database"
 )
 
 const (
@@ -27,8 +32,14 @@ var (
 
 	//go:embed manifests/mysql.yaml
 	_mysqlSpec string
+
+	//go:embed manifests/configmap.yaml
+	_configMapSpec string
 )
 
+// appConfigKey 是 ConfigMap 中 app0 所挂载的配置文件的 key。
+const appConfigKey = "app_config.yaml"
+
 var (
 	_f *Framework
 )
@@ -43,6 +54,7 @@ func init() {
 
 type Framework struct {
 	scaffold *KubernetesScaffold
+	config   *config.Config
 	db       *gorm.DB
 	g        *gomega.GomegaWithT
 	t        *testing.T
@@ -64,6 +76,11 @@ func (f *Framework) DB() *gorm.DB {
 	return f.db
 }
 
+// Config 返回 app0 所使用的配置，与部署时挂载的 ConfigMap 一致。
+func (f *Framework) Config() *config.Config {
+	return f.config
+}
+
 func (f *Framework) DeployComponents() {
 	f.deployDatabase()
 	f.initDatabase()
@@ -82,7 +99,7 @@ func (f *Framework) deployDatabase() {
 
 func (f *Framework) initDatabase() {
 	f.t.Log("it is going to init MySQL")
-	db, err := sql.Open("mysql", "root:changeme@tcp(mysql:3306)/")
+	db, err := sql.Open("mysql", f.config.Database.ServerDSN())
 	f.g.Ω(err).ShouldNot(gomega.HaveOccurred())
 
 	defer func() { _ = db.Close() }()
@@ -91,15 +108,20 @@ func (f *Framework) initDatabase() {
 	f.g.Ω(err).ShouldNot(gomega.HaveOccurred())
 
 	// try to drop database
-	_, err = db.Exec("DROP DATABASE IF EXISTS `go_dev`")
+	_, err = db.Exec(fmt.Sprintf("DROP DATABASE IF EXISTS `%s`", f.config.Database.DBName))
 	f.g.Ω(err).ShouldNot(gomega.HaveOccurred())
 
-	_, err = db.Exec("CREATE DATABASE IF NOT EXISTS `go_dev`")
+	_, err = db.Exec(fmt.Sprintf("CREATE DATABASE IF NOT EXISTS `%s`", f.config.Database.DBName))
 	f.g.Ω(err).ShouldNot(gomega.HaveOccurred())
 }
 
 func (f *Framework) connectDatabase() {
-	// todo: connection database using gorm
+	f.t.Log("it is going to connect MySQL")
+	db, err := database.Open(f.config.Database, nil)
+	f.g.Ω(err).ShouldNot(gomega.HaveOccurred())
+
+	f.db = db
+	_f.db = db
 }
 
 func (f *Framework) deployApp0() {
@@ -107,10 +129,13 @@ func (f *Framework) deployApp0() {
 	err := k8s.KubectlApplyFromStringE(f.t, f.scaffold.kubectlOptions, _appSpec)
 	f.g.Ω(err).ShouldNot(gomega.HaveOccurred())
 
-	err = f.ensureServiceWithTimeout(f.t.Context(), "app0", f.scaffold.kubectlOptions.Namespace, 1, 30)
+	// app0 在迁移与初始化数据完成后才就绪。
+	err = f.ensureServiceWithTimeout(f.t.Context(), "app0", f.scaffold.kubectlOptions.Namespace, 1, 60)
 	f.g.Ω(err).ShouldNot(gomega.HaveOccurred())
 }
 
+// ensureServiceWithTimeout 等待 Service 的就绪 endpoints 数量达到 desiredEndpoints。
+// endpoint 的 Ready 状态由 Pod 的 readinessProbe 决定（app0 为 /readyz），正在终止的 endpoint 不计入。
 func (f *Framework) ensureServiceWithTimeout(ctx context.Context, name, namespace string, desiredEndpoints, timeout int) error {
 	backoff := wait.Backoff{
 		Duration: 6 * time.Second,
@@ -138,7 +163,8 @@ func (f *Framework) ensureServiceWithTimeout(ctx context.Context, name, namespac
 		var es = endpointSliceList.Items[0]
 		count := 0
 		for _, ep := range es.Endpoints {
-			if ep.Conditions.Ready != nil && *ep.Conditions.Ready {
+			if ep.Conditions.Ready != nil && *ep.Conditions.Ready &&
+				(ep.Conditions.Terminating == nil || !*ep.Conditions.Terminating) {
 				count += len(ep.Addresses)
 			}
 		}
@@ -177,5 +203,23 @@ func newFramework() (*Framework, error) {
 		return nil, err
 	}
 
+	_f.config, err = loadAppConfig()
+	if err != nil {
+		return nil, err
+	}
+
 	return _f, nil
 }
+
+// loadAppConfig 从 manifests/configmap.yaml 中读取 app0 的配置，使测试框架与应用共用同一份配置。
+func loadAppConfig() (*config.Config, error) {
+	var cm corev1.ConfigMap
+	if err := yaml.Unmarshal([]byte(_configMapSpec), &cm); err != nil {
+		return nil, fmt.Errorf("failed to decode configmap: %w", err)
+	}
+	data, ok := cm.Data[appConfigKey]
+	if !ok {
+		return nil, fmt.Errorf("configmap %s has no key %s", cm.Name, appConfigKey)
+	}
+	return config.Parse([]byte(data))
+}
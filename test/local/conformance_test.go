@@ -0,0 +1,30 @@
+// Package local 在进程内以嵌入式 SQLite 启动服务并运行一致性测试，不依赖 kind、MySQL 等外部服务。
+package local
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/onsi/ginkgo/v2"
+	"github.com/onsi/gomega"
+
+	_ "github.com/dspo/go-homework/conformance"
+	"github.com/dspo/go-homework/pkg/config"
+	"github.com/dspo/go-homework/pkg/server"
+	"github.com/dspo/go-homework/pkg/session"
+	"github.com/dspo/go-homework/sdk"
+)
+
+func TestConformance(t *testing.T) {
+	db := openDatabase(t)
+	sessions := session.NewDBStore(db, session.Options{
+		AbsoluteTimeout: config.DefaultSessionAbsoluteTimeout,
+		IdleTimeout:     config.DefaultSessionIdleTimeout,
+	})
+	ts := httptest.NewServer(server.New(db, sessions, server.WithLoginThrottle(config.DefaultLoginThrottle)).Handler())
+	defer ts.Close()
+	var _ = sdk.NewSDK(ts.URL)
+
+	gomega.RegisterFailHandler(ginkgo.Fail)
+	ginkgo.RunSpecs(t, "Conformance Suite (SQLite)")
+}
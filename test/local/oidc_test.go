@@ -0,0 +1,320 @@
+package local
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"gorm.io/gorm"
+
+	"github.com/dspo/go-homework/pkg/bootstrap"
+	"github.com/dspo/go-homework/pkg/config"
+	"github.com/dspo/go-homework/pkg/database"
+	"github.com/dspo/go-homework/pkg/migrate"
+	"github.com/dspo/go-homework/pkg/models"
+	"github.com/dspo/go-homework/pkg/password"
+	"github.com/dspo/go-homework/pkg/server"
+	"github.com/dspo/go-homework/pkg/session"
+	"github.com/dspo/go-homework/sdk"
+	"github.com/dspo/go-homework/test/framework/idp"
+)
+
+const (
+	oidcClientID     = "go-homework"
+	oidcClientSecret = "go-homework-secret"
+)
+
+// openDatabase 打开一个已迁移并完成初始化的 SQLite 数据库。
+func openDatabase(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := database.Open(config.Database{
+		Name: config.DatabaseSQLite,
+		Path: filepath.Join(t.TempDir(), "app.db"),
+	}, nil)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	migrator, err := migrate.New(db)
+	if err != nil {
+		t.Fatalf("failed to load migrations: %v", err)
+	}
+	if _, err := migrator.Up(context.Background()); err != nil {
+		t.Fatalf("failed to migrate database: %v", err)
+	}
+	if _, err := bootstrap.Run(context.Background(), db); err != nil {
+		t.Fatalf("failed to bootstrap database: %v", err)
+	}
+	return db
+}
+
+// startOIDCServer 启动一个使用 provider 单点登录的服务，回调地址取决于监听地址，因此先监听再创建 Server。
+func startOIDCServer(t *testing.T, db *gorm.DB, provider *idp.IdP, autoProvision bool, opts ...server.Option) string {
+	t.Helper()
+	ts := httptest.NewUnstartedServer(nil)
+	t.Cleanup(ts.Close)
+	addr := "http://" + ts.Listener.Addr().String()
+	sessions := session.NewDBStore(db, session.Options{
+		AbsoluteTimeout: config.DefaultSessionAbsoluteTimeout,
+		IdleTimeout:     config.DefaultSessionIdleTimeout,
+	})
+	opts = append(opts, server.WithOIDC(config.OIDC{
+		Enabled:       true,
+		Issuer:        provider.Issuer(),
+		ClientID:      oidcClientID,
+		ClientSecret:  oidcClientSecret,
+		RedirectURL:   addr + "/api/login/oidc/callback",
+		Scopes:        config.DefaultOIDCScopes,
+		UsernameClaim: config.DefaultOIDCUsernameClaim,
+		AutoProvision: autoProvision,
+	}))
+	ts.Config.Handler = server.New(db, sessions, opts...).Handler()
+	ts.Start()
+	return addr
+}
+
+func newIdP(t *testing.T) *idp.IdP {
+	t.Helper()
+	provider, err := idp.New(oidcClientID, oidcClientSecret)
+	if err != nil {
+		t.Fatalf("failed to start identity provider: %v", err)
+	}
+	t.Cleanup(provider.Close)
+	return provider
+}
+
+// createLocalUser 直接在数据库中创建一个有本地密码的用户。
+func createLocalUser(t *testing.T, db *gorm.DB, username, email, plain string) *models.User {
+	t.Helper()
+	hash, err := password.Hash(plain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := &models.User{Username: username, Email: &email, PasswordHash: hash}
+	if err := db.Create(user).Error; err != nil {
+		t.Fatal(err)
+	}
+	return user
+}
+
+func wantStatus(t *testing.T, err error, status int) {
+	t.Helper()
+	var e *sdk.Error
+	if !errors.As(err, &e) || e.StatusCode != status {
+		t.Fatalf("error = %v, want status %d", err, status)
+	}
+}
+
+func TestOIDCAutoProvision(t *testing.T) {
+	provider := newIdP(t)
+	addr := startOIDCServer(t, openDatabase(t), provider, true)
+	alice := idp.User{Subject: "alice-sub", Email: "alice@example.com", EmailVerified: true, PreferredUsername: "alice", Name: "Alice"}
+
+	provider.SignIn(alice)
+	client, err := sdk.NewClient(addr).LoginWithOIDC()
+	if err != nil {
+		t.Fatalf("LoginWithOIDC() failed: %v", err)
+	}
+	me, err := client.Me().Get()
+	if err != nil {
+		t.Fatalf("Me().Get() failed: %v", err)
+	}
+	if me.Username != "alice" || me.Email == nil || *me.Email != alice.Email || me.Nickname == nil || *me.Nickname != alice.Name {
+		t.Errorf("provisioned user = %+v, want the claims of the identity", me)
+	}
+	if len(me.Roles) != 1 || me.Roles[0].Name != models.RoleNormalUser {
+		t.Errorf("roles = %+v, want only %q", me.Roles, models.RoleNormalUser)
+	}
+	// 没有本地密码的用户不受首次登录须修改密码的限制。
+	if _, err := client.Me().ListTeams(nil); err != nil {
+		t.Errorf("SSO users should not be asked to change the password: %v", err)
+	}
+	wantStatus(t, client.Me().UpdatePassword("old password 1", "new password 1"), http.StatusBadRequest)
+	_, err = sdk.NewClient(addr).LoginWithUsername("alice", "any password")
+	wantStatus(t, err, http.StatusUnauthorized)
+
+	// 再次登录映射到同一用户。
+	again, err := sdk.NewClient(addr).LoginWithOIDC()
+	if err != nil {
+		t.Fatalf("LoginWithOIDC() failed: %v", err)
+	}
+	if me2, err := again.Me().Get(); err != nil || me2.ID != me.ID {
+		t.Errorf("second login = %+v, %v, want user %d", me2, err, me.ID)
+	}
+
+	// 用户名已被占用时不能自动创建。
+	provider.SignIn(idp.User{Subject: "another-alice", PreferredUsername: "alice"})
+	_, err = sdk.NewClient(addr).LoginWithOIDC()
+	wantStatus(t, err, http.StatusConflict)
+}
+
+func TestOIDCLinkByEmail(t *testing.T) {
+	provider := newIdP(t)
+	db := openDatabase(t)
+	addr := startOIDCServer(t, db, provider, false)
+	email := "bob@example.com"
+	// bob 没有本地密码，可以按邮箱关联。
+	bob := models.User{Username: "bob", Email: &email}
+	if err := db.Create(&bob).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	// 未开启自动创建时，未知的身份不能登录。
+	provider.SignIn(idp.User{Subject: "carol-sub", Email: "carol@example.com", EmailVerified: true, PreferredUsername: "carol"})
+	_, err := sdk.NewClient(addr).LoginWithOIDC()
+	wantStatus(t, err, http.StatusForbidden)
+
+	// 未经 IdP 验证的邮箱不能关联已有用户。
+	provider.SignIn(idp.User{Subject: "bob-sub", Email: email, PreferredUsername: "bob"})
+	_, err = sdk.NewClient(addr).LoginWithOIDC()
+	wantStatus(t, err, http.StatusForbidden)
+
+	provider.SignIn(idp.User{Subject: "bob-sub", Email: email, EmailVerified: true, PreferredUsername: "bob"})
+	client, err := sdk.NewClient(addr).LoginWithOIDC()
+	if err != nil {
+		t.Fatalf("LoginWithOIDC() failed: %v", err)
+	}
+	if me, err := client.Me().Get(); err != nil || me.ID != int(bob.ID) {
+		t.Fatalf("Me().Get() = %+v, %v, want user %d", me, err, bob.ID)
+	}
+	var link models.UserIdentity
+	if err := db.Where("issuer = ? AND subject = ?", provider.Issuer(), "bob-sub").First(&link).Error; err != nil || link.UserID != bob.ID {
+		t.Errorf("identity link = %+v, %v, want user %d", link, err, bob.ID)
+	}
+}
+
+func TestOIDCLinkByEmailRejectsLocalAccounts(t *testing.T) {
+	provider := newIdP(t)
+	db := openDatabase(t)
+	addr := startOIDCServer(t, db, provider, true)
+	createLocalUser(t, db, "dave", "dave@example.com", "dave password 1")
+
+	// 有本地密码的用户与 admin 不按邮箱关联，也不会以该邮箱另建用户。
+	for _, email := range []string{"dave@example.com", "admin@example.com"} {
+		if email == "admin@example.com" {
+			if err := db.Model(&models.User{}).Where("username = ?", models.AdminUsername).Update("email", email).Error; err != nil {
+				t.Fatal(err)
+			}
+		}
+		provider.SignIn(idp.User{Subject: "sub-" + email, Email: email, EmailVerified: true, PreferredUsername: "mallory"})
+		_, err := sdk.NewClient(addr).LoginWithOIDC()
+		wantStatus(t, err, http.StatusForbidden)
+	}
+	var links int64
+	if err := db.Model(&models.UserIdentity{}).Count(&links).Error; err != nil || links != 0 {
+		t.Errorf("identity links = %d, %v, want none", links, err)
+	}
+}
+
+func TestOIDCLinkIdentity(t *testing.T) {
+	provider := newIdP(t)
+	db := openDatabase(t)
+	addr := startOIDCServer(t, db, provider, true)
+	dave := createLocalUser(t, db, "dave", "dave@example.com", "dave password 1")
+	identity := idp.User{Subject: "dave-sub", Email: "dave@example.com", EmailVerified: true, PreferredUsername: "dave"}
+
+	client, err := sdk.NewClient(addr).LoginWithUsername("dave", "dave password 1")
+	if err != nil {
+		t.Fatalf("LoginWithUsername() failed: %v", err)
+	}
+	// 个人访问令牌不能用来关联身份。
+	token, err := client.Me().CreateAccessToken(&sdk.CreateAccessTokenRequest{Name: "ci"})
+	if err != nil {
+		t.Fatalf("CreateAccessToken() failed: %v", err)
+	}
+	provider.SignIn(identity)
+	_, err = sdk.NewSDKWithToken(addr, token.Token).Me().LinkOIDC()
+	wantStatus(t, err, http.StatusForbidden)
+
+	linked, err := client.Me().LinkOIDC()
+	if err != nil {
+		t.Fatalf("LinkOIDC() failed: %v", err)
+	}
+	if linked.ID != int(dave.ID) {
+		t.Errorf("LinkOIDC() = user %d, want %d", linked.ID, dave.ID)
+	}
+	// 关联后仍保持原来的登录态，并可通过单点登录登录。
+	if _, err := client.Me().Get(); err != nil {
+		t.Errorf("Me().Get() after linking failed: %v", err)
+	}
+	sso, err := sdk.NewClient(addr).LoginWithOIDC()
+	if err != nil {
+		t.Fatalf("LoginWithOIDC() failed: %v", err)
+	}
+	if me, err := sso.Me().Get(); err != nil || me.ID != int(dave.ID) {
+		t.Errorf("Me().Get() = %+v, %v, want user %d", me, err, dave.ID)
+	}
+
+	// 已关联的身份不能再关联到其他用户，同一 IdP 也只能关联一个身份。
+	createLocalUser(t, db, "erin", "erin@example.com", "erin password 1")
+	erin, err := sdk.NewClient(addr).LoginWithUsername("erin", "erin password 1")
+	if err != nil {
+		t.Fatalf("LoginWithUsername() failed: %v", err)
+	}
+	_, err = erin.Me().LinkOIDC()
+	wantStatus(t, err, http.StatusConflict)
+	provider.SignIn(idp.User{Subject: "another-dave-sub", PreferredUsername: "dave2"})
+	_, err = client.Me().LinkOIDC()
+	wantStatus(t, err, http.StatusConflict)
+}
+
+func TestOIDCLoginThrottle(t *testing.T) {
+	provider := newIdP(t)
+	db := openDatabase(t)
+	throttle := config.DefaultLoginThrottle
+	throttle.AccountMaxFailures, throttle.IPMaxFailures = 2, 3
+	addr := startOIDCServer(t, db, provider, false, server.WithLoginThrottle(throttle))
+	dave := createLocalUser(t, db, "dave", "dave@example.com", "dave password 1")
+	if err := db.Create(&models.UserIdentity{UserID: dave.ID, Issuer: provider.Issuer(), Subject: "dave-sub"}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	// 密码登录失败导致账号被锁定后，也不能通过单点登录登录。
+	for range 2 {
+		_, err := sdk.NewClient(addr).LoginWithUsername("dave", "wrong password")
+		wantStatus(t, err, http.StatusUnauthorized)
+	}
+	provider.SignIn(idp.User{Subject: "dave-sub", PreferredUsername: "dave"})
+	_, err := sdk.NewClient(addr).LoginWithOIDC()
+	wantStatus(t, err, http.StatusTooManyRequests)
+
+	// 未开通账号的身份计入客户端 IP 的失败次数，IP 被锁定后任何身份都不能登录。
+	provider.SignIn(idp.User{Subject: "frank-sub", PreferredUsername: "frank"})
+	_, err = sdk.NewClient(addr).LoginWithOIDC()
+	wantStatus(t, err, http.StatusForbidden)
+	_, err = sdk.NewClient(addr).LoginWithOIDC()
+	wantStatus(t, err, http.StatusTooManyRequests)
+}
+
+func TestOIDCRejectsForgedCallback(t *testing.T) {
+	provider := newIdP(t)
+	addr := startOIDCServer(t, openDatabase(t), provider, true)
+
+	// IdP 拒绝登录。
+	provider.SignOut()
+	_, err := sdk.NewClient(addr).LoginWithOIDC()
+	wantStatus(t, err, http.StatusUnauthorized)
+
+	// 回调中的 state 与本浏览器发起的登录不一致，如被诱导访问他人构造的回调地址。
+	jar, _ := cookiejar.New(nil)
+	client := &http.Client{Jar: jar, CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	resp, err := client.Get(addr + "/api/login/oidc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusFound {
+		t.Fatalf("GET /api/login/oidc = %d, want 302", resp.StatusCode)
+	}
+	resp, err = client.Get(addr + "/api/login/oidc/callback?code=forged&state=forged")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("callback with a forged state = %d, want 400", resp.StatusCode)
+	}
+}